@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/agent"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// batchInput 批量运行的单个输入，模板中可引用 {{.Input}} 和 {{.Index}}
+type batchInput struct {
+	Index int
+	Input string
+}
+
+// batchResult 单个输入的运行结果
+type batchResult struct {
+	Index      int    `json:"index"`
+	Input      string `json:"input"`
+	Prompt     string `json:"prompt"`
+	Status     string `json:"status"`
+	Result     string `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Workspace  string `json:"workspace,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// batchRunFunc 执行单个提示，返回最终结果和工作目录
+type batchRunFunc func(ctx context.Context, prompt string) (result string, workspace string, err error)
+
+// runBatchCommand 执行batch子命令，对每个输入按模板生成提示并运行智能体
+func runBatchCommand(args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	templatePath := fs.String("template", "", "提示模板文件，可引用 {{.Input}} 和 {{.Index}}")
+	inputsPath := fs.String("inputs", "-", "输入列表文件（每行一个或JSON数组），- 表示标准输入")
+	output := fs.String("output", "batch_results.jsonl", "结果输出：.jsonl 结尾时写入单个文件，否则写入目录")
+	concurrency := fs.Int("concurrency", 1, "同时运行的输入数量")
+	agentName := fs.String("agent", agent.DefaultProfileName, "使用的智能体档案名称")
+	envFile := fs.String("env-file", config.DefaultEnvFile, "启动时加载的环境变量文件（不覆盖已设置的变量）")
+	if err := fs.Parse(args); err != nil {
+		return ExitConfig
+	}
+	if *templatePath == "" || *concurrency < 1 {
+		fmt.Fprintln(os.Stderr, "用法: gomanus batch --template <文件> [--inputs <文件>] [--output <目录|文件.jsonl>] [--concurrency N] [--agent 档案]")
+		return ExitConfig
+	}
+
+	envFileSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "env-file" {
+			envFileSet = true
+		}
+	})
+	if err := config.LoadEnvFile(*envFile, envFileSet); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return ExitConfig
+	}
+
+	tmplData, err := os.ReadFile(*templatePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取提示模板失败: %v\n", err)
+		return ExitFailure
+	}
+	tmpl, err := template.New("batch").Option("missingkey=error").Parse(string(tmplData))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "解析提示模板失败: %v\n", err)
+		return ExitFailure
+	}
+
+	inputs, err := readBatchInputs(*inputsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return ExitFailure
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintln(os.Stderr, "输入列表为空")
+		return ExitFailure
+	}
+
+	if err := initLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化日志失败: %v\n", err)
+		return ExitFailure
+	}
+	defer logger.Sync()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 每个输入使用独立的智能体实例和工作目录
+	run := func(ctx context.Context, prompt string) (string, string, error) {
+		manus, err := agent.NewManusFromProfile(*agentName)
+		if err != nil {
+			return "", "", err
+		}
+		manus.IsolatedWorkspace = true
+		err = manus.Run(ctx, prompt)
+		return manus.GetFinalResult(), manus.GetWorkspace(), err
+	}
+
+	logger.Info("开始批量运行",
+		zap.Int("inputs", len(inputs)),
+		zap.Int("concurrency", *concurrency))
+
+	results := runBatch(ctx, tmpl, inputs, *concurrency, run)
+	if err := writeBatchResults(*output, results); err != nil {
+		fmt.Fprintf(os.Stderr, "写入结果失败: %v\n", err)
+		return ExitFailure
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != "ok" {
+			failed++
+		}
+	}
+	fmt.Printf("批量运行完成: %d 成功, %d 失败, 结果已写入 %s\n", len(results)-failed, failed, *output)
+	if failed > 0 {
+		return ExitFailure
+	}
+	return ExitOK
+}
+
+// readBatchInputs 读取输入列表，内容为JSON数组时按数组解析，否则每个非空行为一个输入
+func readBatchInputs(path string) ([]string, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("打开输入列表失败: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取输入列表失败: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var items []interface{}
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, fmt.Errorf("解析JSON输入列表失败: %w", err)
+		}
+		inputs := make([]string, 0, len(items))
+		for _, item := range items {
+			// 非字符串元素以JSON形式传给模板
+			if str, ok := item.(string); ok {
+				inputs = append(inputs, str)
+				continue
+			}
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return nil, fmt.Errorf("编码输入失败: %w", err)
+			}
+			inputs = append(inputs, string(encoded))
+		}
+		return inputs, nil
+	}
+
+	var inputs []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			inputs = append(inputs, line)
+		}
+	}
+	return inputs, scanner.Err()
+}
+
+// runBatch 并发运行所有输入，结果按输入顺序返回
+func runBatch(ctx context.Context, tmpl *template.Template, inputs []string, concurrency int, run batchRunFunc) []batchResult {
+	results := make([]batchResult, len(inputs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, input := range inputs {
+		results[i] = batchResult{Index: i, Input: input}
+
+		var prompt bytes.Buffer
+		if err := tmpl.Execute(&prompt, batchInput{Index: i, Input: input}); err != nil {
+			results[i].Status = "error"
+			results[i].Error = fmt.Sprintf("渲染提示模板失败: %v", err)
+			continue
+		}
+		results[i].Prompt = prompt.String()
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Status = "error"
+			results[i].Error = ctx.Err().Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *batchResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			output, workspace, err := run(ctx, result.Prompt)
+			result.DurationMs = time.Since(start).Milliseconds()
+			result.Result = output
+			result.Workspace = workspace
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+				logger.Error("批量运行输入失败", zap.Int("index", result.Index), zap.Error(err))
+				return
+			}
+			result.Status = "ok"
+			logger.Info("批量运行输入完成", zap.Int("index", result.Index))
+		}(&results[i])
+	}
+
+	wg.Wait()
+	return results
+}
+
+// writeBatchResults 写入运行结果，.jsonl 结尾时写入单个文件，否则每个输入写入目录中的 <序号>.json
+func writeBatchResults(output string, results []batchResult) error {
+	if strings.HasSuffix(output, ".jsonl") {
+		if dir := filepath.Dir(output); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		encoder := json.NewEncoder(file)
+		for _, result := range results {
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+	for _, result := range results {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(output, fmt.Sprintf("%04d.json", result.Index))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+)
+
+func TestRunBatchWritesOneRecordPerInput(t *testing.T) {
+	tmpl := template.Must(template.New("batch").Option("missingkey=error").Parse("第{{.Index}}项: 总结 {{.Input}}"))
+	inputs := []string{"go.dev", "rust-lang.org", "python.org"}
+
+	var mu sync.Mutex
+	var prompts []string
+	run := func(ctx context.Context, prompt string) (string, string, error) {
+		mu.Lock()
+		prompts = append(prompts, prompt)
+		mu.Unlock()
+		if strings.Contains(prompt, "rust") {
+			return "", "/tmp/ws-rust", errors.New("模型调用失败")
+		}
+		return "摘要: " + prompt, "/tmp/ws", nil
+	}
+
+	results := runBatch(context.Background(), tmpl, inputs, 2, run)
+	output := filepath.Join(t.TempDir(), "out", "results.jsonl")
+	if err := writeBatchResults(output, results); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var records []batchResult
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 3 || len(prompts) != 3 {
+		t.Fatalf("%d records for %d runs, want 3", len(records), len(prompts))
+	}
+	for i, record := range records {
+		if record.Index != i || record.Input != inputs[i] || record.Prompt != fmt.Sprintf("第%d项: 总结 %s", i, inputs[i]) {
+			t.Errorf("record %d = %+v", i, record)
+		}
+	}
+	if records[0].Status != "ok" || records[0].Result != "摘要: 第0项: 总结 go.dev" || records[0].Workspace != "/tmp/ws" {
+		t.Errorf("successful record = %+v", records[0])
+	}
+	if records[1].Status != "error" || records[1].Error != "模型调用失败" {
+		t.Errorf("failed record = %+v", records[1])
+	}
+}
+
+func TestWriteBatchResultsToDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "results")
+	results := []batchResult{{Index: 0, Status: "ok"}, {Index: 1, Status: "error"}}
+	if err := writeBatchResults(dir, results); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0000.json", "0001.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestReadBatchInputs(t *testing.T) {
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "inputs.txt")
+	os.WriteFile(lines, []byte("first\n\n  second  \nthird\n"), 0644)
+	array := filepath.Join(dir, "inputs.json")
+	os.WriteFile(array, []byte(`["a", {"url": "b"}, 3]`), 0644)
+
+	if inputs, err := readBatchInputs(lines); err != nil || strings.Join(inputs, "|") != "first|second|third" {
+		t.Errorf("line inputs = %q, %v", inputs, err)
+	}
+	if inputs, err := readBatchInputs(array); err != nil || strings.Join(inputs, "|") != `a|{"url":"b"}|3` {
+		t.Errorf("JSON inputs = %q, %v", inputs, err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// runConfigCommand 执行config子命令，返回进程退出码
+func runConfigCommand(args []string) int {
+	if err := config.LoadEnvFile(config.DefaultEnvFile, false); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return ExitConfig
+	}
+
+	if len(args) == 0 {
+		printConfigUsage()
+		return ExitConfig
+	}
+
+	switch args[0] {
+	case "show":
+		return runConfigShow(args[1:])
+	case "get":
+		return runConfigGet(args[1:])
+	case "set":
+		return runConfigSet(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "未知的config子命令: %s\n", args[0])
+		printConfigUsage()
+		return ExitConfig
+	}
+}
+
+// printConfigUsage 输出config子命令用法
+func printConfigUsage() {
+	fmt.Fprintln(os.Stderr, "用法: gomanus config <子命令>")
+	fmt.Fprintln(os.Stderr, "  show [--format toml|json]  输出合并环境变量覆盖后的生效配置（敏感字段已屏蔽）")
+	fmt.Fprintln(os.Stderr, "  get <key>                  输出单个配置值，如 llm.default.model")
+	fmt.Fprintln(os.Stderr, "  set <key> <value>          修改配置文件中的单个值，保留其余内容和注释")
+}
+
+// runConfigShow 输出生效配置
+func runConfigShow(args []string) int {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	format := fs.String("format", "toml", "输出格式: toml, json")
+	if err := fs.Parse(args); err != nil {
+		return ExitConfig
+	}
+
+	cfg := config.GetConfig()
+	settings := cfg.EffectiveSettings()
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"source": cfg.ConfigFile(),
+			"config": settings,
+		}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "编码配置失败: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Println(string(data))
+	case "toml":
+		data, err := toml.Marshal(settings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "编码配置失败: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Printf("# 配置文件: %s\n\n%s", cfg.ConfigFile(), data)
+	default:
+		fmt.Fprintf(os.Stderr, "不支持的输出格式: %s\n", *format)
+		return ExitConfig
+	}
+	return ExitOK
+}
+
+// runConfigGet 输出单个生效配置值
+func runConfigGet(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "用法: gomanus config get <key>")
+		return ExitConfig
+	}
+
+	value, ok := config.GetConfig().Get(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "配置项不存在: %s\n", args[0])
+		return ExitFailure
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "编码配置失败: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Println(v)
+	}
+	return ExitOK
+}
+
+// runConfigSet 修改配置文件中的单个值
+func runConfigSet(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "用法: gomanus config set <key> <value>")
+		return ExitConfig
+	}
+
+	cfg := config.GetConfig()
+	if err := cfg.Set(args[0], args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "修改配置失败: %v\n", err)
+		return ExitFailure
+	}
+	fmt.Printf("已更新 %s: %s\n", cfg.ConfigFile(), args[0])
+	return ExitOK
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/yahao333/GoManus/pkg/agent"
+)
+
+// 进程退出码，脚本可据此区分失败类别
+const (
+	// ExitOK 成功
+	ExitOK = 0
+	// ExitFailure 其他失败
+	ExitFailure = 1
+	// ExitConfig 命令行用法错误或配置无效（环境变量文件、智能体档案、输出Schema等）
+	ExitConfig = 2
+	// ExitLLM LLM请求失败
+	ExitLLM = 3
+	// ExitTool 工具执行失败
+	ExitTool = 4
+	// ExitCancelled 运行被取消
+	ExitCancelled = 5
+	// ExitBudget 超出运行预算
+	ExitBudget = 6
+	// ExitMaxSteps 达到最大步骤数时任务仍未完成
+	ExitMaxSteps = 7
+)
+
+// exitCode 将运行错误映射为进程退出码
+// 预算超限和取消优先判断，它们可能与LLM或工具失败同时出现在错误链中
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, agent.ErrBudgetExceeded):
+		return ExitBudget
+	case errors.Is(err, agent.ErrCancelled):
+		return ExitCancelled
+	case errors.Is(err, agent.ErrLLMFailure):
+		return ExitLLM
+	case errors.Is(err, agent.ErrToolFailure):
+		return ExitTool
+	case errors.Is(err, agent.ErrMaxSteps):
+		return ExitMaxSteps
+	default:
+		return ExitFailure
+	}
+}
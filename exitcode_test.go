@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/agent"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/tool"
+)
+
+// crashTool 执行时崩溃的测试工具
+type crashTool struct {
+	tool.BaseTool
+}
+
+// Execute 模拟工具崩溃
+func (c *crashTool) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	panic("crash")
+}
+
+// mockConfig 默认LLM使用mock提供者并按顺序返回 responses 的配置
+func mockConfig(responses ...config.MockResponse) string {
+	var builder strings.Builder
+	builder.WriteString(baseTestConfig)
+	for _, response := range responses {
+		fmt.Fprintf(&builder, "\n[[llm.default.mock_responses]]\ncontent = %s\ntool = %s\narguments = %s\n",
+			strconv.Quote(response.Content), strconv.Quote(response.Tool), strconv.Quote(response.Arguments))
+	}
+	return builder.String()
+}
+
+func TestRunAgentExitCodes(t *testing.T) {
+	progress := config.MockResponse{Tool: "ReportProgress", Arguments: `{"status": "处理中", "percent": 50}`}
+	tests := []struct {
+		name   string
+		config string
+		setup  func(ctx context.Context, manus *agent.Manus) context.Context
+		want   int
+	}{
+		{"success", mockConfig(config.MockResponse{Tool: "Terminate", Arguments: `{"message": "完成"}`}), nil, ExitOK},
+		{"llm", `[llm.default]
+model = "gpt-4o"
+api_key = "sk-test"
+api_type = "openai"
+base_url = "http://127.0.0.1:1/v1"
+`, nil, ExitLLM},
+		{"tool", mockConfig(config.MockResponse{Tool: "Crash", Arguments: `{}`}), func(ctx context.Context, manus *agent.Manus) context.Context {
+			manus.AvailableTools.AddTool(&crashTool{tool.BaseTool{Name: "Crash", Parameters: map[string]interface{}{}}})
+			return ctx
+		}, ExitTool},
+		{"cancelled", mockConfig(), func(ctx context.Context, manus *agent.Manus) context.Context {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+			return ctx
+		}, ExitCancelled},
+		{"budget", mockConfig(progress, progress), func(ctx context.Context, manus *agent.Manus) context.Context {
+			manus.Budget = &agent.Budget{MaxToolCalls: 1}
+			return ctx
+		}, ExitBudget},
+		{"max steps", mockConfig(config.MockResponse{Content: "继续处理"}), func(ctx context.Context, manus *agent.Manus) context.Context {
+			manus.MaxSteps = 1
+			return ctx
+		}, ExitMaxSteps},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testconfig.Use(t, tt.config)
+			manus, err := agent.NewManus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if tt.setup != nil {
+				ctx = tt.setup(ctx, manus)
+			}
+
+			var code int
+			captureStdout(t, func() { code = runAgent(ctx, manus, "任务") })
+			if code != tt.want {
+				t.Errorf("exit code = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigErrorsExitWithConfigCode(t *testing.T) {
+	for _, args := range [][]string{
+		{"list", "--agent", "missing"},
+		{"list", "--env-file", "missing.env"},
+		{"unknown"},
+	} {
+		if code := runToolsCommand(args); code != ExitConfig {
+			t.Errorf("tools %v exit code = %d, want %d", args, code, ExitConfig)
+		}
+	}
+}
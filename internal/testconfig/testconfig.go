@@ -0,0 +1,66 @@
+// Package testconfig 为各包的测试提供临时配置文件
+// 测试在临时目录中运行，配置单例从该目录下的 config/config.toml 读取，各测试通过 Use 替换配置
+package testconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	// path 配置文件路径
+	path string
+	// base 测试默认使用的配置，Use 替换后在测试结束时恢复
+	base string
+	// reload 让配置单例重新读取配置文件，由调用方传入以免与 config 包循环引用
+	reload func() error
+)
+
+// Run 在临时目录中写入默认配置并切换到该目录后运行测试，结束后删除临时目录，返回退出码
+// 在各包的 TestMain 中调用；reloadConfig 应在调用时才获取配置单例，单例在切换目录后才首次加载
+func Run(m *testing.M, prefix, baseConfig string, reloadConfig func() error) int {
+	dir, err := os.MkdirTemp("", prefix)
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path = filepath.Join(dir, "config", "config.toml")
+	base = baseConfig
+	reload = reloadConfig
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(path, []byte(base), 0644); err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+
+	return m.Run()
+}
+
+// Path 获取测试配置文件路径
+func Path() string {
+	return path
+}
+
+// Use 替换配置文件并重新加载，测试结束后恢复默认配置
+func Use(t testing.TB, content string) {
+	t.Helper()
+	Write(t, content)
+	t.Cleanup(func() { Write(t, base) })
+}
+
+// Write 写入配置文件并重新加载
+func Write(t testing.TB, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reload(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"syscall"
 
 	"github.com/yahao333/GoManus/pkg/agent"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/llm"
 	"github.com/yahao333/GoManus/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -23,27 +26,66 @@ const (
 )
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(runConfigCommand(os.Args[2:]))
+		case "batch":
+			os.Exit(runBatchCommand(os.Args[2:]))
+		case "tools":
+			os.Exit(runToolsCommand(os.Args[2:]))
+		}
+	}
+
 	// 解析命令行参数
 	var (
-		prompt   string
-		showVer  bool
+		prompt     string
+		agentName  string
+		envFile    string
+		schemaFile string
+		seed       int
+		debugLLM   bool
+		showVer    bool
 	)
 	flag.StringVar(&prompt, "prompt", "", "输入提示")
+	flag.StringVar(&agentName, "agent", agent.DefaultProfileName, "使用的智能体档案名称")
+	flag.StringVar(&envFile, "env-file", config.DefaultEnvFile, "启动时加载的环境变量文件（不覆盖已设置的变量）")
+	flag.StringVar(&schemaFile, "output-schema", "", "最终答案必须符合的JSON Schema文件，结果以JSON输出")
+	flag.IntVar(&seed, "seed", 0, "LLM随机种子，用于复现运行结果（提供者支持时生效）")
+	flag.BoolVar(&debugLLM, "debug-llm", false, "在调试级别记录完整的LLM请求和响应（密钥已屏蔽）")
 	flag.BoolVar(&showVer, "version", false, "显示版本信息")
 	flag.Parse()
 
+	seedSet, envFileSet := false, false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "seed":
+			seedSet = true
+		case "env-file":
+			envFileSet = true
+		}
+	})
+
+	// 加载环境变量文件，需在读取配置之前完成；显式指定的文件必须存在
+	if err := config.LoadEnvFile(envFile, envFileSet); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(ExitConfig)
+	}
+
 	// 显示版本信息
 	if showVer {
 		fmt.Printf("GoManus v%s\n", Version)
 		fmt.Printf("构建时间: %s\n", BuildTime)
 		fmt.Printf("Git提交: %s\n", GitCommit)
-		os.Exit(0)
+		os.Exit(ExitOK)
 	}
 
 	// 初始化日志
-	if err := logger.InitLogger("logs/gomanus.log", zap.InfoLevel); err != nil {
+	llm.SetDebug(debugLLM)
+	if err := initLogging(); err != nil {
 		fmt.Fprintf(os.Stderr, "初始化日志失败: %v\n", err)
-		os.Exit(1)
+		os.Exit(ExitFailure)
 	}
 	defer logger.Sync()
 
@@ -54,13 +96,13 @@ func main() {
 		fmt.Print("请输入您的提示: ")
 		if _, err := fmt.Scanln(&prompt); err != nil {
 			logger.Error("读取用户输入失败", zap.Error(err))
-			os.Exit(1)
+			os.Exit(ExitFailure)
 		}
 	}
 
 	if prompt == "" {
 		logger.Warn("空提示提供")
-		os.Exit(0)
+		os.Exit(ExitOK)
 	}
 
 	// 创建上下文
@@ -76,20 +118,91 @@ func main() {
 		cancel()
 	}()
 
-	// 创建Manus智能体
-	manus, err := agent.NewManus()
+	// 根据档案创建Manus智能体
+	manus, err := agent.NewManusFromProfile(agentName)
 	if err != nil {
 		logger.Error("创建Manus智能体失败", zap.Error(err))
-		os.Exit(1)
+		os.Exit(ExitConfig)
+	}
+
+	if seedSet {
+		if err := manus.SetSeed(seed); err != nil {
+			logger.Error("设置随机种子失败", zap.Error(err))
+			os.Exit(ExitConfig)
+		}
+	}
+
+	if schemaFile != "" {
+		outputSchema, err := loadOutputSchema(schemaFile)
+		if err != nil {
+			logger.Error("加载输出Schema失败", zap.Error(err))
+			os.Exit(ExitConfig)
+		}
+		manus.SetOutputSchema(outputSchema)
+	}
+
+	manus.OnProgress = func(progress agent.Progress) {
+		fmt.Fprintf(os.Stderr, "[%3.0f%%] %s\n", progress.Percent, progress.Status)
 	}
 
+	manus.OnWorkspaceDiff = printWorkspaceDiff
+
+	code := runAgent(ctx, manus, prompt)
+	logger.Sync()
+	os.Exit(code)
+}
+
+// runAgent 运行智能体并输出最终结果，返回按失败类别区分的进程退出码
+func runAgent(ctx context.Context, manus *agent.Manus, prompt string) int {
 	logger.Info("处理您的请求...")
 
-	// 运行智能体
 	if err := manus.Run(ctx, prompt); err != nil {
 		logger.Error("运行智能体失败", zap.Error(err))
-		os.Exit(1)
+		return exitCode(err)
+	}
+
+	if result := manus.GetFinalResult(); result != "" {
+		fmt.Println(result)
 	}
 
 	logger.Info("请求处理完成")
-}
\ No newline at end of file
+	return ExitOK
+}
+
+// loadOutputSchema 读取JSON Schema文件
+func loadOutputSchema(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var outputSchema map[string]interface{}
+	if err := json.Unmarshal(data, &outputSchema); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+	return outputSchema, nil
+}
+
+// printWorkspaceDiff 在标准错误输出本次运行创建、修改和删除的文件
+func printWorkspaceDiff(diff agent.WorkspaceDiff) {
+	if diff.Empty() {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "工作目录改动:")
+	for _, group := range []struct {
+		mark    string
+		changes []agent.FileChange
+	}{{"+", diff.Created}, {"~", diff.Modified}, {"-", diff.Deleted}} {
+		for _, change := range group.changes {
+			fmt.Fprintf(os.Stderr, "  %s %s (%d 字节)\n", group.mark, change.Path, change.Size)
+		}
+	}
+}
+
+// initLogging 初始化日志，开启LLM调试时使用调试级别
+func initLogging() error {
+	logLevel := zap.InfoLevel
+	if llm.DebugEnabled() {
+		logLevel = zap.DebugLevel
+	}
+	return logger.InitLogger("logs/gomanus.log", logLevel)
+}
@@ -2,10 +2,11 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
-	"github.com/google/uuid"
+	"github.com/yahao333/GoManus/pkg/config"
 	"github.com/yahao333/GoManus/pkg/llm"
 	"github.com/yahao333/GoManus/pkg/logger"
 	"github.com/yahao333/GoManus/pkg/schema"
@@ -49,6 +50,29 @@ type Agent struct {
 	MaxSteps         int
 	CurrentStep      int
 	DuplicateThreshold int
+	Budget           *Budget
+	// Processes 工具在后台启动的进程，清理时全部结束
+	Processes        *tool.ProcessRegistry
+	MemoryStrategy   MemoryStrategy
+	Examples         []schema.Message
+	// OutputSchema 最终答案必须符合的JSON Schema，OutputAttempts 为不符合时的重新生成次数
+	OutputSchema     map[string]interface{}
+	OutputAttempts   int
+	// OnProgress 收到运行进度时回调
+	OnProgress       func(Progress)
+	// OnWorkspaceDiff 运行结束时回调本次运行对工作目录的改动
+	OnWorkspaceDiff  func(WorkspaceDiff)
+	Workspace        string
+	IsolatedWorkspace bool
+	// inheritWorkspace 使用上下文中已有的工作目录（子智能体与父智能体共用）
+	inheritWorkspace bool
+	FinalResult      string
+	terminated       bool
+	progress         Progress
+	// progressMu 保护progress，与智能体锁分开以便工具执行期间更新
+	progressMu       sync.Mutex
+	structuredResult interface{}
+	workspaceDiff    WorkspaceDiff
 	
 	mu               sync.RWMutex
 	ctx              context.Context
@@ -63,8 +87,22 @@ func NewAgent(name, description, systemPrompt, nextStepPrompt string) (*Agent, e
 		return nil, fmt.Errorf("创建LLM客户端失败: %w", err)
 	}
 
+	var budget *Budget
+	var strategySettings *config.MemoryStrategySettings
+	outputAttempts := 0
+	if settings := config.GetConfig().GetAgentSettings(); settings != nil {
+		budget = NewBudget(settings.Budget)
+		strategySettings = settings.MemoryStrategy
+		outputAttempts = settings.OutputAttempts
+	}
+
+	memoryStrategy, err := NewMemoryStrategy(strategySettings)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Agent{
-		ID:               uuid.New().String(),
+		ID:               schema.NewID(),
 		Name:             name,
 		Description:      description,
 		SystemPrompt:     systemPrompt,
@@ -76,6 +114,10 @@ func NewAgent(name, description, systemPrompt, nextStepPrompt string) (*Agent, e
 		MaxSteps:         10,
 		CurrentStep:      0,
 		DuplicateThreshold: 2,
+		Budget:           budget,
+		Processes:        tool.NewProcessRegistry(),
+		MemoryStrategy:   memoryStrategy,
+		OutputAttempts:   outputAttempts,
 	}, nil
 }
 
@@ -104,12 +146,57 @@ func (a *Agent) GetState() schema.AgentState {
 func (a *Agent) SetState(state schema.AgentState) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.setStateLocked(state)
+}
+
+// setStateLocked 在已持有锁时设置智能体状态
+func (a *Agent) setStateLocked(state schema.AgentState) {
 	a.State = state
 	logger.Info("智能体状态变更", 
 		zap.String("agent", a.Name),
 		zap.String("state", string(state)))
 }
 
+// SetSeed 为智能体的LLM设置随机种子，使支持的提供者生成可复现的结果
+func (a *Agent) SetSeed(seed int) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	llmClient, err := a.LLM.WithSettings(llm.SettingsOverride{Seed: &seed})
+	if err != nil {
+		return fmt.Errorf("设置随机种子失败: %w", err)
+	}
+	a.LLM = llmClient
+	return nil
+}
+
+// runFields 运行开始时记录的元数据
+func (a *Agent) runFields(prompt string) []zap.Field {
+	fields := []zap.Field{
+		zap.String("agent", a.Name),
+		zap.String("prompt", prompt),
+	}
+	if seed := a.LLM.GetSettings().Seed; seed != nil {
+		fields = append(fields, zap.Int("seed", *seed))
+	}
+	return fields
+}
+
+// GetCurrentStep 获取当前步骤
+func (a *Agent) GetCurrentStep() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.CurrentStep
+}
+
+// incrementStep 步骤加一并返回新的步骤数
+func (a *Agent) incrementStep() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.CurrentStep++
+	return a.CurrentStep
+}
+
 // GetMemory 获取内存
 func (a *Agent) GetMemory() *schema.Memory {
 	a.mu.RLock()
@@ -141,7 +228,7 @@ func (a *Agent) Initialize(ctx context.Context) error {
 	}
 
 	a.ctx, a.cancel = context.WithCancel(ctx)
-	a.State = schema.AgentStateIdle
+	a.setStateLocked(schema.AgentStateIdle)
 
 	// 添加系统消息
 	if a.SystemPrompt != "" {
@@ -167,7 +254,7 @@ func (a *Agent) ProcessMessage(ctx context.Context, message schema.Message) (*sc
 	// 生成响应
 	response, err := a.generateResponse(ctx)
 	if err != nil {
-		a.State = schema.AgentStateError
+		a.setStateLocked(schema.AgentStateError)
 		return nil, fmt.Errorf("生成响应失败: %w", err)
 	}
 
@@ -192,24 +279,23 @@ func (a *Agent) Run(ctx context.Context, prompt string) error {
 	userMessage := schema.NewUserMessage(prompt)
 	a.Memory.AddMessage(userMessage)
 
-	logger.Info("开始运行智能体", 
-		zap.String("agent", a.Name),
-		zap.String("prompt", prompt))
+	logger.Info("开始运行智能体", a.runFields(prompt)...)
 
 	// 执行步骤循环
-	for a.CurrentStep < a.MaxSteps {
+	completed := false
+	for !completed && a.GetCurrentStep() < a.MaxSteps {
 		select {
 		case <-a.ctx.Done():
-			return fmt.Errorf("智能体运行被取消")
+			return cancelledError(ctx)
 		case <-ctx.Done():
-			return fmt.Errorf("上下文被取消")
+			return cancelledError(ctx)
 		default:
 		}
 
-		a.CurrentStep++
+		step := a.incrementStep()
 		logger.Info("执行步骤", 
 			zap.String("agent", a.Name),
-			zap.Int("step", a.CurrentStep),
+			zap.Int("step", step),
 			zap.Int("max_steps", a.MaxSteps))
 
 		// 生成响应
@@ -225,20 +311,23 @@ func (a *Agent) Run(ctx context.Context, prompt string) error {
 		// 检查是否完成任务
 		if a.isTaskComplete(response) {
 			logger.Info("任务完成", zap.String("agent", a.Name))
+			completed = true
 			break
 		}
 
 		// 检查重复响应
 		if a.isDuplicateResponse(response) {
 			logger.Warn("检测到重复响应", zap.String("agent", a.Name))
+			completed = true
 			break
 		}
 	}
 
-	if a.CurrentStep >= a.MaxSteps {
+	if !completed {
 		logger.Warn("达到最大步骤限制", 
 			zap.String("agent", a.Name),
 			zap.Int("max_steps", a.MaxSteps))
+		return maxStepsError(a.MaxSteps)
 	}
 
 	return nil
@@ -253,7 +342,10 @@ func (a *Agent) Cleanup(ctx context.Context) error {
 		a.cancel()
 	}
 
-	a.State = schema.AgentStateIdle
+	// 结束工具启动且仍在运行的后台进程
+	a.Processes.Cleanup()
+
+	a.setStateLocked(schema.AgentStateIdle)
 	logger.Info("智能体清理完成", zap.String("agent", a.Name))
 	return nil
 }
@@ -273,6 +365,20 @@ func (a *Agent) UpdateMemory(role schema.Role, content string, base64Image ...st
 	return nil
 }
 
+// GetFinalResult 获取运行的最终结果
+func (a *Agent) GetFinalResult() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.FinalResult
+}
+
+// setFinalResult 设置运行的最终结果
+func (a *Agent) setFinalResult(result string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.FinalResult = result
+}
+
 // GetSystemPrompt 获取系统提示
 func (a *Agent) GetSystemPrompt() string {
 	a.mu.RLock()
@@ -292,13 +398,57 @@ func (a *Agent) generateResponse(ctx context.Context) (*schema.Message, error) {
 	// 获取工具定义
 	toolDefs := a.AvailableTools.GetDefinitions()
 
-	// 生成响应
-	response, err := a.LLM.GenerateResponse(ctx, a.Memory.GetRecentMessages(20), toolDefs)
-	if err != nil {
-		return nil, err
-	}
+	return a.requestResponse(ctx, toolDefs)
+}
 
-	return response, nil
+// maxLengthRetries 响应因长度限制被截断时提高max_tokens重试的次数
+const maxLengthRetries = 1
+
+// requestResponse 请求LLM响应并记录预算用量
+// 响应因长度限制被截断时提高max_tokens重试，被内容过滤拦截时返回明确的错误
+func (a *Agent) requestResponse(ctx context.Context, toolDefs []schema.ToolDefinition) (*schema.Message, error) {
+	return a.requestResponseWith(ctx, a.LLM, toolDefs)
+}
+
+// requestResponseWith 使用指定的LLM客户端请求响应
+func (a *Agent) requestResponseWith(ctx context.Context, client *llm.LLM, toolDefs []schema.ToolDefinition) (*schema.Message, error) {
+	for attempt := 0; ; attempt++ {
+		response, err := client.GenerateResponse(ctx, a.withExamples(a.MemoryStrategy.Select(a.Memory.Messages)), toolDefs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %w", ErrCancelled, err)
+			}
+			if errors.Is(err, llm.ErrContentFiltered) {
+				return nil, fmt.Errorf("%w: 模型响应被内容过滤拦截，请调整任务描述后重试: %w", ErrLLMFailure, err)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrLLMFailure, err)
+		}
+
+		// 检查运行预算
+		if err := a.Budget.RecordLLMUsage(response.Usage); err != nil {
+			return nil, err
+		}
+
+		if response.FinishReason != schema.FinishReasonLength {
+			return response, nil
+		}
+
+		maxTokens := client.GetSettings().MaxTokens * 2
+		if attempt >= maxLengthRetries || maxTokens <= 0 {
+			logger.Warn("响应因长度限制被截断",
+				zap.String("agent", a.Name),
+				zap.Int("max_tokens", client.GetSettings().MaxTokens))
+			return response, nil
+		}
+
+		logger.Warn("响应因长度限制被截断，提高max_tokens后重试",
+			zap.String("agent", a.Name),
+			zap.Int("max_tokens", maxTokens))
+		client, err = client.WithSettings(llm.SettingsOverride{MaxTokens: &maxTokens})
+		if err != nil {
+			return response, nil
+		}
+	}
 }
 
 // isTaskComplete 检查任务是否完成
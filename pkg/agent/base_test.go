@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// scriptedOpenAI 按顺序返回给定结束原因的OpenAI兼容服务，记录收到的请求
+type scriptedOpenAI struct {
+	*httptest.Server
+	// toolCalls 每个响应附带的工具调用
+	toolCalls []openai.ToolCall
+	requests  []openai.ChatCompletionRequest
+	mu        sync.Mutex
+}
+
+// newScriptedOpenAI 启动模拟服务并将默认LLM指向它，第n次请求以 reasons[n] 结束，用尽后重复最后一个
+func newScriptedOpenAI(t *testing.T, reasons ...openai.FinishReason) *scriptedOpenAI {
+	t.Helper()
+	fake := &scriptedOpenAI{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		fake.mu.Lock()
+		n := len(fake.requests)
+		fake.requests = append(fake.requests, req)
+		toolCalls := fake.toolCalls
+		fake.mu.Unlock()
+
+		reason := reasons[min(n, len(reasons)-1)]
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "回复: " + string(reason), ToolCalls: toolCalls},
+			FinishReason: reason,
+		}}})
+	}))
+	t.Cleanup(fake.Close)
+
+	testconfig.Use(t, fake.Config())
+	return fake
+}
+
+// Config 默认LLM指向模拟服务的配置
+func (f *scriptedOpenAI) Config() string {
+	return `[llm.default]
+model = "gpt-4o"
+api_key = "sk-test"
+api_type = "openai"
+max_tokens = 100
+base_url = "` + f.URL + `/v1"
+`
+}
+
+// Requests 返回收到的请求
+func (f *scriptedOpenAI) Requests() []openai.ChatCompletionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), f.requests...)
+}
+
+func TestFinishReasonHandling(t *testing.T) {
+	tests := []struct {
+		name      string
+		reasons   []openai.FinishReason
+		content   string
+		maxTokens []int
+		filtered  bool
+	}{
+		{"stop", []openai.FinishReason{openai.FinishReasonStop}, "回复: stop", []int{100}, false},
+		{"tool calls", []openai.FinishReason{openai.FinishReasonToolCalls}, "回复: tool_calls", []int{100}, false},
+		// 被截断时提高max_tokens重试一次
+		{"length then stop", []openai.FinishReason{openai.FinishReasonLength, openai.FinishReasonStop}, "回复: stop", []int{100, 200}, false},
+		// 重试后仍被截断时返回截断的响应
+		{"length twice", []openai.FinishReason{openai.FinishReasonLength}, "回复: length", []int{100, 200}, false},
+		{"content filter", []openai.FinishReason{openai.FinishReasonContentFilter}, "", []int{100}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newScriptedOpenAI(t, tt.reasons...)
+			agent, err := NewToolCallAgent("finish", "", "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			agent.Memory.AddMessage(schema.NewUserMessage("你好"))
+
+			response, err := agent.requestResponse(context.Background(), nil)
+			if tt.filtered {
+				if err == nil || !strings.Contains(err.Error(), "内容过滤") {
+					t.Errorf("err = %v, want an error naming the content filter", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if *response.Content != tt.content {
+				t.Errorf("content = %q, want %q", *response.Content, tt.content)
+			}
+			var got []int
+			for _, req := range fake.Requests() {
+				got = append(got, req.MaxTokens)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.maxTokens) {
+				t.Errorf("max_tokens per request = %v, want %v", got, tt.maxTokens)
+			}
+		})
+	}
+}
+
+func TestSeedReachesRequestAndRunRecord(t *testing.T) {
+	fake := newScriptedOpenAI(t, openai.FinishReasonStop)
+	agent, err := NewToolCallAgent("seeded", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := agent.SetSeed(42); err != nil {
+		t.Fatal(err)
+	}
+	agent.Memory.AddMessage(schema.NewUserMessage("你好"))
+	if _, err := agent.requestResponse(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if seed := fake.Requests()[0].Seed; seed == nil || *seed != 42 {
+		t.Errorf("request seed = %v, want 42", seed)
+	}
+	// 运行开始时记录的元数据包含种子，便于复现
+	recorded := false
+	for _, field := range agent.runFields("你好") {
+		if field.Key == "seed" && field.Integer == 42 {
+			recorded = true
+		}
+	}
+	if !recorded {
+		t.Errorf("run fields %v do not record the seed", agent.runFields("你好"))
+	}
+}
+
+func TestNoSeedByDefault(t *testing.T) {
+	fake := newScriptedOpenAI(t, openai.FinishReasonStop)
+	agent, _ := NewToolCallAgent("unseeded", "", "", "")
+	agent.Memory.AddMessage(schema.NewUserMessage("你好"))
+	if _, err := agent.requestResponse(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if seed := fake.Requests()[0].Seed; seed != nil {
+		t.Errorf("request seed = %d, want none", *seed)
+	}
+}
+
+func TestAgentIDsUseInjectedGenerator(t *testing.T) {
+	defer schema.SetIDGenerator(schema.SequenceIDGenerator("agent"))()
+
+	first, err := NewToolCallAgent("first", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewToolCallAgent("second", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != "agent-1" || second.ID != "agent-2" {
+		t.Errorf("ids = %q, %q", first.ID, second.ID)
+	}
+}
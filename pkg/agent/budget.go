@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// ErrBudgetExceeded 超出运行预算
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// 预算限制类型
+const (
+	BudgetLimitTokens    = "tokens"
+	BudgetLimitCost      = "cost"
+	BudgetLimitToolCalls = "tool_calls"
+)
+
+// BudgetUsage 累计用量
+type BudgetUsage struct {
+	PromptTokens     int
+	CachedTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	Cost             float64
+	ToolCalls        int
+}
+
+// BudgetExceededError 预算超限错误，携带触发的限制和累计用量
+type BudgetExceededError struct {
+	Limit string
+	Usage BudgetUsage
+}
+
+// Error 实现error接口
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: %s (tokens=%d, cost=%.4f, tool_calls=%d)",
+		ErrBudgetExceeded, e.Limit, e.Usage.TotalTokens, e.Usage.Cost, e.Usage.ToolCalls)
+}
+
+// Unwrap 支持errors.Is(err, ErrBudgetExceeded)
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// Budget 运行预算，nil表示不限制
+type Budget struct {
+	MaxTokens              int
+	MaxCost                float64
+	MaxToolCalls           int
+	PromptPricePer1K       float64
+	CachedPromptPricePer1K float64
+	CompletionPricePer1K   float64
+
+	usage BudgetUsage
+	mu    sync.Mutex
+}
+
+// NewBudget 根据配置创建运行预算，未配置时返回nil
+func NewBudget(settings *config.BudgetSettings) *Budget {
+	if settings == nil {
+		return nil
+	}
+	return &Budget{
+		MaxTokens:              settings.MaxTokens,
+		MaxCost:                settings.MaxCost,
+		MaxToolCalls:           settings.MaxToolCalls,
+		PromptPricePer1K:       settings.PromptPricePer1K,
+		CachedPromptPricePer1K: settings.CachedPromptPricePer1K,
+		CompletionPricePer1K:   settings.CompletionPricePer1K,
+	}
+}
+
+// RecordLLMUsage 记录一次LLM调用的用量并检查预算
+func (b *Budget) RecordLLMUsage(usage *schema.Usage) error {
+	if b == nil || usage == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.usage.PromptTokens += usage.PromptTokens
+	b.usage.CachedTokens += usage.CachedTokens
+	b.usage.CompletionTokens += usage.CompletionTokens
+	b.usage.TotalTokens += usage.TotalTokens
+
+	// 命中提示缓存的输入令牌按缓存价格计费，未配置缓存价格时按普通输入价格
+	cachedPrice := b.CachedPromptPricePer1K
+	if cachedPrice <= 0 {
+		cachedPrice = b.PromptPricePer1K
+	}
+	b.usage.Cost += float64(usage.PromptTokens-usage.CachedTokens)/1000*b.PromptPricePer1K +
+		float64(usage.CachedTokens)/1000*cachedPrice +
+		float64(usage.CompletionTokens)/1000*b.CompletionPricePer1K
+
+	return b.checkLocked()
+}
+
+// RecordToolCall 记录一次工具执行并检查预算
+func (b *Budget) RecordToolCall() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.usage.ToolCalls++
+	return b.checkLocked()
+}
+
+// GetUsage 获取累计用量
+func (b *Budget) GetUsage() BudgetUsage {
+	if b == nil {
+		return BudgetUsage{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usage
+}
+
+// checkLocked 检查是否超出预算，调用方需持有锁
+func (b *Budget) checkLocked() error {
+	switch {
+	case b.MaxTokens > 0 && b.usage.TotalTokens > b.MaxTokens:
+		return &BudgetExceededError{Limit: BudgetLimitTokens, Usage: b.usage}
+	case b.MaxCost > 0 && b.usage.Cost > b.MaxCost:
+		return &BudgetExceededError{Limit: BudgetLimitCost, Usage: b.usage}
+	case b.MaxToolCalls > 0 && b.usage.ToolCalls > b.MaxToolCalls:
+		return &BudgetExceededError{Limit: BudgetLimitToolCalls, Usage: b.usage}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+func TestBudgetTripsEachLimit(t *testing.T) {
+	usage := &schema.Usage{PromptTokens: 800, CompletionTokens: 200, TotalTokens: 1000}
+
+	tests := []struct {
+		name   string
+		budget *Budget
+		record func(b *Budget) error
+		limit  string
+	}{
+		{
+			name:   "tokens",
+			budget: &Budget{MaxTokens: 1500},
+			record: func(b *Budget) error { return b.RecordLLMUsage(usage) },
+			limit:  BudgetLimitTokens,
+		},
+		{
+			// 每次调用 0.8*0.01 + 0.2*0.03 = 0.014
+			name:   "cost",
+			budget: &Budget{MaxCost: 0.02, PromptPricePer1K: 0.01, CompletionPricePer1K: 0.03},
+			record: func(b *Budget) error { return b.RecordLLMUsage(usage) },
+			limit:  BudgetLimitCost,
+		},
+		{
+			name:   "tool calls",
+			budget: &Budget{MaxToolCalls: 1},
+			record: func(b *Budget) error { return b.RecordToolCall() },
+			limit:  BudgetLimitToolCalls,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.record(tt.budget); err != nil {
+				t.Fatalf("first record within budget: %v", err)
+			}
+			err := tt.record(tt.budget)
+			var exceeded *BudgetExceededError
+			if !errors.As(err, &exceeded) || !errors.Is(err, ErrBudgetExceeded) {
+				t.Fatalf("second record: got %v, want BudgetExceededError", err)
+			}
+			if exceeded.Limit != tt.limit {
+				t.Errorf("limit = %q, want %q", exceeded.Limit, tt.limit)
+			}
+		})
+	}
+}
+
+func TestBudgetCachedPromptPrice(t *testing.T) {
+	budget := &Budget{PromptPricePer1K: 1, CachedPromptPricePer1K: 0.5}
+	budget.RecordLLMUsage(&schema.Usage{PromptTokens: 1000, CachedTokens: 1000, TotalTokens: 1000})
+	if cost := budget.GetUsage().Cost; cost != 0.5 {
+		t.Errorf("cost = %v, want 0.5", cost)
+	}
+}
+
+func TestNilBudgetNeverTrips(t *testing.T) {
+	var budget *Budget
+	if err := budget.RecordLLMUsage(&schema.Usage{TotalTokens: 1 << 30}); err != nil {
+		t.Error(err)
+	}
+	if err := budget.RecordToolCall(); err != nil {
+		t.Error(err)
+	}
+}
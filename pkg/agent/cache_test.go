@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// newCachingAgent 创建启用工具结果缓存的智能体并注册工具
+func newCachingAgent(t *testing.T, tools ...*fakeTool) *ToolCallAgent {
+	t.Helper()
+	testconfig.Use(t, baseTestConfig+`
+[tools.cache]
+enabled = true
+ttl = 60
+capacity = 16
+`)
+	agent, err := NewToolCallAgent("cache", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range tools {
+		agent.AvailableTools.AddTool(tool)
+	}
+	return agent
+}
+
+func TestIdenticalCallHitsCache(t *testing.T) {
+	lookup := newFakeTool("Lookup")
+	lookup.cacheable = true
+	agent := newCachingAgent(t, lookup)
+	ctx := context.Background()
+
+	first, _ := agent.executeTool(ctx, newToolCall("1", "Lookup", `{"q": "go", "n": 1}`))
+	// 参数键顺序和空白不同也视为相同调用
+	second, _ := agent.executeTool(ctx, newToolCall("2", "Lookup", `{"n":1,"q":"go"}`))
+
+	if lookup.Calls() != 1 {
+		t.Errorf("tool executed %d times, want 1", lookup.Calls())
+	}
+	if first.Content != "ok" || second.Content != "ok" {
+		t.Errorf("results = %q, %q", first.Content, second.Content)
+	}
+
+	agent.executeTool(ctx, newToolCall("3", "Lookup", `{"q": "rust", "n": 1}`))
+	if lookup.Calls() != 2 {
+		t.Errorf("different arguments should miss the cache, tool executed %d times", lookup.Calls())
+	}
+}
+
+func TestMutatingToolIsNeverCached(t *testing.T) {
+	write := newFakeTool("Write")
+	agent := newCachingAgent(t, write)
+
+	for i := 0; i < 3; i++ {
+		agent.executeTool(context.Background(), newToolCall("w", "Write", `{"path": "a.txt"}`))
+	}
+	if write.Calls() != 3 {
+		t.Errorf("mutating tool executed %d times, want 3", write.Calls())
+	}
+}
+
+func TestErrorResultsAreNotCached(t *testing.T) {
+	flaky := newFakeTool("Flaky")
+	flaky.cacheable = true
+	flaky.execute = func(calls int, _ string) (interface{}, error) {
+		if calls == 1 {
+			return schema.NewToolError("暂时不可用", nil), nil
+		}
+		return "ok", nil
+	}
+	agent := newCachingAgent(t, flaky)
+	ctx := context.Background()
+
+	agent.executeTool(ctx, newToolCall("1", "Flaky", `{}`))
+	output, _ := agent.executeTool(ctx, newToolCall("2", "Flaky", `{}`))
+	if flaky.Calls() != 2 || output.IsError {
+		t.Errorf("calls = %d, second result error = %v; an error result must not be cached", flaky.Calls(), output.IsError)
+	}
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/tool"
+	"go.uber.org/zap"
+)
+
+// ErrDelegationDepth 子智能体嵌套超过最大深度
+var ErrDelegationDepth = errors.New("delegation depth limit reached")
+
+// defaultDelegationDepth 未配置时子智能体的最大嵌套深度
+const defaultDelegationDepth = 2
+
+// 委派工具会运行新的Manus，在init中注册以避免 builtinTools 的初始化循环
+func init() {
+	builtinTools["DelegateTask"] = newDelegateTask
+}
+
+// maxDelegationDepth 获取子智能体的最大嵌套深度
+func maxDelegationDepth() int {
+	if settings := config.GetConfig().GetAgentSettings(); settings != nil && settings.MaxDelegationDepth > 0 {
+		return settings.MaxDelegationDepth
+	}
+	return defaultDelegationDepth
+}
+
+// canDelegate 检查当前智能体是否还能委派子任务
+func (m *Manus) canDelegate() bool {
+	return m.Depth < maxDelegationDepth()
+}
+
+// newDelegateTask 创建子任务委派工具，由全新的Manus子智能体执行子任务
+func newDelegateTask(m *Manus) tool.Tool {
+	delegateTool := tool.NewDelegateTask()
+	delegateTool.Delegate = m.delegate
+	return delegateTool
+}
+
+// delegate 创建子智能体执行子任务并返回其最终结果
+// 子智能体使用独立的记忆和步数上限，与父智能体共用LLM客户端、运行预算和工作目录
+func (m *Manus) delegate(ctx context.Context, task, background string, maxSteps int) (string, error) {
+	if !m.canDelegate() {
+		return "", fmt.Errorf("%w: %d", ErrDelegationDepth, maxDelegationDepth())
+	}
+
+	child, err := NewManus()
+	if err != nil {
+		return "", fmt.Errorf("创建子智能体失败: %w", err)
+	}
+
+	child.Name = fmt.Sprintf("%s-sub%d", m.Name, m.Depth+1)
+	child.Depth = m.Depth + 1
+	child.LLM = m.LLM
+	child.Budget = m.Budget
+	child.MemoryStrategy = m.MemoryStrategy
+	child.MaxToolCallsPerStep = m.MaxToolCallsPerStep
+	child.EnabledTools = m.EnabledTools
+	child.inheritWorkspace = true
+	child.MaxSteps = m.MaxSteps
+	if maxSteps > 0 && maxSteps < m.MaxSteps {
+		child.MaxSteps = maxSteps
+	}
+
+	prompt := "你是被委派执行子任务的子智能体，完成后使用Terminate工具返回结果。\n\n任务: " + task
+	if background != "" {
+		prompt += "\n\n背景信息:\n" + background
+	}
+
+	logger.Info("启动子智能体",
+		zap.String("parent", m.Name),
+		zap.String("agent", child.Name),
+		zap.Int("depth", child.Depth),
+		zap.Int("max_steps", child.MaxSteps))
+
+	err = child.Run(ctx, prompt)
+	if err != nil && !errors.Is(err, ErrMaxSteps) {
+		return "", err
+	}
+
+	result := child.GetFinalResult()
+	if result == "" {
+		return fmt.Sprintf("子智能体在 %d 步内未给出结果", child.MaxSteps), nil
+	}
+	return result, nil
+}
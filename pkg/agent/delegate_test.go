@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+func TestParentIncorporatesDelegatedResult(t *testing.T) {
+	// 父子智能体共用mock脚本：父智能体委派，子智能体完成子任务，父智能体基于结果结束
+	manus := runManus(t, mockLLMConfig(
+		config.MockResponse{Tool: "DelegateTask", Arguments: `{"task": "统计 data.csv 的行数", "context": "文件在工作目录中"}`},
+		terminate("data.csv 共 42 行"),
+		terminate("报告: 数据共 42 行"),
+	), "写一份数据报告")
+
+	if got := manus.GetFinalResult(); got != "报告: 数据共 42 行" {
+		t.Errorf("final result = %q", got)
+	}
+
+	var delegated *schema.Message
+	for i, message := range manus.Memory.Messages {
+		if message.Role == schema.RoleTool && message.Name != nil && *message.Name == "DelegateTask" {
+			delegated = &manus.Memory.Messages[i]
+		}
+	}
+	if delegated == nil || !strings.Contains(*delegated.Content, "data.csv 共 42 行") {
+		t.Fatalf("parent memory lacks the child's result: %+v", delegated)
+	}
+
+	// 子智能体的对话不进入父智能体的记忆
+	for _, message := range manus.Memory.Messages {
+		if message.Content != nil && strings.Contains(*message.Content, "你是被委派执行子任务的子智能体") {
+			t.Error("child prompt leaked into the parent memory")
+		}
+	}
+}
+
+func TestDelegationDepthLimit(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+"\n[agent]\nmax_delegation_depth = 1\n")
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !manus.canDelegate() {
+		t.Fatal("top-level agent should be able to delegate")
+	}
+
+	manus.Depth = 1
+	if _, err := manus.delegate(context.Background(), "子任务", "", 0); !errors.Is(err, ErrDelegationDepth) {
+		t.Errorf("err = %v, want ErrDelegationDepth", err)
+	}
+
+	child, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	child.Depth = 1
+	for _, name := range toolNames(child) {
+		if name == "DelegateTask" {
+			t.Error("agent at the depth limit still offers DelegateTask")
+		}
+	}
+}
+
+func TestChildBudgetExhaustionStopsParent(t *testing.T) {
+	// 父子智能体共用运行预算：委派本身计一次工具调用，子智能体的工具调用超出预算
+	testconfig.Use(t, mockLLMConfig(
+		config.MockResponse{Tool: "DelegateTask", Arguments: `{"task": "写入文件"}`},
+		createFile("child.txt", "x"),
+		terminate("子任务完成"),
+		terminate("父任务完成"),
+	)+"\n[agent.budget]\nmax_tool_calls = 1\n")
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = manus.Run(context.Background(), "委派任务")
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("err = %v, want ErrBudgetExceeded from the child", err)
+	}
+	if manus.GetFinalResult() != "" || manus.GetCurrentStep() != 1 {
+		t.Errorf("parent continued after the child exhausted the budget: step %d, result %q", manus.GetCurrentStep(), manus.GetFinalResult())
+	}
+}
+
+func TestCancelledChildStopsParent(t *testing.T) {
+	testconfig.Use(t, baseTestConfig)
+	agent, err := NewToolCallAgent("parent", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 子智能体被取消时委派工具返回包装后的取消错误
+	delegateTask := newFakeTool("DelegateTask")
+	delegateTask.execute = func(int, string) (interface{}, error) {
+		return nil, fmt.Errorf("子任务执行失败: %w", cancelledError(context.Background()))
+	}
+	agent.AvailableTools.AddTool(delegateTask)
+
+	response := schema.NewAssistantMessage("")
+	response.ToolCalls = []schema.ToolCall{newToolCall("1", "DelegateTask", `{}`)}
+	if _, err := agent.executeToolCalls(context.Background(), &response); !errors.Is(err, ErrCancelled) {
+		t.Errorf("err = %v, want ErrCancelled from the child", err)
+	}
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// 运行失败的类别，返回的错误均可用 errors.Is 判断
+// 超出运行预算为 ErrBudgetExceeded
+var (
+	// ErrLLMFailure LLM请求失败
+	ErrLLMFailure = errors.New("llm request failed")
+	// ErrToolFailure 工具执行失败且无法继续运行
+	ErrToolFailure = errors.New("tool failed")
+	// ErrCancelled 运行被取消
+	ErrCancelled = errors.New("run cancelled")
+	// ErrMaxSteps 达到最大步骤数时任务仍未完成
+	ErrMaxSteps = errors.New("max steps reached")
+)
+
+// ToolError 工具执行失败错误，携带工具名和原始错误
+type ToolError struct {
+	Tool string
+	Err  error
+}
+
+// Error 实现error接口
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", ErrToolFailure, e.Tool, e.Err)
+}
+
+// Unwrap 支持errors.Is(err, ErrToolFailure)及判断原始错误
+func (e *ToolError) Unwrap() []error {
+	return []error{ErrToolFailure, e.Err}
+}
+
+// cancelledError 运行上下文或智能体被取消时的错误
+func cancelledError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, err)
+	}
+	return fmt.Errorf("%w: 智能体已停止", ErrCancelled)
+}
+
+// maxStepsError 达到最大步骤数时的错误
+func maxStepsError(maxSteps int) error {
+	return fmt.Errorf("%w: %d", ErrMaxSteps, maxSteps)
+}
+
+// executeToolSafely 执行工具并捕获工具崩溃
+// 崩溃的工具可能留下未完成的副作用，返回 ToolError 结束运行，而不是让整个进程退出
+func (t *ToolCallAgent) executeToolSafely(ctx context.Context, toolCall schema.ToolCall) (output *schema.ToolOutput, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ToolError{Tool: toolCall.Function.Name, Err: fmt.Errorf("panic: %v", r)}
+		}
+	}()
+	return t.executeTool(ctx, toolCall)
+}
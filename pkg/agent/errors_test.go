@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/llm"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+func TestLLMFailureIsDetectable(t *testing.T) {
+	newScriptedOpenAI(t, openai.FinishReasonContentFilter)
+	agent, err := NewToolCallAgent("llm", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent.Memory.AddMessage(schema.NewUserMessage("你好"))
+
+	_, err = agent.requestResponse(context.Background(), nil)
+	if !errors.Is(err, ErrLLMFailure) || !errors.Is(err, llm.ErrContentFiltered) {
+		t.Errorf("err = %v, want ErrLLMFailure wrapping ErrContentFiltered", err)
+	}
+}
+
+func TestToolFailureIsDetectable(t *testing.T) {
+	agent, err := NewToolCallAgent("tool", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crash := newFakeTool("Crash")
+	crash.execute = func(int, string) (interface{}, error) { panic("nil map") }
+	agent.AvailableTools.AddTool(crash)
+
+	response := schema.NewAssistantMessage("")
+	response.ToolCalls = []schema.ToolCall{newToolCall("1", "Crash", `{}`)}
+	_, err = agent.executeToolCalls(context.Background(), &response)
+
+	var toolErr *ToolError
+	if !errors.Is(err, ErrToolFailure) || !errors.As(err, &toolErr) || toolErr.Tool != "Crash" {
+		t.Errorf("err = %v, want a ToolError for Crash", err)
+	}
+}
+
+func TestCancelledRunIsDetectable(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(terminate("完成")))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := manus.Run(ctx, "取消的任务"); !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want ErrCancelled wrapping context.Canceled", err)
+	}
+}
+
+func TestMaxStepsIsDetectable(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(config.MockResponse{Content: "继续处理"}, config.MockResponse{Content: "还在处理"}))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manus.MaxSteps = 2
+
+	if err := manus.Run(context.Background(), "长任务"); !errors.Is(err, ErrMaxSteps) {
+		t.Errorf("err = %v, want ErrMaxSteps", err)
+	}
+}
+
+func TestBudgetExceededIsDetectable(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(config.MockResponse{Tool: "ReportProgress", Arguments: `{"status": "开始", "percent": 10}`}))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manus.Budget = &Budget{MaxToolCalls: 1}
+	manus.MaxSteps = 5
+
+	err = manus.Run(context.Background(), "超出预算")
+	if !errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrLLMFailure) || errors.Is(err, ErrToolFailure) {
+		t.Errorf("err = %v, want only ErrBudgetExceeded", err)
+	}
+}
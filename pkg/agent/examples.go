@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// LoadExamples 加载档案中的示例对话，examples_file 中的示例追加在 examples 之后
+// 示例文件为JSON数组，元素字段与 examples 相同
+func LoadExamples(profile config.AgentProfile) ([]schema.Message, error) {
+	examples := append([]config.ExampleMessage{}, profile.Examples...)
+
+	if profile.ExamplesFile != "" {
+		data, err := os.ReadFile(profile.ExamplesFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取示例文件失败: %w", err)
+		}
+		var fileExamples []config.ExampleMessage
+		if err := json.Unmarshal(data, &fileExamples); err != nil {
+			return nil, fmt.Errorf("解析示例文件 %s 失败: %w", profile.ExamplesFile, err)
+		}
+		examples = append(examples, fileExamples...)
+	}
+
+	return convertExamples(examples)
+}
+
+// convertExamples 将示例配置转换为消息，工具结果关联到最近一次示例工具调用
+func convertExamples(examples []config.ExampleMessage) ([]schema.Message, error) {
+	messages := make([]schema.Message, 0, len(examples))
+	var lastCall *schema.ToolCall
+
+	for i, example := range examples {
+		switch schema.Role(example.Role) {
+		case schema.RoleUser:
+			messages = append(messages, schema.NewUserMessage(example.Content))
+		case schema.RoleAssistant:
+			message := schema.NewAssistantMessage(example.Content)
+			if example.Tool != "" {
+				arguments := example.Arguments
+				if arguments == "" {
+					arguments = "{}"
+				}
+				message.ToolCalls = []schema.ToolCall{{
+					ID:       fmt.Sprintf("example_call_%d", i),
+					Type:     "function",
+					Function: schema.Function{Name: example.Tool, Arguments: arguments},
+				}}
+				lastCall = &message.ToolCalls[0]
+			}
+			messages = append(messages, message)
+		case schema.RoleTool:
+			if lastCall == nil {
+				return nil, fmt.Errorf("第%d条示例是工具结果，但之前没有示例工具调用", i+1)
+			}
+			messages = append(messages, schema.NewToolMessage(example.Content, lastCall.Function.Name, lastCall.ID))
+			lastCall = nil
+		default:
+			return nil, fmt.Errorf("第%d条示例的角色无效: %q", i+1, example.Role)
+		}
+	}
+	return messages, nil
+}
+
+// SetExamples 设置示例对话，每次请求时插入在系统消息之后，不受记忆窗口截断影响
+func (a *Agent) SetExamples(examples []schema.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Examples = examples
+}
+
+// withExamples 在开头的系统消息之后插入示例对话
+func (a *Agent) withExamples(messages []schema.Message) []schema.Message {
+	if len(a.Examples) == 0 {
+		return messages
+	}
+	system, rest := splitSystemMessages(messages)
+	return append(append(system, a.Examples...), rest...)
+}
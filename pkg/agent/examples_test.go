@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+const examplesProfile = `
+[agents.fewshot]
+system_prompt = "你是一名助手"
+tools = ["Terminate"]
+examples = [
+  { role = "user", content = "示例问题" },
+  { role = "assistant", tool = "Lookup", arguments = '{"q": "示例"}' },
+  { role = "tool", content = "示例结果" },
+  { role = "assistant", content = "示例回答" },
+]
+
+[agent.memory_strategy]
+type = "fixed"
+messages = 2
+`
+
+// describe 以 角色:内容 的形式描述请求消息，工具调用记为 角色:call(工具名)
+func describe(messages []openai.ChatCompletionMessage) []string {
+	var result []string
+	for _, msg := range messages {
+		if len(msg.ToolCalls) > 0 {
+			result = append(result, fmt.Sprintf("%s:call(%s)", msg.Role, msg.ToolCalls[0].Function.Name))
+			continue
+		}
+		result = append(result, msg.Role+":"+msg.Content)
+	}
+	return result
+}
+
+func TestExamplesFollowSystemMessagesAndSurviveTrimming(t *testing.T) {
+	fake := newScriptedOpenAI(t, openai.FinishReasonStop)
+	testconfig.Use(t, fake.Config()+examplesProfile)
+
+	m, err := NewManusFromProfile("fewshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, question := range []string{"q1", "q2", "q3"} {
+		if _, err := m.ProcessMessage(context.Background(), schema.NewUserMessage(question)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	examples := "user:示例问题,assistant:call(Lookup),tool:示例结果,assistant:示例回答"
+	requests := fake.Requests()
+	want := []string{
+		"system:你是一名助手," + examples + ",user:q1",
+		// 记忆窗口只保留最近2条消息，窗口之外的系统消息被截断，示例仍在最前面
+		examples + ",assistant:回复: stop,user:q2",
+		examples + ",assistant:回复: stop,user:q3",
+	}
+	for i, request := range requests {
+		if got := strings.Join(describe(request.Messages), ","); got != want[i] {
+			t.Errorf("request %d messages:\n got %s\nwant %s", i+1, got, want[i])
+		}
+	}
+
+	// 示例工具结果关联到示例工具调用
+	call, result := requests[2].Messages[1], requests[2].Messages[2]
+	if result.ToolCallID == "" || result.ToolCallID != call.ToolCalls[0].ID {
+		t.Errorf("example tool result id %q does not match call id %q", result.ToolCallID, call.ToolCalls[0].ID)
+	}
+	if len(m.Memory.Messages) != 7 {
+		t.Errorf("memory has %d messages, examples should not be stored", len(m.Memory.Messages))
+	}
+}
+
+func TestLoadExamplesAppendsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "examples.json")
+	if err := os.WriteFile(file, []byte(`[{"role": "user", "content": "文件问题"}, {"role": "assistant", "content": "文件回答"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := LoadExamples(config.AgentProfile{
+		Examples:     []config.ExampleMessage{{Role: "user", Content: "内联问题"}},
+		ExamplesFile: file,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, msg := range messages {
+		got = append(got, *msg.Content)
+	}
+	if strings.Join(got, ",") != "内联问题,文件问题,文件回答" {
+		t.Errorf("examples = %v", got)
+	}
+}
+
+func TestInvalidExamples(t *testing.T) {
+	tests := map[string][]config.ExampleMessage{
+		"tool result without call": {{Role: "tool", Content: "结果"}},
+		"unknown role":             {{Role: "narrator", Content: "旁白"}},
+	}
+	for name, examples := range tests {
+		if _, err := LoadExamples(config.AgentProfile{Examples: examples}); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LoadExamples(config.AgentProfile{ExamplesFile: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("missing examples file: expected an error")
+	}
+}
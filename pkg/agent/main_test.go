@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"github.com/yahao333/GoManus/pkg/tool"
+)
+
+// baseTestConfig 测试默认使用的配置，各测试通过 testconfig.Use 替换
+const baseTestConfig = `[llm.default]
+model = "gpt-4o"
+api_key = "sk-test"
+api_type = "mock"
+`
+
+// TestMain 在临时目录中写入配置并切换到该目录，配置单例从这里读取
+func TestMain(m *testing.M) {
+	os.Exit(testconfig.Run(m, "gomanus-agent-test-", baseTestConfig, func() error { return config.GetConfig().Reload() }))
+}
+
+// mockLLMConfig 生成默认LLM使用mock提供者的配置，按顺序返回 responses，用尽后回显并调用Terminate
+func mockLLMConfig(responses ...config.MockResponse) string {
+	var builder strings.Builder
+	builder.WriteString(baseTestConfig)
+	for _, response := range responses {
+		fmt.Fprintf(&builder, "\n[[llm.default.mock_responses]]\ncontent = %s\ntool = %s\narguments = %s\n",
+			strconv.Quote(response.Content), strconv.Quote(response.Tool), strconv.Quote(response.Arguments))
+	}
+	return builder.String()
+}
+
+// fakeTool 记录调用次数的测试工具，execute 为nil时返回 "ok"
+type fakeTool struct {
+	tool.BaseTool
+	cacheable bool
+	retryable bool
+	execute   func(calls int, arguments string) (interface{}, error)
+
+	calls int
+	mu    sync.Mutex
+}
+
+// newFakeTool 创建测试工具
+func newFakeTool(name string) *fakeTool {
+	return &fakeTool{BaseTool: tool.BaseTool{Name: name, Description: "测试工具", Parameters: map[string]interface{}{}}}
+}
+
+// Execute 记录调用并执行 execute
+func (f *fakeTool) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	f.mu.Lock()
+	f.calls++
+	calls := f.calls
+	f.mu.Unlock()
+
+	if f.execute != nil {
+		return f.execute(calls, arguments)
+	}
+	return "ok", nil
+}
+
+// Cacheable 实现 tool.CacheableTool
+func (f *fakeTool) Cacheable(string) bool {
+	return f.cacheable
+}
+
+// Retryable 实现 tool.RetryableTool
+func (f *fakeTool) Retryable(string) bool {
+	return f.retryable
+}
+
+// Calls 返回调用次数
+func (f *fakeTool) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// newToolCall 创建工具调用
+func newToolCall(id, name, arguments string) schema.ToolCall {
+	return schema.ToolCall{ID: id, Type: "function", Function: schema.Function{Name: name, Arguments: arguments}}
+}
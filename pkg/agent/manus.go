@@ -14,87 +14,147 @@ import (
 // Manus 主要智能体
 type Manus struct {
 	*ToolCallAgent
-	MaxObserve    int
-	SpecialTools  []string
+	EnabledTools []string
+	// Depth 子智能体嵌套深度，主智能体为0
+	Depth int
 }
 
 // NewManus 创建新的Manus智能体
 func NewManus() (*Manus, error) {
-	systemPrompt := fmt.Sprintf(`你是一个有用的AI助手，可以帮助用户完成各种任务。
-工作目录: %s
-
-你可以使用以下工具来完成任务：
-- PythonExecute: 执行Python代码
-- SimpleBrowser: 简单的HTTP浏览器
-- SimpleSearch: 简单的网络搜索
-- StrReplaceEditor: 编辑文件
-- AskHuman: 向用户提问
-- Terminate: 完成任务
-
-请根据用户的需求选择合适的工具。`, config.GetConfig().GetWorkspaceRoot())
-
-	nextStepPrompt := "根据当前状态，确定下一步应该执行什么操作。"
+	// 系统提示语言随区域设置
+	prompts := manusPromptsFor(config.GetConfig().GetLocale())
 
 	toolCallAgent, err := NewToolCallAgent(
 		"Manus",
 		"一个多功能的AI助手，可以使用各种工具完成任务",
-		systemPrompt,
-		nextStepPrompt,
+		prompts.System,
+		prompts.NextStep,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	toolCallAgent.SpecialTools = []string{"Terminate"}
+
 	return &Manus{
 		ToolCallAgent: toolCallAgent,
-		MaxObserve:    10000,
-		SpecialTools:  []string{"Terminate"},
 	}, nil
 }
 
 // Initialize 初始化Manus智能体
 func (m *Manus) Initialize(ctx context.Context) error {
+	// 先注册工具，系统提示中的工具列表以实际注册结果为准
+	m.addDefaultTools()
+
+	m.mu.Lock()
+	m.SystemPrompt = renderSystemPrompt(m.SystemPrompt, m.AvailableTools.GetAllTools())
+	m.mu.Unlock()
+
 	if err := m.ToolCallAgent.Initialize(ctx); err != nil {
 		return err
 	}
 
-	// 添加默认工具
-	m.addDefaultTools()
-
 	logger.Info("Manus智能体初始化完成")
 	return nil
 }
 
-// addDefaultTools 添加默认工具
-func (m *Manus) addDefaultTools() {
-	// 添加Python执行工具
+// builtinTools 内置工具构造函数
+var builtinTools = map[string]func(m *Manus) tool.Tool{
+	"PythonExecute":    newPythonExecute,
+	"SimpleBrowser":    func(*Manus) tool.Tool { return tool.NewSimpleBrowser() },
+	"SimpleSearch":     func(*Manus) tool.Tool { return tool.NewSimpleSearch() },
+	"StrReplaceEditor": func(*Manus) tool.Tool { return tool.NewStrReplaceEditor() },
+	"AskHuman":         func(*Manus) tool.Tool { return tool.NewAskHuman() },
+	"Terminate":        func(*Manus) tool.Tool { return tool.NewTerminate() },
+	"BrowserUseTool":   func(*Manus) tool.Tool { return tool.NewBrowserUseTool() },
+	"RunTests":         func(*Manus) tool.Tool { return tool.NewRunTests() },
+	"Summarize":        func(m *Manus) tool.Tool { return tool.NewSummarize(m.LLM) },
+	"ReportProgress":   newReportProgress,
+	"ConvertFormat":    func(*Manus) tool.Tool { return tool.NewConvertFormat() },
+	"Chart":            func(*Manus) tool.Tool { return tool.NewChart() },
+	"ListProcesses":    func(m *Manus) tool.Tool { return tool.NewListProcesses(m.Processes) },
+	"KillProcess":      func(m *Manus) tool.Tool { return tool.NewKillProcess(m.Processes) },
+	"RecallToolCalls":  newRecallToolCalls,
+}
+
+// newPythonExecute 创建Python执行工具，脚本输出实时写入日志，后台脚本登记到智能体的进程表
+func newPythonExecute(m *Manus) tool.Tool {
 	pythonTool := tool.NewPythonExecute()
-	m.AvailableTools.AddTool(pythonTool)
+	pythonTool.Processes = m.Processes
+	pythonTool.OnOutput = func(stream, line string) {
+		logger.Debug("Python输出", zap.String("stream", stream), zap.String("line", line))
+	}
+	return pythonTool
+}
 
-	// 添加简化浏览器工具
-	browserTool := tool.NewSimpleBrowser()
-	m.AvailableTools.AddTool(browserTool)
+// newRecallToolCalls 创建工具调用回顾工具，记录来自智能体本次运行的工具调用
+func newRecallToolCalls(m *Manus) tool.Tool {
+	recallTool := tool.NewRecallToolCalls()
+	recallTool.Recall = m.ToolCallHistory
+	return recallTool
+}
 
-	// 添加简化搜索工具
-	searchTool := tool.NewSimpleSearch()
-	m.AvailableTools.AddTool(searchTool)
+// newReportProgress 创建进度报告工具，进度记录到智能体
+func newReportProgress(m *Manus) tool.Tool {
+	progressTool := tool.NewReportProgress()
+	progressTool.OnProgress = m.setProgress
+	return progressTool
+}
 
-	// 添加文件编辑工具
-	fileTool := tool.NewStrReplaceEditor()
-	m.AvailableTools.AddTool(fileTool)
+// defaultToolNames Manus默认启用的工具
+var defaultToolNames = []string{
+	"PythonExecute",
+	"SimpleBrowser",
+	"SimpleSearch",
+	"StrReplaceEditor",
+	"AskHuman",
+	"Summarize",
+	"ConvertFormat",
+	"Chart",
+	"ReportProgress",
+	"ListProcesses",
+	"KillProcess",
+	"RecallToolCalls",
+	"DelegateTask",
+	"Terminate",
+}
+
+// addDefaultTools 添加默认工具
+func (m *Manus) addDefaultTools() {
+	names := m.EnabledTools
+	if len(names) == 0 {
+		names = defaultToolNames
+		if tool.RunTestsEnabled() {
+			names = append(append([]string{}, names...), "RunTests")
+		}
+	}
+
+	for _, name := range names {
+		newTool, ok := builtinTools[name]
+		if !ok {
+			logger.Warn("未知的工具，已忽略", zap.String("tool", name))
+			continue
+		}
+		// 达到最大嵌套深度的子智能体不再提供委派工具
+		if name == "DelegateTask" && !m.canDelegate() {
+			continue
+		}
+		m.AvailableTools.AddTool(newTool(m))
+	}
+}
 
-	// 添加人类提问工具
-	humanTool := tool.NewAskHuman()
-	m.AvailableTools.AddTool(humanTool)
+// ToolDefinitions 获取智能体启用的工具定义，按名称排序；尚未初始化时先注册工具
+func (m *Manus) ToolDefinitions() []schema.ToolDefinition {
+	if len(m.AvailableTools.GetAllTools()) == 0 {
+		m.addDefaultTools()
+	}
 
-	// 添加终止工具
-	terminateTool := tool.NewTerminate()
-	m.AvailableTools.AddTool(terminateTool)
+	return m.AvailableTools.GetDefinitions()
 }
 
 // Run 运行Manus智能体
 func (m *Manus) Run(ctx context.Context, prompt string) error {
-	logger.Info("开始运行Manus智能体", zap.String("prompt", prompt))
+	logger.Info("开始运行Manus智能体", m.runFields(prompt)...)
 	
 	// 初始化
 	if err := m.Initialize(ctx); err != nil {
@@ -102,27 +162,47 @@ func (m *Manus) Run(ctx context.Context, prompt string) error {
 	}
 	defer m.Cleanup(ctx)
 
+	// 准备本次运行的工作目录
+	ctx, cleanupWorkspace, err := m.prepareWorkspace(ctx)
+	if err != nil {
+		return err
+	}
+	defer cleanupWorkspace()
+
+	// 记录运行开始时的工作目录快照，结束时统计文件改动
+	if before, err := snapshotWorkspace(m.GetWorkspace()); err != nil {
+		logger.Warn("工作目录快照失败，无法统计文件改动", zap.Error(err))
+	} else {
+		defer m.recordWorkspaceDiff(m.GetWorkspace(), before)
+	}
+
 	// 设置运行状态
 	m.SetState(schema.AgentStateRunning)
 	defer m.SetState(schema.AgentStateFinished)
 
+	// 指定输出Schema时告知模型最终答案的格式
+	if instruction, ok := m.outputSchemaInstruction(); ok {
+		m.Memory.AddMessage(instruction)
+	}
+
 	// 添加用户消息
 	userMessage := schema.NewUserMessage(prompt)
 	m.Memory.AddMessage(userMessage)
 
 	// 执行主循环
-	for m.CurrentStep < m.MaxSteps {
+	completed := false
+	for !completed && m.GetCurrentStep() < m.MaxSteps {
 		select {
 		case <-m.ctx.Done():
-			return fmt.Errorf("智能体运行被取消")
+			return cancelledError(ctx)
 		case <-ctx.Done():
-			return fmt.Errorf("上下文被取消")
+			return cancelledError(ctx)
 		default:
 		}
 
-		m.CurrentStep++
+		step := m.incrementStep()
 		logger.Info("执行步骤", 
-			zap.Int("step", m.CurrentStep),
+			zap.Int("step", step),
 			zap.Int("max_steps", m.MaxSteps))
 
 		// 处理当前状态
@@ -134,13 +214,23 @@ func (m *Manus) Run(ctx context.Context, prompt string) error {
 
 		// 检查是否完成任务
 		if m.isTaskComplete(response) {
-			logger.Info("任务完成")
-			break
+			// 未通过终止工具结束时，以最后的回复作为最终结果
+			if m.GetFinalResult() == "" && response.Content != nil {
+				m.setFinalResult(*response.Content)
+			}
+			logger.Info("任务完成", zap.String("result", m.GetFinalResult()))
+			completed = true
 		}
 	}
 
-	if m.CurrentStep >= m.MaxSteps {
+	if !completed {
 		logger.Warn("达到最大步骤限制", zap.Int("max_steps", m.MaxSteps))
+		return maxStepsError(m.MaxSteps)
+	}
+
+	if err := m.enforceOutputSchema(ctx); err != nil {
+		m.SetState(schema.AgentStateError)
+		return err
 	}
 
 	return nil
@@ -158,48 +248,43 @@ func (m *Manus) processCurrentState(ctx context.Context) (*schema.Message, error
 	m.Memory.AddMessage(*response)
 
 	// 如果有工具调用，执行工具
-	if response.ToolCalls != nil && len(response.ToolCalls) > 0 {
-		for _, toolCall := range response.ToolCalls {
-			toolResult, err := m.executeTool(ctx, toolCall)
-			if err != nil {
-				logger.Error("工具执行失败", 
-					zap.String("tool", toolCall.Function.Name),
-					zap.Error(err))
-				continue
-			}
-
-			// 添加工具结果到内存
-			toolMessage := schema.NewToolMessage(
-				fmt.Sprintf("%v", toolResult.Result),
-				toolCall.Function.Name,
-				toolCall.ID,
-			)
-			m.Memory.AddMessage(toolMessage)
-		}
+	special, err := m.executeToolCalls(ctx, response)
+	if err != nil {
+		return nil, err
+	}
+	if special != nil {
+		m.finishWithSpecialTool(*special)
 	}
 
 	return response, nil
 }
 
+// finishWithSpecialTool 特殊工具执行后结束运行，其消息作为运行的最终结果
+func (m *Manus) finishWithSpecialTool(toolCall schema.ToolCall) {
+	m.mu.Lock()
+	m.terminated = true
+	m.FinalResult = specialToolMessage(toolCall)
+	m.mu.Unlock()
+
+	m.SetState(schema.AgentStateFinished)
+}
+
 // isTaskComplete 检查任务是否完成
 func (m *Manus) isTaskComplete(response *schema.Message) bool {
 	if response.Content != nil {
 		content := *response.Content
 		// 检查是否包含完成标记
 		if contains(content, "任务完成") || contains(content, "task completed") ||
-		   contains(content, "完成") || contains(content, "completed") ||
-		   contains(content, "Terminate") {
+		   contains(content, "完成") || contains(content, "completed") {
 			return true
 		}
 	}
 
-	// 检查工具调用
-	if response.ToolCalls != nil {
-		for _, tc := range response.ToolCalls {
-			if tc.Function.Name == "Terminate" {
-				return true
-			}
-		}
+	// 检查终止工具是否已执行
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.terminated {
+		return true
 	}
 
 	return false
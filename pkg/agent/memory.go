@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/llm"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// 记忆窗口策略类型
+const (
+	MemoryStrategyFixed       = "fixed"
+	MemoryStrategyTokenBudget = "token_budget"
+	MemoryStrategySummary     = "summary"
+)
+
+const (
+	// defaultMemoryMessages 默认发送的最近消息数
+	defaultMemoryMessages = 20
+	// defaultMemoryTokens token_budget 策略的默认令牌预算
+	defaultMemoryTokens = 8000
+	// maxSummaryChars 摘要中单条消息保留的最大字符数
+	maxSummaryChars = 200
+)
+
+// MemoryStrategy 记忆窗口策略，从内存中选出每次发送给模型的消息
+type MemoryStrategy interface {
+	Select(messages []schema.Message) []schema.Message
+}
+
+// NewMemoryStrategy 根据配置创建记忆窗口策略，未配置时使用最近20条消息
+func NewMemoryStrategy(settings *config.MemoryStrategySettings) (MemoryStrategy, error) {
+	if settings == nil {
+		return &FixedWindowStrategy{Messages: defaultMemoryMessages}, nil
+	}
+
+	messages := settings.Messages
+	if messages <= 0 {
+		messages = defaultMemoryMessages
+	}
+
+	switch settings.Type {
+	case "", MemoryStrategyFixed:
+		return &FixedWindowStrategy{Messages: messages}, nil
+	case MemoryStrategyTokenBudget:
+		maxTokens := settings.MaxTokens
+		if maxTokens <= 0 {
+			maxTokens = defaultMemoryTokens
+		}
+		return &TokenBudgetStrategy{MaxTokens: maxTokens}, nil
+	case MemoryStrategySummary:
+		return &SummaryWindowStrategy{Messages: messages}, nil
+	default:
+		return nil, fmt.Errorf("不支持的记忆窗口策略: %s", settings.Type)
+	}
+}
+
+// FixedWindowStrategy 固定窗口：发送最近N条消息
+type FixedWindowStrategy struct {
+	Messages int
+}
+
+// Select 选择最近N条消息
+func (s *FixedWindowStrategy) Select(messages []schema.Message) []schema.Message {
+	return recentMessages(completeToolCalls(messages), s.Messages)
+}
+
+// TokenBudgetStrategy 令牌预算：保留系统消息，其余按从新到旧填满预算
+type TokenBudgetStrategy struct {
+	MaxTokens int
+}
+
+// Select 选择预算内的系统消息和最近消息，最新一个消息单元始终保留
+// 带工具调用的助手消息与其工具结果作为整体计入预算，不会只保留其中一部分
+func (s *TokenBudgetStrategy) Select(messages []schema.Message) []schema.Message {
+	system, rest := splitSystemMessages(messages)
+	rest = completeToolCalls(rest)
+
+	budget := s.MaxTokens - llm.EstimateTokens(system)
+	starts := messageUnitStarts(rest)
+	start := len(rest)
+	for i := len(starts) - 1; i >= 0; i-- {
+		cost := llm.EstimateTokens(rest[starts[i]:start])
+		if budget-cost < 0 && start < len(rest) {
+			break
+		}
+		budget -= cost
+		start = starts[i]
+	}
+
+	return append(system, rest[start:]...)
+}
+
+// SummaryWindowStrategy 系统消息 + 更早消息的摘要 + 最近N条消息
+type SummaryWindowStrategy struct {
+	Messages int
+}
+
+// Select 选择系统消息和最近N条消息，窗口之外的消息压缩为一条摘要
+func (s *SummaryWindowStrategy) Select(messages []schema.Message) []schema.Message {
+	system, rest := splitSystemMessages(messages)
+	rest = completeToolCalls(rest)
+
+	start := recentStart(rest, s.Messages)
+	older, recent := rest[:start], rest[start:]
+	if len(older) == 0 {
+		return append(system, recent...)
+	}
+
+	selected := append(system, schema.NewSystemMessage(summarizeMessages(older)))
+	return append(selected, recent...)
+}
+
+// summarizeMessages 将消息压缩为摘要文本，保留每条消息的角色、截断的内容和调用的工具
+func summarizeMessages(messages []schema.Message) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "此前 %d 条消息的摘要:\n", len(messages))
+	for _, msg := range messages {
+		content := ""
+		if msg.Content != nil {
+			content = strings.Join(strings.Fields(*msg.Content), " ")
+		}
+		if runes := []rune(content); len(runes) > maxSummaryChars {
+			content = string(runes[:maxSummaryChars]) + "..."
+		}
+
+		var tools []string
+		for _, tc := range msg.ToolCalls {
+			tools = append(tools, tc.Function.Name)
+		}
+		if len(tools) > 0 {
+			content = strings.TrimSpace(content + " [调用工具: " + strings.Join(tools, ", ") + "]")
+		}
+		if content != "" {
+			fmt.Fprintf(&builder, "- %s: %s\n", msg.Role, content)
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// splitSystemMessages 拆分开头的系统消息和其余消息
+func splitSystemMessages(messages []schema.Message) ([]schema.Message, []schema.Message) {
+	i := 0
+	for i < len(messages) && messages[i].Role == schema.RoleSystem {
+		i++
+	}
+	return append([]schema.Message{}, messages[:i]...), messages[i:]
+}
+
+// recentMessages 获取最近n条消息，不拆分工具调用单元
+func recentMessages(messages []schema.Message, n int) []schema.Message {
+	return messages[recentStart(messages, n):]
+}
+
+// recentStart 最近n条消息的起点，落在工具调用单元中间时后移到下一个单元，起点之后没有完整单元时保留最后一个单元
+func recentStart(messages []schema.Message, n int) int {
+	if n <= 0 || n > len(messages) {
+		n = len(messages)
+	}
+
+	starts := messageUnitStarts(messages)
+	if len(starts) == 0 {
+		return 0
+	}
+	for _, start := range starts {
+		if start >= len(messages)-n {
+			return start
+		}
+	}
+	return starts[len(starts)-1]
+}
+
+// messageUnitStarts 将消息划分为单元并返回各单元的起始下标
+// 带工具调用的助手消息与紧随其后、回应这些调用的工具消息组成一个单元，其余消息各自为一个单元
+func messageUnitStarts(messages []schema.Message) []int {
+	var starts []int
+	for i := 0; i < len(messages); {
+		starts = append(starts, i)
+		i += len(toolCallUnit(messages[i:]))
+	}
+	return starts
+}
+
+// toolCallUnit 返回以messages[0]开头的消息单元
+func toolCallUnit(messages []schema.Message) []schema.Message {
+	if len(messages) == 0 || len(messages[0].ToolCalls) == 0 {
+		return messages[:min(len(messages), 1)]
+	}
+
+	ids := make(map[string]bool, len(messages[0].ToolCalls))
+	for _, tc := range messages[0].ToolCalls {
+		ids[tc.ID] = true
+	}
+	end := 1
+	for end < len(messages) && messages[end].Role == schema.RoleTool &&
+		messages[end].ToolCallID != nil && ids[*messages[end].ToolCallID] {
+		end++
+	}
+	return messages[:end]
+}
+
+// completeToolCalls 去掉不完整的工具调用单元：缺少对应助手工具调用的工具消息，
+// 以及并非每个工具调用都有结果的助手消息（连同其已有的结果），模型会拒绝这样的请求
+func completeToolCalls(messages []schema.Message) []schema.Message {
+	complete := make([]schema.Message, 0, len(messages))
+	for i := 0; i < len(messages); {
+		unit := toolCallUnit(messages[i:])
+		i += len(unit)
+
+		if unit[0].Role == schema.RoleTool {
+			continue
+		}
+		if len(unit[0].ToolCalls) > 0 && len(unit)-1 < len(unit[0].ToolCalls) {
+			continue
+		}
+		complete = append(complete, unit...)
+	}
+	return complete
+}
@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// testConversation 系统消息之后是一轮工具调用和两轮问答，每条文本消息约100个令牌
+func testConversation() []schema.Message {
+	text := func(s string) string { return s + strings.Repeat(".", 400-len(s)) }
+
+	call := schema.NewAssistantMessage("")
+	call.ToolCalls = []schema.ToolCall{newToolCall("c1", "Lookup", `{}`)}
+	return []schema.Message{
+		schema.NewSystemMessage("system"),
+		schema.NewUserMessage(text("u1")),
+		call,
+		schema.NewToolMessage(text("result"), "Lookup", "c1"),
+		schema.NewUserMessage(text("u2")),
+		schema.NewAssistantMessage(text("a2")),
+		schema.NewUserMessage(text("u3")),
+	}
+}
+
+// labels 返回消息内容的前缀，系统消息以外的摘要消息返回 "summary"
+func labels(messages []schema.Message) []string {
+	var result []string
+	for _, msg := range messages {
+		switch {
+		case len(msg.ToolCalls) > 0:
+			result = append(result, "call")
+		case strings.HasPrefix(*msg.Content, "此前"):
+			result = append(result, "summary")
+		default:
+			result = append(result, strings.TrimRight(*msg.Content, "."))
+		}
+	}
+	return result
+}
+
+func TestMemoryStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *config.MemoryStrategySettings
+		want     string
+	}{
+		{"default", nil, "system,u1,call,result,u2,a2,u3"},
+		{"fixed", &config.MemoryStrategySettings{Type: MemoryStrategyFixed, Messages: 5}, "call,result,u2,a2,u3"},
+		// 窗口起点落在工具调用单元中间时后移，不拆分调用和结果
+		{"fixed splits no tool call", &config.MemoryStrategySettings{Type: MemoryStrategyFixed, Messages: 4}, "u2,a2,u3"},
+		{"token budget", &config.MemoryStrategySettings{Type: MemoryStrategyTokenBudget, MaxTokens: 250}, "system,a2,u3"},
+		// 预算连最新消息都容不下时仍保留它
+		{"token budget keeps newest", &config.MemoryStrategySettings{Type: MemoryStrategyTokenBudget, MaxTokens: 10}, "system,u3"},
+		{"summary", &config.MemoryStrategySettings{Type: MemoryStrategySummary, Messages: 2}, "system,summary,a2,u3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewMemoryStrategy(tt.settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			messages := testConversation()
+			got := strategy.Select(messages)
+			if strings.Join(labels(got), ",") != tt.want {
+				t.Errorf("selected %v, want %s", labels(got), tt.want)
+			}
+			if len(messages) != 7 || *messages[1].Content != *testConversation()[1].Content {
+				t.Error("Select modified the memory")
+			}
+		})
+	}
+}
+
+func TestSummaryListsOlderMessages(t *testing.T) {
+	strategy := &SummaryWindowStrategy{Messages: 2}
+	summary := *strategy.Select(testConversation())[1].Content
+
+	if !strings.HasPrefix(summary, "此前 4 条消息的摘要") {
+		t.Errorf("summary header = %q", strings.SplitN(summary, "\n", 2)[0])
+	}
+	for _, want := range []string{"- user: u1", "[调用工具: Lookup]", "- tool: result", "- user: u2"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary misses %q", want)
+		}
+	}
+	if strings.Contains(summary, "a2") {
+		t.Errorf("summary includes a message from the window:\n%s", summary)
+	}
+	for _, line := range strings.Split(summary, "\n")[1:] {
+		_, content, _ := strings.Cut(line, ": ")
+		if len(content) > maxSummaryChars+len("...") {
+			t.Errorf("summary line not truncated: %d bytes", len(content))
+		}
+	}
+}
+
+func TestIncompleteToolCallsAreDropped(t *testing.T) {
+	conversation := testConversation()
+	// 缺少结果的工具调用和没有对应调用的工具结果
+	pending := schema.NewAssistantMessage("")
+	pending.ToolCalls = []schema.ToolCall{newToolCall("c2", "Lookup", `{}`)}
+	messages := append(conversation[:2:2], conversation[3], conversation[4], pending)
+
+	for _, strategy := range []MemoryStrategy{
+		&FixedWindowStrategy{Messages: 10},
+		&TokenBudgetStrategy{MaxTokens: 10000},
+		&SummaryWindowStrategy{Messages: 10},
+	} {
+		if got := strings.Join(labels(strategy.Select(messages)), ","); got != "system,u1,u2" {
+			t.Errorf("%T selected %s, want system,u1,u2", strategy, got)
+		}
+	}
+}
+
+func TestUnknownMemoryStrategy(t *testing.T) {
+	if _, err := NewMemoryStrategy(&config.MemoryStrategySettings{Type: "lru"}); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+}
+
+// orphanedToolMessage 返回第一条没有对应工具调用或缺少结果的消息下标，没有时返回-1
+func orphanedToolMessage(messages []schema.Message) int {
+	pending := map[string]bool{}
+	for i, message := range messages {
+		switch {
+		case message.Role == schema.RoleTool:
+			if !pending[*message.ToolCallID] {
+				return i
+			}
+			delete(pending, *message.ToolCallID)
+		case len(pending) > 0:
+			return i
+		}
+		for _, tc := range message.ToolCalls {
+			pending[tc.ID] = true
+		}
+	}
+	if len(pending) > 0 {
+		return len(messages)
+	}
+	return -1
+}
+
+func TestStrategiesNeverSplitToolCalls(t *testing.T) {
+	call := func(ids ...string) schema.Message {
+		message := schema.NewAssistantMessage("")
+		for _, id := range ids {
+			message.ToolCalls = append(message.ToolCalls, newToolCall(id, "Lookup", `{}`))
+		}
+		return message
+	}
+	// 开头是调用已被截断的工具结果，末尾是尚未返回结果的工具调用
+	messages := []schema.Message{
+		schema.NewSystemMessage("system"),
+		schema.NewToolMessage("孤立结果", "Lookup", "gone"),
+		schema.NewUserMessage("问题"),
+		call("a", "b"),
+		schema.NewToolMessage("结果a", "Lookup", "a"),
+		schema.NewToolMessage("结果b", "Lookup", "b"),
+		schema.NewAssistantMessage("回答"),
+		call("c"),
+		schema.NewToolMessage("结果c", "Lookup", "c"),
+		call("d", "e"),
+		schema.NewToolMessage("结果d", "Lookup", "d"),
+	}
+
+	for n := 1; n <= len(messages); n++ {
+		for _, strategy := range []MemoryStrategy{
+			&FixedWindowStrategy{Messages: n},
+			&TokenBudgetStrategy{MaxTokens: n * 3},
+			&SummaryWindowStrategy{Messages: n},
+		} {
+			selected := strategy.Select(messages)
+			if i := orphanedToolMessage(selected); i >= 0 {
+				t.Errorf("%T with %d: tool calls split at message %d: %v", strategy, n, i, labels(selected))
+			}
+		}
+	}
+}
+
+func TestRunKeepsToolCallsPairedInMemory(t *testing.T) {
+	write := createFile("state.txt", "x")
+	testconfig.Use(t, mockLLMConfig(write, write, write, write, terminate("完成")))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 内存容量小于运行产生的消息数，裁剪必然落在工具调用单元附近
+	manus.Memory = schema.NewMemory(4)
+
+	if err := manus.Run(context.Background(), "写入文件"); err != nil {
+		t.Fatal(err)
+	}
+	messages := manus.Memory.Messages
+	if i := orphanedToolMessage(messages); i >= 0 {
+		t.Errorf("memory split tool calls at message %d: %v", i, labels(messages))
+	}
+	for n := 1; n <= len(messages); n++ {
+		strategy := &FixedWindowStrategy{Messages: n}
+		if i := orphanedToolMessage(strategy.Select(messages)); i >= 0 {
+			t.Errorf("window of %d split tool calls at message %d", n, i)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/llm"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/tool"
+	"go.uber.org/zap"
+)
+
+// DefaultProfileName 默认智能体档案名称
+const DefaultProfileName = "manus"
+
+// NewManusFromProfile 根据配置中的智能体档案创建Manus智能体
+// 未配置的默认档案回退到内置的Manus配置
+func NewManusFromProfile(profileName string) (*Manus, error) {
+	if profileName == "" {
+		profileName = DefaultProfileName
+	}
+
+	profile, ok := config.GetConfig().GetAgentProfile(profileName)
+	if !ok {
+		if profileName == DefaultProfileName {
+			return NewManus()
+		}
+		return nil, fmt.Errorf("智能体档案不存在: %s", profileName)
+	}
+
+	manus, err := NewManus()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := manus.applyProfile(profileName, profile); err != nil {
+		return nil, err
+	}
+
+	logger.Info("根据档案创建智能体",
+		zap.String("profile", profileName),
+		zap.Strings("tools", manus.EnabledTools))
+	return manus, nil
+}
+
+// applyProfile 将档案配置应用到智能体
+func (m *Manus) applyProfile(name string, profile config.AgentProfile) error {
+	m.Name = name
+	if profile.Description != "" {
+		m.Description = profile.Description
+	}
+	if profile.SystemPrompt != "" {
+		m.SystemPrompt = profile.SystemPrompt
+	}
+	if profile.NextStepPrompt != "" {
+		m.NextStepPrompt = profile.NextStepPrompt
+	}
+	if profile.MaxSteps > 0 {
+		m.MaxSteps = profile.MaxSteps
+	}
+
+	for _, toolName := range profile.Tools {
+		if _, ok := builtinTools[toolName]; !ok {
+			return fmt.Errorf("智能体档案 %s 引用了未知工具: %s", name, toolName)
+		}
+		// 构建/测试工具会执行项目中的任意代码，只能在配置中显式启用
+		if toolName == "RunTests" && !tool.RunTestsEnabled() {
+			return fmt.Errorf("智能体档案 %s 引用了RunTests，但配置中未启用 [tools.run_tests]", name)
+		}
+	}
+	m.EnabledTools = profile.Tools
+
+	examples, err := LoadExamples(profile)
+	if err != nil {
+		return fmt.Errorf("智能体档案 %s 的示例对话无效: %w", name, err)
+	}
+	m.Examples = examples
+
+	if profile.LLMConfig != "" {
+		// 未配置的名称会回退到默认配置，档案中的拼写错误需直接报错
+		if _, ok := config.GetConfig().GetLLMSettings(profile.LLMConfig); !ok {
+			return fmt.Errorf("智能体档案 %s 引用了未知LLM配置: %s", name, profile.LLMConfig)
+		}
+		llmClient, err := llm.NewLLM(profile.LLMConfig)
+		if err != nil {
+			return fmt.Errorf("创建LLM客户端失败: %w", err)
+		}
+		m.LLM = llmClient
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+const profilesConfig = baseTestConfig + `
+[agents.coder]
+description = "写代码的智能体"
+system_prompt = "你是一名程序员"
+tools = ["PythonExecute", "StrReplaceEditor", "Terminate"]
+max_steps = 7
+
+[agents.researcher]
+system_prompt = "你是一名研究员"
+tools = ["SimpleSearch", "SimpleBrowser", "Terminate"]
+`
+
+// toolNames 返回智能体启用的工具名称
+func toolNames(m *Manus) []string {
+	return m.EnabledTools
+}
+
+func TestProfilesHaveDistinctToolsAndPrompts(t *testing.T) {
+	testconfig.Use(t, profilesConfig)
+
+	coder, err := NewManusFromProfile("coder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	researcher, err := NewManusFromProfile("researcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := toolNames(coder), []string{"PythonExecute", "StrReplaceEditor", "Terminate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("coder tools = %v, want %v", got, want)
+	}
+	if got, want := toolNames(researcher), []string{"SimpleSearch", "SimpleBrowser", "Terminate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("researcher tools = %v, want %v", got, want)
+	}
+	if coder.SystemPrompt != "你是一名程序员" || researcher.SystemPrompt != "你是一名研究员" {
+		t.Errorf("system prompts = %q, %q", coder.SystemPrompt, researcher.SystemPrompt)
+	}
+	if coder.Name != "coder" || coder.MaxSteps != 7 || coder.Description != "写代码的智能体" {
+		t.Errorf("coder profile not applied: name=%q max_steps=%d description=%q", coder.Name, coder.MaxSteps, coder.Description)
+	}
+}
+
+func TestProfileRunTestsWhenEnabled(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[tools.run_tests]
+enabled = true
+
+[agents.tester]
+tools = ["RunTests", "Terminate"]
+`)
+
+	m, err := NewManusFromProfile("tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := toolNames(m), []string{"RunTests", "Terminate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tester tools = %v, want %v", got, want)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[agents.broken]
+tools = ["NoSuchTool"]
+
+[agents.typo]
+llm_config = "defualt"
+
+[agents.tester]
+tools = ["RunTests", "Terminate"]
+`)
+
+	if _, err := NewManusFromProfile("broken"); err == nil {
+		t.Error("profile with an unknown tool: expected error")
+	}
+	if _, err := NewManusFromProfile("typo"); err == nil || !strings.Contains(err.Error(), "defualt") {
+		t.Errorf("profile with an unknown llm config: err = %v, want error naming it", err)
+	}
+	if _, err := NewManusFromProfile("tester"); err == nil || !strings.Contains(err.Error(), "RunTests") {
+		t.Errorf("profile with RunTests while disabled: err = %v, want error naming it", err)
+	}
+	if _, err := NewManusFromProfile("missing"); err == nil {
+		t.Error("unknown profile: expected error")
+	}
+	if m, err := NewManusFromProfile(""); err != nil || m.Name != "Manus" {
+		t.Errorf("default profile without config: %v, %v", m, err)
+	}
+}
@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"time"
+)
+
+// Progress 运行进度，由 ReportProgress 工具更新
+type Progress struct {
+	Status    string
+	Percent   float64
+	UpdatedAt time.Time
+}
+
+// GetProgress 获取最近一次报告的运行进度
+func (a *Agent) GetProgress() Progress {
+	a.progressMu.Lock()
+	defer a.progressMu.Unlock()
+	return a.progress
+}
+
+// setProgress 更新运行进度并通知 OnProgress
+// 由工具在执行期间调用，此时 ProcessMessage 可能持有智能体锁，因此只使用进度锁
+func (a *Agent) setProgress(status string, percent float64) {
+	progress := Progress{Status: status, Percent: percent, UpdatedAt: time.Now()}
+
+	a.progressMu.Lock()
+	a.progress = progress
+	onProgress := a.OnProgress
+	a.progressMu.Unlock()
+
+	if onProgress != nil {
+		onProgress(progress)
+	}
+}
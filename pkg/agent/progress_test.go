@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// reportProgress 调用ReportProgress工具的mock响应
+func reportProgress(arguments string) config.MockResponse {
+	return config.MockResponse{Tool: "ReportProgress", Arguments: arguments}
+}
+
+func TestReportProgressUpdatesAgent(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(
+		reportProgress(`{"status": "下载数据", "percent": 30}`),
+		reportProgress(`{"status": "生成图表", "percent": 80}`),
+		reportProgress(`{"status": "超出范围", "percent": 120}`),
+		terminate("完成"),
+	))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Progress
+	manus.OnProgress = func(progress Progress) {
+		// 回调在工具执行期间调用，读取进度不能因智能体锁而阻塞
+		if got := manus.GetProgress(); got != progress {
+			t.Errorf("GetProgress in callback = %+v, want %+v", got, progress)
+		}
+		events = append(events, progress)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- manus.Run(context.Background(), "画一张图") }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("run did not finish, reporting progress deadlocked")
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("got %d progress events, want 2 (invalid percent rejected): %+v", len(events), events)
+	}
+	if events[0].Status != "下载数据" || events[0].Percent != 30 || events[1].Status != "生成图表" || events[1].Percent != 80 {
+		t.Errorf("events = %+v", events)
+	}
+	if got := manus.GetProgress(); got.Status != "生成图表" || got.Percent != 80 || got.UpdatedAt.IsZero() {
+		t.Errorf("final progress = %+v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/tool"
+	"go.uber.org/zap"
+)
+
+// manusSystemPrompt Manus默认系统提示模板
+const manusSystemPrompt = `你是一个有用的AI助手，可以帮助用户完成各种任务。
+当前时间: {{.Now}}
+运行环境: {{.OS}}/{{.Arch}}
+工作目录: {{.Workspace}}
+
+你可以使用以下工具来完成任务：
+{{.Tools}}
+请根据用户的需求选择合适的工具。`
+
+// manusSystemPromptEN Manus英文系统提示模板
+const manusSystemPromptEN = `You are a helpful AI assistant that can help users complete a wide range of tasks.
+Current time: {{.Now}}
+Environment: {{.OS}}/{{.Arch}}
+Workspace: {{.Workspace}}
+
+You can use the following tools to complete tasks:
+{{.Tools}}
+Choose the tools that best fit the user's request.`
+
+// localizedPrompts 某一语言的默认提示
+type localizedPrompts struct {
+	System   string
+	NextStep string
+}
+
+// manusPrompts 各语言的Manus默认提示，键为语言代码
+var manusPrompts = map[string]localizedPrompts{
+	"zh": {System: manusSystemPrompt, NextStep: "根据当前状态，确定下一步应该执行什么操作。"},
+	"en": {System: manusSystemPromptEN, NextStep: "Based on the current state, decide what to do next."},
+}
+
+// fallbackPromptLang 没有对应语言的提示时使用的语言
+const fallbackPromptLang = "en"
+
+// manusPromptsFor 按区域设置的语言选择默认提示
+func manusPromptsFor(locale string) localizedPrompts {
+	lang, _ := config.SplitLocale(locale)
+	if prompts, ok := manusPrompts[lang]; ok {
+		return prompts
+	}
+	return manusPrompts[fallbackPromptLang]
+}
+
+// PromptData 系统提示模板变量
+type PromptData struct {
+	Now       string
+	OS        string
+	Arch      string
+	Workspace string
+	Tools     string
+}
+
+// newPromptData 根据已注册的工具构建模板变量
+func newPromptData(tools []tool.Tool) PromptData {
+	return PromptData{
+		Now:       time.Now().Format("2006-01-02 15:04:05 MST"),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Workspace: config.GetConfig().GetWorkspaceRoot(),
+		Tools:     toolList(tools),
+	}
+}
+
+// toolList 生成按名称排序的工具列表，每个工具一行
+func toolList(tools []tool.Tool) string {
+	sorted := append([]tool.Tool{}, tools...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+
+	var b strings.Builder
+	for _, t := range sorted {
+		description := strings.TrimSpace(t.GetDescription())
+		if idx := strings.IndexByte(description, '\n'); idx >= 0 {
+			description = strings.TrimSpace(description[:idx])
+		}
+		b.WriteString("- ")
+		b.WriteString(t.GetName())
+		b.WriteString(": ")
+		b.WriteString(description)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderSystemPrompt 渲染系统提示模板，模板无效时原样返回
+func renderSystemPrompt(prompt string, tools []tool.Tool) string {
+	if !strings.Contains(prompt, "{{") {
+		return prompt
+	}
+
+	tmpl, err := template.New("system_prompt").Parse(prompt)
+	if err != nil {
+		logger.Warn("系统提示模板解析失败，使用原始内容", zap.Error(err))
+		return prompt
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, newPromptData(tools)); err != nil {
+		logger.Warn("系统提示模板渲染失败，使用原始内容", zap.Error(err))
+		return prompt
+	}
+	return b.String()
+}
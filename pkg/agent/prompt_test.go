@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/tool"
+)
+
+func TestSystemPromptListsRegisteredTools(t *testing.T) {
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manus.EnabledTools = []string{"StrReplaceEditor", "Terminate", "SimpleBrowser"}
+	if err := manus.Initialize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := manus.GetSystemPrompt()
+	if strings.Contains(prompt, "{{") {
+		t.Errorf("template not rendered:\n%s", prompt)
+	}
+	tools := manus.AvailableTools.GetAllTools()
+	if len(tools) != 3 {
+		t.Fatalf("registered %d tools, want 3", len(tools))
+	}
+	for _, registered := range tools {
+		if !strings.Contains(prompt, "- "+registered.GetName()+": ") {
+			t.Errorf("prompt does not list %s:\n%s", registered.GetName(), prompt)
+		}
+	}
+	// 未注册的工具不出现在提示中
+	if strings.Contains(prompt, "PythonExecute") {
+		t.Errorf("prompt lists an unregistered tool:\n%s", prompt)
+	}
+}
+
+func TestToolListIsSortedByName(t *testing.T) {
+	list := toolList([]tool.Tool{newFakeTool("Zeta"), newFakeTool("Alpha")})
+	if want := "- Alpha: 测试工具\n- Zeta: 测试工具\n"; list != want {
+		t.Errorf("toolList = %q, want %q", list, want)
+	}
+}
+
+func TestSystemPromptFollowsLocale(t *testing.T) {
+	tests := map[string]string{
+		"en-US": "You are a helpful AI assistant",
+		"zh-CN": "你是一个有用的AI助手",
+		// 没有对应语言的模板时使用英文
+		"fr-FR": "You are a helpful AI assistant",
+	}
+	for locale, want := range tests {
+		testconfig.Use(t, baseTestConfig+"\n[agent]\nlocale = \""+locale+"\"\n")
+		manus, err := NewManus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := manus.Initialize(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if prompt := manus.GetSystemPrompt(); !strings.HasPrefix(prompt, want) || strings.Contains(prompt, "{{") {
+			t.Errorf("locale %s: prompt = %q, want rendered template starting with %q", locale, prompt, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// newRetryingAgent 创建重试等待很短的智能体并注册工具
+func newRetryingAgent(t *testing.T, tools ...*fakeTool) *ToolCallAgent {
+	t.Helper()
+	testconfig.Use(t, baseTestConfig+`
+[tools.retry]
+max_attempts = 3
+backoff = 1
+`)
+	agent, err := NewToolCallAgent("retry", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range tools {
+		agent.AvailableTools.AddTool(tool)
+	}
+	return agent
+}
+
+// flakyExecute 第一次执行返回临时错误，之后成功
+func flakyExecute(calls int, arguments string) (interface{}, error) {
+	if calls == 1 {
+		return nil, errors.New("connection reset by peer")
+	}
+	return "ok", nil
+}
+
+func TestRetryableToolSucceedsOnSecondAttempt(t *testing.T) {
+	search := newFakeTool("Search")
+	search.retryable = true
+	search.execute = flakyExecute
+	agent := newRetryingAgent(t, search)
+
+	output, _ := agent.executeTool(context.Background(), newToolCall("1", "Search", `{}`))
+	if output.IsError || output.Content != "ok" {
+		t.Errorf("output = %+v, want success after retry", output)
+	}
+	if search.Calls() != 2 {
+		t.Errorf("tool executed %d times, want 2", search.Calls())
+	}
+}
+
+func TestMutatingToolIsNotRetried(t *testing.T) {
+	write := newFakeTool("Write")
+	write.execute = flakyExecute
+	agent := newRetryingAgent(t, write)
+
+	output, _ := agent.executeTool(context.Background(), newToolCall("1", "Write", `{}`))
+	if !output.IsError {
+		t.Errorf("output = %+v, want the first error", output)
+	}
+	if write.Calls() != 1 {
+		t.Errorf("mutating tool executed %d times, want 1", write.Calls())
+	}
+}
+
+func TestRetryOptInAndAttemptLimit(t *testing.T) {
+	write := newFakeTool("Write")
+	write.execute = func(int, string) (interface{}, error) { return nil, errors.New("timeout") }
+	agent := newRetryingAgent(t, write)
+	agent.Retry.Tools = []string{"write"}
+
+	if output, _ := agent.executeTool(context.Background(), newToolCall("1", "Write", `{}`)); !output.IsError {
+		t.Errorf("output = %+v, want error after exhausting retries", output)
+	}
+	if write.Calls() != 3 {
+		t.Errorf("opted-in tool executed %d times, want 3", write.Calls())
+	}
+}
@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"github.com/yahao333/GoManus/pkg/tool"
+)
+
+// terminate 调用Terminate工具结束运行的mock响应
+func terminate(message string) config.MockResponse {
+	return config.MockResponse{Tool: "Terminate", Arguments: `{"message": "` + message + `"}`}
+}
+
+// runManus 使用给定配置创建并运行Manus智能体
+func runManus(t *testing.T, content, prompt string) *Manus {
+	t.Helper()
+	testconfig.Use(t, content)
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manus.Run(context.Background(), prompt); err != nil {
+		t.Fatal(err)
+	}
+	return manus
+}
+
+// countMessages 统计内存中指定角色的消息数
+func countMessages(a *ToolCallAgent, role schema.Role) int {
+	count := 0
+	for _, message := range a.Memory.Messages {
+		if message.Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+func TestTerminateMessageBecomesRunResult(t *testing.T) {
+	manus := runManus(t, mockLLMConfig(terminate("报告已写入 report.md")), "写一份报告")
+
+	if got := manus.GetFinalResult(); got != "报告已写入 report.md" {
+		t.Errorf("final result = %q, want the Terminate message", got)
+	}
+}
+
+func TestFinalReplyBecomesRunResultWithoutTerminate(t *testing.T) {
+	manus := runManus(t, mockLLMConfig(config.MockResponse{Content: "任务完成：共3个文件"}), "统计文件")
+
+	if got := manus.GetFinalResult(); got != "任务完成：共3个文件" {
+		t.Errorf("final result = %q, want the last reply", got)
+	}
+}
+
+func TestNoLLMCallsAfterTerminate(t *testing.T) {
+	// Terminate之后的脚本响应若被请求，会在工作目录中创建文件
+	manus := runManus(t, mockLLMConfig(terminate("完成"), createFile("late.txt", "x")), "结束任务")
+
+	if calls := countMessages(manus.ToolCallAgent, schema.RoleAssistant); calls != 1 {
+		t.Errorf("LLM called %d times, want 1", calls)
+	}
+	if manus.GetCurrentStep() != 1 {
+		t.Errorf("ran %d steps, want 1", manus.GetCurrentStep())
+	}
+	if _, err := os.Stat(filepath.Join(config.GetConfig().GetWorkspaceRoot(), "late.txt")); !os.IsNotExist(err) {
+		t.Errorf("response scripted after Terminate was executed: %v", err)
+	}
+}
+
+func TestToolCallsAfterTerminateAreSkipped(t *testing.T) {
+	agent, err := NewToolCallAgent("terminate", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := newFakeTool("Lookup")
+	agent.AvailableTools.AddTool(lookup)
+	agent.AvailableTools.AddTool(tool.NewTerminate())
+	agent.SpecialTools = []string{"Terminate"}
+
+	response := schema.NewAssistantMessage("")
+	response.ToolCalls = []schema.ToolCall{
+		newToolCall("1", "Lookup", `{}`),
+		newToolCall("2", "Terminate", `{"message": "完成"}`),
+		newToolCall("3", "Lookup", `{}`),
+	}
+	// 与运行时一样，响应先加入内存，工具消息跟在其后
+	agent.Memory.AddMessage(response)
+	special, err := agent.executeToolCalls(context.Background(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if special == nil || special.ID != "2" {
+		t.Fatalf("special tool call = %+v, want the Terminate call", special)
+	}
+	if lookup.Calls() != 1 {
+		t.Errorf("Lookup executed %d times, want 1", lookup.Calls())
+	}
+	// 每个工具调用都有对应的工具消息，未执行的说明原因
+	var skipped []string
+	for _, message := range agent.Memory.Messages {
+		if message.Role == schema.RoleTool && strings.HasPrefix(*message.Content, "未执行") {
+			skipped = append(skipped, *message.ToolCallID)
+		}
+	}
+	if countMessages(agent, schema.RoleTool) != 3 || len(skipped) != 1 || skipped[0] != "3" {
+		t.Errorf("tool messages = %d, skipped = %v; want 3 with call 3 skipped", countMessages(agent, schema.RoleTool), skipped)
+	}
+}
+
+func TestStateReadableDuringRun(t *testing.T) {
+	write := createFile("state.txt", "x")
+	testconfig.Use(t, mockLLMConfig(write, write, write, write, terminate("完成")))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 运行期间并发读取步骤、状态和结果，配合 -race 发现未加锁的访问
+	done := make(chan struct{})
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		last := 0
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			step := manus.GetCurrentStep()
+			if step < last {
+				t.Errorf("step went backwards from %d to %d", last, step)
+			}
+			last = step
+			manus.GetState()
+			manus.GetFinalResult()
+		}
+	}()
+
+	err = manus.Run(context.Background(), "写入文件")
+	close(done)
+	<-readerDone
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manus.GetCurrentStep() != 5 {
+		t.Errorf("ran %d steps, want 5", manus.GetCurrentStep())
+	}
+}
+
+func TestOfflineRunWithMockProvider(t *testing.T) {
+	// 脚本化的工具调用在工作目录中执行，脚本用尽后回显提示并结束
+	manus := runManus(t, mockLLMConfig(createFile("notes.txt", "offline")), "离线运行")
+
+	data, err := os.ReadFile(filepath.Join(config.GetConfig().GetWorkspaceRoot(), "notes.txt"))
+	if err != nil || string(data) != "offline" {
+		t.Errorf("notes.txt = %q, %v", data, err)
+	}
+	if got := manus.GetFinalResult(); got != "echo: 离线运行" {
+		t.Errorf("final result = %q, want the echoed prompt", got)
+	}
+}
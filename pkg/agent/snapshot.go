@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// skippedSnapshotDirs 快照时跳过的目录，Python虚拟环境等不属于运行产物
+var skippedSnapshotDirs = map[string]bool{
+	".venv": true,
+	".git":  true,
+}
+
+// FileChange 运行期间发生变化的文件，Path 为相对工作目录的路径，Size 为运行结束时的大小（删除的文件为运行开始时的大小）
+type FileChange struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// WorkspaceDiff 一次运行对工作目录的改动
+type WorkspaceDiff struct {
+	Created  []FileChange `json:"created"`
+	Modified []FileChange `json:"modified"`
+	Deleted  []FileChange `json:"deleted"`
+}
+
+// Empty 是否没有任何改动
+func (d WorkspaceDiff) Empty() bool {
+	return len(d.Created) == 0 && len(d.Modified) == 0 && len(d.Deleted) == 0
+}
+
+// fileState 快照中单个文件的大小和内容哈希
+type fileState struct {
+	size int64
+	hash string
+}
+
+// workspaceSnapshot 工作目录快照，键为相对路径
+type workspaceSnapshot map[string]fileState
+
+// snapshotWorkspace 记录工作目录下所有文件的大小和哈希，目录不存在时返回空快照
+func snapshotWorkspace(dir string) (workspaceSnapshot, error) {
+	snapshot := make(workspaceSnapshot)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && skippedSnapshotDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		state, err := hashFile(path)
+		if err != nil {
+			// 运行期间被删除的文件不计入快照
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		snapshot[filepath.ToSlash(rel)] = state
+		return nil
+	})
+	return snapshot, err
+}
+
+// hashFile 计算文件的大小和SHA-256哈希
+func hashFile(path string) (fileState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{size: size, hash: hex.EncodeToString(hasher.Sum(nil))}, nil
+}
+
+// diffWorkspace 比较运行前后的快照，各列表按路径排序
+func diffWorkspace(before, after workspaceSnapshot) WorkspaceDiff {
+	var diff WorkspaceDiff
+	for path, state := range after {
+		previous, existed := before[path]
+		switch {
+		case !existed:
+			diff.Created = append(diff.Created, FileChange{Path: path, Size: state.size})
+		case previous.hash != state.hash:
+			diff.Modified = append(diff.Modified, FileChange{Path: path, Size: state.size})
+		}
+	}
+	for path, state := range before {
+		if _, exists := after[path]; !exists {
+			diff.Deleted = append(diff.Deleted, FileChange{Path: path, Size: state.size})
+		}
+	}
+
+	for _, changes := range [][]FileChange{diff.Created, diff.Modified, diff.Deleted} {
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	}
+	return diff
+}
+
+// GetWorkspaceDiff 获取最近一次运行对工作目录的改动
+func (a *Agent) GetWorkspaceDiff() WorkspaceDiff {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.workspaceDiff
+}
+
+// recordWorkspaceDiff 比较运行开始时的快照与当前工作目录，记录改动并通知 OnWorkspaceDiff
+func (a *Agent) recordWorkspaceDiff(dir string, before workspaceSnapshot) {
+	after, err := snapshotWorkspace(dir)
+	if err != nil {
+		logger.Warn("工作目录快照失败，无法统计文件改动",
+			zap.String("workspace", dir),
+			zap.Error(err))
+		return
+	}
+	diff := diffWorkspace(before, after)
+
+	a.mu.Lock()
+	a.workspaceDiff = diff
+	onDiff := a.OnWorkspaceDiff
+	a.mu.Unlock()
+
+	logger.Info("本次运行的文件改动",
+		zap.String("agent", a.Name),
+		zap.Int("created", len(diff.Created)),
+		zap.Int("modified", len(diff.Modified)),
+		zap.Int("deleted", len(diff.Deleted)))
+
+	if onDiff != nil {
+		onDiff(diff)
+	}
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+func TestRunReportsWorkspaceDiff(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(createFile("diff/a.txt", "alpha"), createFile("diff/b.txt", "beta!!"), terminate("完成")))
+	root := config.GetConfig().GetWorkspaceRoot()
+	// 运行前已存在、运行中未改动的文件不出现在改动中
+	if err := os.MkdirAll(filepath.Join(root, "diff"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "diff", "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var event *WorkspaceDiff
+	manus.OnWorkspaceDiff = func(diff WorkspaceDiff) { event = &diff }
+	if err := manus.Run(context.Background(), "写入两个文件"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FileChange{{Path: "diff/a.txt", Size: 5}, {Path: "diff/b.txt", Size: 6}}
+	diff := manus.GetWorkspaceDiff()
+	if !reflect.DeepEqual(diff.Created, want) {
+		t.Errorf("created = %+v, want %+v", diff.Created, want)
+	}
+	if len(diff.Modified) != 0 || len(diff.Deleted) != 0 {
+		t.Errorf("modified = %+v, deleted = %+v; want none", diff.Modified, diff.Deleted)
+	}
+	if event == nil || !reflect.DeepEqual(*event, diff) {
+		t.Errorf("OnWorkspaceDiff event = %+v, want %+v", event, diff)
+	}
+}
+
+func TestDiffWorkspaceDetectsModifiedAndDeleted(t *testing.T) {
+	before := workspaceSnapshot{
+		"same.txt":    {size: 1, hash: "a"},
+		"changed.txt": {size: 1, hash: "b"},
+		"gone.txt":    {size: 3, hash: "c"},
+	}
+	after := workspaceSnapshot{
+		"same.txt":    {size: 1, hash: "a"},
+		"changed.txt": {size: 2, hash: "d"},
+		"new.txt":     {size: 4, hash: "e"},
+	}
+
+	diff := diffWorkspace(before, after)
+	want := WorkspaceDiff{
+		Created:  []FileChange{{Path: "new.txt", Size: 4}},
+		Modified: []FileChange{{Path: "changed.txt", Size: 2}},
+		Deleted:  []FileChange{{Path: "gone.txt", Size: 3}},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff = %+v, want %+v", diff, want)
+	}
+}
@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/llm"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// ErrStructuredOutput 最终答案不符合输出Schema
+var ErrStructuredOutput = errors.New("structured output invalid")
+
+// defaultOutputAttempts 最终答案不符合Schema时默认的重新生成次数
+const defaultOutputAttempts = 3
+
+// SetOutputSchema 设置最终答案必须符合的JSON Schema，nil表示不限制
+func (a *Agent) SetOutputSchema(outputSchema map[string]interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.OutputSchema = outputSchema
+}
+
+// GetStructuredResult 获取按输出Schema解析后的最终答案，未设置Schema时返回nil
+func (a *Agent) GetStructuredResult() interface{} {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.structuredResult
+}
+
+// outputSchemaInstruction 告知模型最终答案格式的系统消息
+func (a *Agent) outputSchemaInstruction() (schema.Message, bool) {
+	if a.OutputSchema == nil {
+		return schema.Message{}, false
+	}
+	data, _ := json.MarshalIndent(a.OutputSchema, "", "  ")
+	return schema.NewSystemMessage(fmt.Sprintf(
+		"最终答案（结束时Terminate工具的message参数）必须是符合以下JSON Schema的JSON，不要包含其他文字或代码块标记:\n%s", data)), true
+}
+
+// enforceOutputSchema 校验最终答案是否符合输出Schema
+// 不符合时把错误告知模型并重新生成（提供者支持时使用JSON模式），超过重试次数返回 ErrStructuredOutput
+func (a *Agent) enforceOutputSchema(ctx context.Context) error {
+	if a.OutputSchema == nil {
+		return nil
+	}
+
+	attempts := a.OutputAttempts
+	if attempts <= 0 {
+		attempts = defaultOutputAttempts
+	}
+
+	answer := a.GetFinalResult()
+	value, err := parseStructuredAnswer(answer, a.OutputSchema)
+	for attempt := 1; err != nil; attempt++ {
+		if attempt > attempts {
+			return fmt.Errorf("%w: 重新生成 %d 次后仍不符合: %v", ErrStructuredOutput, attempts, err)
+		}
+		logger.Warn("最终答案不符合输出Schema，要求模型重新生成",
+			zap.String("agent", a.Name),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+
+		a.Memory.AddMessage(schema.NewUserMessage(fmt.Sprintf(
+			"最终答案不符合要求的JSON Schema: %v\n请只输出符合Schema的JSON。", err)))
+		response, requestErr := a.requestResponseWith(ctx, jsonModeClient(a.LLM), nil)
+		if requestErr != nil {
+			return requestErr
+		}
+		a.Memory.AddMessage(*response)
+
+		answer = ""
+		if response.Content != nil {
+			answer = *response.Content
+		}
+		value, err = parseStructuredAnswer(answer, a.OutputSchema)
+	}
+
+	formatted, _ := json.MarshalIndent(value, "", "  ")
+	a.mu.Lock()
+	a.structuredResult = value
+	a.FinalResult = string(formatted)
+	a.mu.Unlock()
+	return nil
+}
+
+// parseStructuredAnswer 解析最终答案中的JSON并按Schema校验，允许答案包在代码块中
+func parseStructuredAnswer(answer string, outputSchema map[string]interface{}) (interface{}, error) {
+	text := strings.TrimSpace(answer)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	if text == "" {
+		return nil, fmt.Errorf("答案为空")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, fmt.Errorf("不是有效的JSON: %w", err)
+	}
+	if err := schema.ValidateJSONSchema(value, outputSchema); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// jsonModeClient 为支持JSON模式的提供者派生开启 json_object 响应格式的客户端，其他提供者原样返回
+func jsonModeClient(client *llm.LLM) *llm.LLM {
+	switch strings.ToLower(client.GetSettings().APIType) {
+	case "openai", "azure":
+	default:
+		return client
+	}
+
+	format := "json_object"
+	jsonClient, err := client.WithSettings(llm.SettingsOverride{ResponseFormat: &format})
+	if err != nil {
+		return client
+	}
+	return jsonClient
+}
@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// cityCountSchema 测试用的输出Schema
+var cityCountSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"city", "count"},
+	"properties": map[string]interface{}{
+		"city":  map[string]interface{}{"type": "string"},
+		"count": map[string]interface{}{"type": "integer", "minimum": 0},
+	},
+	"additionalProperties": false,
+}
+
+func TestInvalidAnswerIsRegenerated(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(
+		terminate("北京有3个"),
+		config.MockResponse{Content: `{"city": "北京", "count": -1}`},
+		config.MockResponse{Content: "```json\n{\"city\": \"北京\", \"count\": 3}\n```"},
+	))
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manus.SetOutputSchema(cityCountSchema)
+
+	if err := manus.Run(context.Background(), "统计城市"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"city": "北京", "count": float64(3)}
+	if got := manus.GetStructuredResult(); !reflect.DeepEqual(got, want) {
+		t.Errorf("structured result = %#v, want %#v", got, want)
+	}
+	if !strings.Contains(manus.GetFinalResult(), `"count": 3`) {
+		t.Errorf("final result = %q, want the formatted JSON", manus.GetFinalResult())
+	}
+
+	// 每次不符合都把校验错误告知模型
+	var feedback []string
+	for _, message := range manus.Memory.Messages {
+		if message.Role == schema.RoleUser && strings.HasPrefix(*message.Content, "最终答案不符合要求的JSON Schema") {
+			feedback = append(feedback, *message.Content)
+		}
+	}
+	if len(feedback) != 2 || !strings.Contains(feedback[0], "不是有效的JSON") || !strings.Contains(feedback[1], "$.count") {
+		t.Errorf("feedback = %q", feedback)
+	}
+	if !hasSchemaInstruction(manus) {
+		t.Error("the model was not told the schema")
+	}
+}
+
+// hasSchemaInstruction 检查内存中是否有告知输出Schema的系统消息
+func hasSchemaInstruction(m *Manus) bool {
+	for _, message := range m.Memory.Messages {
+		if message.Role == schema.RoleSystem && strings.Contains(*message.Content, "JSON Schema") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestStructuredOutputGivesUpAfterAttempts(t *testing.T) {
+	fake := newScriptedOpenAI(t, openai.FinishReasonToolCalls)
+	fake.toolCalls = []openai.ToolCall{{
+		ID:       "call_1",
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: "Terminate", Arguments: `{"message": "北京有3个"}`},
+	}}
+	testconfig.Use(t, fake.Config()+"\n[agent]\noutput_attempts = 2\n")
+	manus, err := NewManus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manus.SetOutputSchema(cityCountSchema)
+
+	if err := manus.Run(context.Background(), "统计城市"); !errors.Is(err, ErrStructuredOutput) {
+		t.Fatalf("err = %v, want ErrStructuredOutput", err)
+	}
+
+	// 首次回答后重新生成2次，重新生成时使用JSON模式
+	requests := fake.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(requests))
+	}
+	for i, request := range requests {
+		jsonMode := request.ResponseFormat != nil && request.ResponseFormat.Type == openai.ChatCompletionResponseFormatTypeJSONObject
+		if jsonMode != (i > 0) {
+			t.Errorf("request %d json mode = %v", i+1, jsonMode)
+		}
+	}
+}
@@ -2,10 +2,18 @@ package agent
 
 import (
     "context"
+    "encoding/json"
+    "errors"
     "fmt"
+    "strings"
+    "sync"
+    "time"
+    "unicode/utf8"
 
+    "github.com/yahao333/GoManus/pkg/config"
     "github.com/yahao333/GoManus/pkg/logger"
     "github.com/yahao333/GoManus/pkg/schema"
+    "github.com/yahao333/GoManus/pkg/tool"
     "go.uber.org/zap"
 )
 
@@ -14,6 +22,70 @@ type ToolCallAgent struct {
 	*Agent
 	MaxObserve    int
 	SpecialTools  []string
+	ResultCache   *tool.ResultCache
+	// MaxToolCallsPerStep 单次响应中最多执行的工具调用数，0表示不限制
+	MaxToolCallsPerStep int
+	// MaxArgumentRepairs 同一工具连续参数无效时，附带参数Schema要求模型修正的最多次数
+	MaxArgumentRepairs int
+	// Retry 工具临时失败时的重试策略
+	Retry ToolRetry
+	// argumentFailures 各工具连续参数无效的次数，调用成功后清零
+	argumentFailures map[string]int
+	// toolCalls 本次运行中已执行的工具调用记录，供 RecallToolCalls 回顾
+	toolCalls []tool.ToolCallRecord
+	// toolCallsMu 保护toolCalls，与智能体锁分开以便工具执行期间读取
+	toolCallsMu sync.Mutex
+}
+
+// defaultArgumentRepairs 未配置时同一工具参数无效后要求模型修正的最多次数
+const defaultArgumentRepairs = 2
+
+// ToolRetry 工具临时失败重试策略
+type ToolRetry struct {
+	// MaxAttempts 包含首次执行在内的最多执行次数，不大于1表示不重试
+	MaxAttempts int
+	// Backoff 首次重试前的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// Tools 未实现 tool.RetryableTool 但同样重试的工具名称
+	Tools []string
+}
+
+// 未配置时的重试策略：只读工具最多执行3次，首次重试前等待500毫秒
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 500 * time.Millisecond
+)
+
+// newToolRetry 根据配置创建重试策略，未设置的项使用默认值
+func newToolRetry(settings *config.ToolRetrySettings) ToolRetry {
+	retry := ToolRetry{MaxAttempts: defaultRetryAttempts, Backoff: defaultRetryBackoff}
+	if settings == nil {
+		return retry
+	}
+	if settings.MaxAttempts > 0 {
+		retry.MaxAttempts = settings.MaxAttempts
+	}
+	if settings.Backoff > 0 {
+		retry.Backoff = time.Duration(settings.Backoff) * time.Millisecond
+	}
+	retry.Tools = settings.Tools
+	return retry
+}
+
+// applies 工具在给定参数下是否重试，写操作默认不重试，需在配置中显式列出
+func (r ToolRetry) applies(toolInstance tool.Tool, arguments string) bool {
+	if r.MaxAttempts <= 1 {
+		return false
+	}
+	if tool.IsRetryable(toolInstance, arguments) {
+		return true
+	}
+	for _, name := range r.Tools {
+		if strings.EqualFold(name, toolInstance.GetName()) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewToolCallAgent 创建新的工具调用智能体
@@ -23,10 +95,32 @@ func NewToolCallAgent(name, description, systemPrompt, nextStepPrompt string) (*
 		return nil, err
 	}
 
+	var resultCache *tool.ResultCache
+	var retrySettings *config.ToolRetrySettings
+	if settings := config.GetConfig().GetToolsSettings(); settings != nil {
+		if settings.Cache != nil && settings.Cache.Enabled {
+			resultCache = tool.NewResultCache(settings.Cache.Capacity,
+				time.Duration(settings.Cache.TTL)*time.Second)
+		}
+		retrySettings = settings.Retry
+	}
+
+	maxToolCalls, maxRepairs := 0, defaultArgumentRepairs
+	if settings := config.GetConfig().GetAgentSettings(); settings != nil {
+		maxToolCalls = settings.MaxToolCallsPerStep
+		if settings.MaxArgumentRepairs > 0 {
+			maxRepairs = settings.MaxArgumentRepairs
+		}
+	}
+
 	return &ToolCallAgent{
-		Agent:        baseAgent,
-		MaxObserve:   10000,
-		SpecialTools: []string{},
+		Agent:               baseAgent,
+		MaxObserve:          10000,
+		SpecialTools:        []string{},
+		ResultCache:         resultCache,
+		MaxToolCallsPerStep: maxToolCalls,
+		MaxArgumentRepairs:  maxRepairs,
+		Retry:               newToolRetry(retrySettings),
 	}, nil
 }
 
@@ -45,7 +139,7 @@ func (t *ToolCallAgent) ProcessMessage(ctx context.Context, message schema.Messa
 	// 生成响应
 	response, err := t.generateResponseWithTools(ctx)
 	if err != nil {
-		t.State = schema.AgentStateError
+		t.setStateLocked(schema.AgentStateError)
 		return nil, fmt.Errorf("生成响应失败: %w", err)
 	}
 
@@ -53,45 +147,135 @@ func (t *ToolCallAgent) ProcessMessage(ctx context.Context, message schema.Messa
 	t.Memory.AddMessage(*response)
 
 	// 如果有工具调用，执行工具
-	if response.ToolCalls != nil && len(response.ToolCalls) > 0 {
-		for _, toolCall := range response.ToolCalls {
-			toolResult, err := t.executeTool(ctx, toolCall)
-			if err != nil {
-				logger.Error("工具执行失败", 
-					zap.String("tool", toolCall.Function.Name),
-					zap.Error(err))
-				continue
-			}
-
-			// 添加工具结果到内存
-			toolMessage := schema.NewToolMessage(
-				fmt.Sprintf("%v", toolResult.Result),
-				toolCall.Function.Name,
-				toolCall.ID,
-			)
-			t.Memory.AddMessage(toolMessage)
-		}
+	special, err := t.executeToolCalls(ctx, response)
+	if err != nil {
+		return nil, err
+	}
+	if special != nil {
+		t.terminated = true
+		t.FinalResult = specialToolMessage(*special)
 	}
 
 	return response, nil
 }
 
+// executeToolCalls 依次执行响应中的工具调用并记录结果
+// 特殊工具（如Terminate）执行成功后立即停止，返回该工具调用
+// 特殊工具之后和超出单步上限的工具调用不执行，以工具消息告知模型
+func (t *ToolCallAgent) executeToolCalls(ctx context.Context, response *schema.Message) (*schema.ToolCall, error) {
+	toolCalls, skipped := response.ToolCalls, []schema.ToolCall(nil)
+	if limit := t.MaxToolCallsPerStep; limit > 0 && len(toolCalls) > limit {
+		logger.Warn("工具调用数超出单步上限，超出部分不执行",
+			zap.Int("tool_calls", len(toolCalls)),
+			zap.Int("limit", limit))
+		toolCalls, skipped = toolCalls[:limit], toolCalls[limit:]
+	}
+
+	for i, toolCall := range toolCalls {
+		if err := t.Budget.RecordToolCall(); err != nil {
+			return nil, err
+		}
+
+		output, err := t.executeToolSafely(ctx, toolCall)
+		if err != nil {
+			return nil, err
+		}
+		if output.IsError {
+			logger.Error("工具执行失败",
+				zap.String("tool", toolCall.Function.Name),
+				zap.String("error", output.Content))
+		}
+
+		// 添加工具结果到内存，失败结果同样告知模型
+		t.Memory.AddMessage(t.toolMessage(output, toolCall))
+		t.recordToolCall(toolCall, output)
+
+		if !output.IsError && t.isSpecialTool(toolCall.Function.Name) {
+			logger.Info("特殊工具已执行，停止后续工具调用",
+				zap.String("tool", toolCall.Function.Name))
+			reason := fmt.Sprintf("未执行：%s 已结束运行", toolCall.Function.Name)
+			t.addSkippedToolMessages(toolCalls[i+1:], reason)
+			t.addSkippedToolMessages(skipped, reason)
+			special := toolCall
+			return &special, nil
+		}
+	}
+
+	t.addSkippedToolMessages(skipped, fmt.Sprintf(
+		"未执行：单步最多执行 %d 个工具调用，本次响应包含 %d 个。请减少每次的工具调用数量，在后续步骤中重新发起需要的调用",
+		t.MaxToolCallsPerStep, len(response.ToolCalls)))
+
+	return nil, nil
+}
+
+// addSkippedToolMessages 为未执行的工具调用添加说明原因的工具消息
+// 每个工具调用都需要对应的工具消息，否则下一次请求会因缺少工具结果被拒绝
+func (t *ToolCallAgent) addSkippedToolMessages(toolCalls []schema.ToolCall, reason string) {
+	for _, toolCall := range toolCalls {
+		t.Memory.AddMessage(schema.NewToolMessage(reason, toolCall.Function.Name, toolCall.ID))
+	}
+}
+
+// recordSummaryBytes 工具调用记录中参数和结果摘要的最大字节数
+const recordSummaryBytes = 200
+
+// recordToolCall 记录已执行的工具调用，参数和结果只保留摘要
+// 在运行所在的协程中调用，直接读取当前步骤不会与步骤更新并发
+func (t *ToolCallAgent) recordToolCall(toolCall schema.ToolCall, output *schema.ToolOutput) {
+	result := output.Content
+	if result == "" {
+		result = output.Format()
+	}
+	record := tool.ToolCallRecord{
+		Step:      t.CurrentStep,
+		Tool:      toolCall.Function.Name,
+		Arguments: summarize(toolCall.Function.Arguments),
+		Success:   !output.IsError,
+		Result:    summarize(result),
+	}
+
+	t.toolCallsMu.Lock()
+	t.toolCalls = append(t.toolCalls, record)
+	t.toolCallsMu.Unlock()
+}
+
+// ToolCallHistory 获取本次运行中已执行的工具调用记录
+func (t *ToolCallAgent) ToolCallHistory() []tool.ToolCallRecord {
+	t.toolCallsMu.Lock()
+	defer t.toolCallsMu.Unlock()
+	return append([]tool.ToolCallRecord(nil), t.toolCalls...)
+}
+
+// summarize 将文本压缩为单行并截断到 recordSummaryBytes
+func summarize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > recordSummaryBytes {
+		text = truncateUTF8(text, recordSummaryBytes) + "..."
+	}
+	return text
+}
+
+// specialToolMessage 提取特殊工具调用中的message参数
+func specialToolMessage(toolCall schema.ToolCall) string {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		return ""
+	}
+	message, _ := args["message"].(string)
+	return message
+}
+
 // generateResponseWithTools 生成带工具的响应
 func (t *ToolCallAgent) generateResponseWithTools(ctx context.Context) (*schema.Message, error) {
 	// 获取工具定义
 	toolDefs := t.AvailableTools.GetDefinitions()
 
-	// 生成响应
-	response, err := t.LLM.GenerateResponse(ctx, t.Memory.GetRecentMessages(20), toolDefs)
-	if err != nil {
-		return nil, err
-	}
-
-	return response, nil
+	return t.requestResponse(ctx, toolDefs)
 }
 
-// executeTool 执行工具
-func (t *ToolCallAgent) executeTool(ctx context.Context, toolCall schema.ToolCall) (*schema.ToolResult, error) {
+// executeTool 执行工具，工具未找到或执行出错时返回错误输出
+// 工具执行中耗尽运行预算或运行被取消时（如委派的子智能体）返回错误，结束整个运行
+func (t *ToolCallAgent) executeTool(ctx context.Context, toolCall schema.ToolCall) (*schema.ToolOutput, error) {
 	toolName := toolCall.Function.Name
 	toolArgs := toolCall.Function.Arguments
 
@@ -102,31 +286,120 @@ func (t *ToolCallAgent) executeTool(ctx context.Context, toolCall schema.ToolCal
 	// 获取工具实例
 	toolInstance, err := t.AvailableTools.GetTool(toolName)
 	if err != nil {
-		return &schema.ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("工具未找到: %s", toolName),
-		}, nil
+		return schema.NewToolError(fmt.Sprintf("工具未找到: %s", toolName), nil), nil
+	}
+
+	// 参数不符合Schema时不执行，要求模型修正
+	if err := tool.ValidateArguments(toolInstance, toolArgs); err != nil {
+		return t.argumentError(toolInstance, err), nil
 	}
 
-	// 执行工具
-	result, err := toolInstance.Execute(ctx, toolArgs)
+	// 只读工具优先使用缓存结果
+	cacheable := t.ResultCache != nil && tool.IsCacheable(toolInstance, toolArgs)
+	if cacheable {
+		if cached, ok := t.ResultCache.Get(toolName, toolArgs); ok {
+			logger.Info("命中工具结果缓存", zap.String("tool", toolName))
+			return schema.ToToolOutput(cached), nil
+		}
+	}
+
+	// 执行工具，兼容返回字符串或任意值的工具
+	result, err := t.executeWithRetry(ctx, toolInstance, toolArgs)
+	if errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrCancelled) {
+		return nil, err
+	}
+	if errors.Is(err, tool.ErrInvalidArguments) {
+		return t.argumentError(toolInstance, err), nil
+	}
 	if err != nil {
-		return &schema.ToolResult{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		return schema.NewToolError(err.Error(), nil), nil
 	}
+	output := schema.ToToolOutput(result)
+	delete(t.argumentFailures, toolName)
 
-	// 截断结果
-	if len(fmt.Sprintf("%v", result)) > t.MaxObserve {
-		truncated := fmt.Sprintf("%v", result)[:t.MaxObserve] + "..."
-		result = truncated
+	// 失败结果不缓存，下次调用重新执行
+	if cacheable && !output.IsError {
+		t.ResultCache.Set(toolName, toolArgs, output)
 	}
 
-	return &schema.ToolResult{
-		Success: true,
-		Result:  result,
-	}, nil
+	return output, nil
+}
+
+// executeWithRetry 执行工具，可重试的工具遇到临时错误时按指数退避重新执行
+// 参数无效、被策略拦截和上下文取消不重试；返回最后一次执行的结果
+func (t *ToolCallAgent) executeWithRetry(ctx context.Context, toolInstance tool.Tool, arguments string) (interface{}, error) {
+	result, err := toolInstance.Execute(ctx, arguments)
+	if err == nil || !t.Retry.applies(toolInstance, arguments) {
+		return result, err
+	}
+
+	backoff := t.Retry.Backoff
+	for attempt := 2; attempt <= t.Retry.MaxAttempts && tool.IsTransientError(err) && ctx.Err() == nil; attempt++ {
+		logger.Warn("工具执行失败，稍后重试",
+			zap.String("tool", toolInstance.GetName()),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, err
+		case <-timer.C:
+		}
+		backoff *= 2
+
+		result, err = toolInstance.Execute(ctx, arguments)
+		if err == nil {
+			logger.Info("工具重试成功",
+				zap.String("tool", toolInstance.GetName()),
+				zap.Int("attempt", attempt))
+		}
+	}
+	return result, err
+}
+
+// argumentError 生成参数无效的错误输出
+// 附带工具的参数Schema供模型修正；同一工具连续失败超过 MaxArgumentRepairs 次后不再附带，提示模型换一种方式
+func (t *ToolCallAgent) argumentError(toolInstance tool.Tool, err error) *schema.ToolOutput {
+	name := toolInstance.GetName()
+	if t.argumentFailures == nil {
+		t.argumentFailures = make(map[string]int)
+	}
+	t.argumentFailures[name]++
+
+	if failures := t.argumentFailures[name]; failures > t.MaxArgumentRepairs {
+		logger.Warn("工具参数连续无效，超出修正次数",
+			zap.String("tool", name),
+			zap.Int("failures", failures))
+		return schema.NewToolError(fmt.Sprintf(
+			"参数无效: %v\n已连续 %d 次参数无效，请不要再以相同方式调用 %s，改用其他方法完成任务", err, failures, name), nil)
+	}
+
+	return schema.NewToolError(fmt.Sprintf(
+		"参数无效: %v\n请按以下 %s 参数Schema修正参数后重新调用", err, name), tool.ParametersSchema(toolInstance))
+}
+
+// toolMessage 将工具输出按结果模板格式化为工具消息，文本超出 MaxObserve 时截断，图片随消息单独发送
+func (t *ToolCallAgent) toolMessage(output *schema.ToolOutput, toolCall schema.ToolCall) schema.Message {
+	content := tool.FormatOutput(toolCall.Function.Name, output)
+	if t.MaxObserve > 0 && len(content) > t.MaxObserve {
+		content = truncateUTF8(content, t.MaxObserve) + "..."
+	}
+
+	if output.Base64Image != "" {
+		return schema.NewToolMessage(content, toolCall.Function.Name, toolCall.ID, output.Base64Image)
+	}
+	return schema.NewToolMessage(content, toolCall.Function.Name, toolCall.ID)
+}
+
+// truncateUTF8 按字节数截断字符串，不切断多字节字符
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
 }
 
 // isSpecialTool 检查是否为特殊工具
@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+func TestToolCallsBeyondCapAreNotExecuted(t *testing.T) {
+	fake := newScriptedOpenAI(t, openai.FinishReasonToolCalls)
+	for i := 0; i < 5; i++ {
+		fake.toolCalls = append(fake.toolCalls, openai.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     openai.ToolTypeFunction,
+			Function: openai.FunctionCall{Name: "Lookup", Arguments: fmt.Sprintf(`{"n": %d}`, i)},
+		})
+	}
+	testconfig.Use(t, fake.Config()+`
+[agent]
+max_tool_calls_per_step = 2
+`)
+
+	agent, err := NewToolCallAgent("capped", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := newFakeTool("Lookup")
+	agent.AvailableTools.AddTool(lookup)
+
+	response, err := agent.ProcessMessage(context.Background(), schema.NewUserMessage("查五次"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.ToolCalls) != 5 {
+		t.Fatalf("response has %d tool calls, want 5", len(response.ToolCalls))
+	}
+	if lookup.Calls() != 2 {
+		t.Errorf("executed %d tool calls, want the cap of 2", lookup.Calls())
+	}
+
+	// 每个工具调用都有工具消息，超出上限的说明未执行
+	var skipped []string
+	for _, message := range agent.Memory.Messages {
+		if message.Role == schema.RoleTool && strings.Contains(*message.Content, "单步最多执行 2 个工具调用") {
+			skipped = append(skipped, *message.ToolCallID)
+		}
+	}
+	if countMessages(agent, schema.RoleTool) != 5 || strings.Join(skipped, ",") != "call_2,call_3,call_4" {
+		t.Errorf("tool messages = %d, skipped = %v", countMessages(agent, schema.RoleTool), skipped)
+	}
+}
+
+func TestNoToolCallCapByDefault(t *testing.T) {
+	agent, _ := NewToolCallAgent("uncapped", "", "", "")
+	lookup := newFakeTool("Lookup")
+	agent.AvailableTools.AddTool(lookup)
+
+	response := schema.NewAssistantMessage("")
+	for i := 0; i < 12; i++ {
+		response.ToolCalls = append(response.ToolCalls, newToolCall(fmt.Sprint(i), "Lookup", `{}`))
+	}
+	if _, err := agent.executeToolCalls(context.Background(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if lookup.Calls() != 12 {
+		t.Errorf("executed %d tool calls, want all 12", lookup.Calls())
+	}
+}
+
+// newSearchTool 要求字符串参数 query 的测试工具
+func newSearchTool() *fakeTool {
+	search := newFakeTool("Search")
+	search.Parameters = map[string]interface{}{
+		"query": map[string]interface{}{"type": "string", "description": "搜索词"},
+	}
+	search.Required = []string{"query"}
+	return search
+}
+
+// lastToolMessage 返回内存中最后一条工具消息的内容
+func lastToolMessage(a *ToolCallAgent) string {
+	for i := len(a.Memory.Messages) - 1; i >= 0; i-- {
+		if message := a.Memory.Messages[i]; message.Role == schema.RoleTool {
+			return *message.Content
+		}
+	}
+	return ""
+}
+
+func TestInvalidArgumentsReturnSchemaThenSucceed(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(
+		config.MockResponse{Tool: "Search", Arguments: `{"q": 42}`},
+		config.MockResponse{Tool: "Search", Arguments: `{"query": "天气"}`},
+	))
+	agent, err := NewToolCallAgent("repair", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	search := newSearchTool()
+	agent.AvailableTools.AddTool(search)
+
+	if _, err := agent.ProcessMessage(context.Background(), schema.NewUserMessage("查天气")); err != nil {
+		t.Fatal(err)
+	}
+	feedback := lastToolMessage(agent)
+	if search.Calls() != 0 {
+		t.Error("tool ran with invalid arguments")
+	}
+	for _, want := range []string{"参数无效", "缺少必需字段 query", `"required":["query"]`, `"query":{"description":"搜索词","type":"string"}`} {
+		if !strings.Contains(feedback, want) {
+			t.Errorf("feedback misses %q:\n%s", want, feedback)
+		}
+	}
+
+	// 模型根据Schema修正参数后正常执行
+	if _, err := agent.ProcessMessage(context.Background(), schema.NewUserMessage("继续")); err != nil {
+		t.Fatal(err)
+	}
+	if search.Calls() != 1 || lastToolMessage(agent) != "ok" {
+		t.Errorf("calls = %d, result = %q", search.Calls(), lastToolMessage(agent))
+	}
+	if len(agent.argumentFailures) != 0 {
+		t.Errorf("failures not reset after success: %v", agent.argumentFailures)
+	}
+}
+
+func TestRepeatedInvalidArgumentsStopRepairs(t *testing.T) {
+	agent, _ := NewToolCallAgent("repair", "", "", "")
+	agent.AvailableTools.AddTool(newSearchTool())
+
+	var feedback []string
+	for i := 0; i < agent.MaxArgumentRepairs+1; i++ {
+		response := schema.NewAssistantMessage("")
+		response.ToolCalls = []schema.ToolCall{newToolCall(fmt.Sprint(i), "Search", `{}`)}
+		agent.Memory.AddMessage(response)
+		if _, err := agent.executeToolCalls(context.Background(), &response); err != nil {
+			t.Fatal(err)
+		}
+		feedback = append(feedback, lastToolMessage(agent))
+	}
+
+	for i, text := range feedback[:agent.MaxArgumentRepairs] {
+		if !strings.Contains(text, "参数Schema") {
+			t.Errorf("attempt %d should carry the schema:\n%s", i+1, text)
+		}
+	}
+	if last := feedback[len(feedback)-1]; strings.Contains(last, `"properties"`) || !strings.Contains(last, "改用其他方法") {
+		t.Errorf("after the repair limit the model should be told to change approach:\n%s", last)
+	}
+}
+
+func TestRecallToolCallsSummarizesEarlierCalls(t *testing.T) {
+	manus := runManus(t, mockLLMConfig(
+		createFile("recall/notes.txt", "hello"),
+		config.MockResponse{Tool: "ReportProgress", Arguments: `{"status": "", "percent": 10}`},
+		config.MockResponse{Tool: "RecallToolCalls", Arguments: `{}`},
+		terminate("完成"),
+	), "回顾调用")
+
+	var recalled string
+	for _, message := range manus.Memory.Messages {
+		if message.Role == schema.RoleTool && message.Name != nil && *message.Name == "RecallToolCalls" {
+			recalled = *message.Content
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(recalled), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "共执行 2 次工具调用") {
+		t.Fatalf("recall output = %q, want a header and two calls", recalled)
+	}
+	if !strings.Contains(lines[1], "[步骤1] StrReplaceEditor") || !strings.Contains(lines[1], "recall/notes.txt") || !strings.Contains(lines[1], "成功") {
+		t.Errorf("first call = %q, want the successful StrReplaceEditor call", lines[1])
+	}
+	if !strings.Contains(lines[2], "[步骤2] ReportProgress") || !strings.Contains(lines[2], "失败") {
+		t.Errorf("second call = %q, want the failed ReportProgress call", lines[2])
+	}
+
+	// 回顾调用本身在执行后才记录
+	if history := manus.ToolCallHistory(); len(history) != 4 || history[2].Tool != "RecallToolCalls" {
+		t.Errorf("history = %+v, want four records with the recall third", history)
+	}
+}
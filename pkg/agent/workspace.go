@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"github.com/yahao333/GoManus/pkg/tool"
+	"go.uber.org/zap"
+)
+
+// prepareWorkspace 准备本次运行的工作目录，返回携带工作目录的上下文和清理函数
+// 启用 per_run 或 IsolatedWorkspace 时每次运行使用独立的 workspace/<智能体ID> 目录，避免并发运行互相覆盖文件
+func (a *Agent) prepareWorkspace(ctx context.Context) (context.Context, func(), error) {
+	root := config.GetConfig().GetWorkspaceRoot()
+	settings := config.GetConfig().GetWorkspaceSettings()
+
+	dir := root
+	perRun := !a.inheritWorkspace && (a.IsolatedWorkspace || (settings != nil && settings.PerRun))
+	if perRun {
+		dir = filepath.Join(root, a.ID)
+	} else if a.inheritWorkspace {
+		dir = tool.WorkspaceFromContext(ctx)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ctx, func() {}, fmt.Errorf("创建工作目录失败: %w", err)
+	}
+
+	a.mu.Lock()
+	a.Workspace = dir
+	a.mu.Unlock()
+
+	if perRun {
+		a.Memory.AddMessage(schema.NewSystemMessage(fmt.Sprintf("本次运行的工作目录: %s", dir)))
+	}
+
+	cleanup := func() {
+		if !perRun || settings == nil || !settings.Cleanup {
+			return
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Error("清理工作目录失败",
+				zap.String("workspace", dir),
+				zap.Error(err))
+		}
+	}
+
+	logger.Info("工作目录已准备", zap.String("agent", a.Name), zap.String("workspace", dir))
+	return tool.WithWorkspace(ctx, dir), cleanup, nil
+}
+
+// GetWorkspace 获取本次运行的工作目录
+func (a *Agent) GetWorkspace() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Workspace
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/tool"
+)
+
+// createFile 调用 StrReplaceEditor 创建文件的mock响应
+func createFile(path, text string) config.MockResponse {
+	return config.MockResponse{
+		Tool:      "StrReplaceEditor",
+		Arguments: `{"command": "create", "path": "` + path + `", "file_text": "` + text + `"}`,
+	}
+}
+
+func TestConcurrentRunsUseSeparateWorkspaces(t *testing.T) {
+	testconfig.Use(t, mockLLMConfig(createFile("result.txt", "done"))+`
+[workspace]
+per_run = true
+`)
+
+	agents := make([]*Manus, 2)
+	for i := range agents {
+		manus, err := NewManus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		agents[i] = manus
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(agents))
+	for i, manus := range agents {
+		wg.Add(1)
+		go func(i int, manus *Manus) {
+			defer wg.Done()
+			errs[i] = manus.Run(context.Background(), "写入结果文件")
+		}(i, manus)
+	}
+	wg.Wait()
+
+	root := config.GetConfig().GetWorkspaceRoot()
+	for i, manus := range agents {
+		if errs[i] != nil {
+			t.Fatalf("agent %d: %v", i, errs[i])
+		}
+		workspace := manus.GetWorkspace()
+		if workspace != filepath.Join(root, manus.ID) {
+			t.Errorf("agent %d workspace = %s, want %s", i, workspace, filepath.Join(root, manus.ID))
+		}
+		if _, err := os.Stat(filepath.Join(workspace, "result.txt")); err != nil {
+			t.Errorf("agent %d: %v", i, err)
+		}
+	}
+	if agents[0].GetWorkspace() == agents[1].GetWorkspace() {
+		t.Error("concurrent runs share a workspace")
+	}
+	if _, err := os.Stat(filepath.Join(root, "result.txt")); !os.IsNotExist(err) {
+		t.Errorf("file written to the shared workspace root: %v", err)
+	}
+}
+
+func TestWorkspaceFromContextDefaultsToRoot(t *testing.T) {
+	if got, want := tool.WorkspaceFromContext(context.Background()), config.GetConfig().GetWorkspaceRoot(); got != want {
+		t.Errorf("WorkspaceFromContext = %s, want %s", got, want)
+	}
+}
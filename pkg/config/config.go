@@ -4,21 +4,42 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/spf13/viper"
+	"github.com/yahao333/GoManus/pkg/logger"
 )
 
 // LLMSettings LLM配置
 type LLMSettings struct {
-	Model          string  `mapstructure:"model"`
-	BaseURL        string  `mapstructure:"base_url"`
-	APIKey         string  `mapstructure:"api_key"`
-	MaxTokens      int     `mapstructure:"max_tokens"`
-	MaxInputTokens *int    `mapstructure:"max_input_tokens"`
-	Temperature    float64 `mapstructure:"temperature"`
-	APIType        string  `mapstructure:"api_type"`
-	APIVersion     string  `mapstructure:"api_version"`
+	Model             string         `mapstructure:"model"`
+	BaseURL           string         `mapstructure:"base_url"`
+	APIKey            string         `mapstructure:"api_key"`
+	MaxTokens         int            `mapstructure:"max_tokens"`
+	MaxInputTokens    *int           `mapstructure:"max_input_tokens"`
+	Temperature       float64        `mapstructure:"temperature"`
+	TopP              float64        `mapstructure:"top_p"`
+	PresencePenalty   float64        `mapstructure:"presence_penalty"`
+	FrequencyPenalty  float64        `mapstructure:"frequency_penalty"`
+	Stop              []string       `mapstructure:"stop"`
+	Seed              *int           `mapstructure:"seed"`
+	ResponseFormat    string         `mapstructure:"response_format"`
+	APIType           string         `mapstructure:"api_type"`
+	APIVersion        string         `mapstructure:"api_version"`
+	RequestsPerMinute int            `mapstructure:"requests_per_minute"`
+	TokensPerMinute   int            `mapstructure:"tokens_per_minute"`
+	MockResponses     []MockResponse `mapstructure:"mock_responses"`
+	Fallbacks         []string       `mapstructure:"fallbacks"`
+	BreakerThreshold  int            `mapstructure:"breaker_threshold"`
+	BreakerCooldown   int            `mapstructure:"breaker_cooldown"`
+}
+
+// MockResponse mock 提供者的单条脚本化响应
+type MockResponse struct {
+	Content   string `mapstructure:"content"`
+	Tool      string `mapstructure:"tool"`
+	Arguments string `mapstructure:"arguments"`
 }
 
 // ProxySettings 代理配置
@@ -91,6 +112,124 @@ type RunflowSettings struct {
 	UseDataAnalysisAgent bool `mapstructure:"use_data_analysis_agent"`
 }
 
+// BudgetSettings 运行预算配置，0表示不限制
+type BudgetSettings struct {
+	MaxTokens              int     `mapstructure:"max_tokens"`
+	MaxCost                float64 `mapstructure:"max_cost"`
+	MaxToolCalls           int     `mapstructure:"max_tool_calls"`
+	PromptPricePer1K       float64 `mapstructure:"prompt_price_per_1k"`
+	CachedPromptPricePer1K float64 `mapstructure:"cached_prompt_price_per_1k"`
+	CompletionPricePer1K   float64 `mapstructure:"completion_price_per_1k"`
+}
+
+// MemoryStrategySettings 记忆窗口策略配置
+type MemoryStrategySettings struct {
+	Type      string `mapstructure:"type"`
+	Messages  int    `mapstructure:"messages"`
+	MaxTokens int    `mapstructure:"max_tokens"`
+}
+
+// AgentSettings 智能体通用配置
+type AgentSettings struct {
+	Budget              *BudgetSettings         `mapstructure:"budget"`
+	MemoryStrategy      *MemoryStrategySettings `mapstructure:"memory_strategy"`
+	MaxToolCallsPerStep int                     `mapstructure:"max_tool_calls_per_step"`
+	MaxDelegationDepth  int                     `mapstructure:"max_delegation_depth"`
+	OutputAttempts      int                     `mapstructure:"output_attempts"`
+	MaxArgumentRepairs  int                     `mapstructure:"max_argument_repairs"`
+	Locale              string                  `mapstructure:"locale"`
+}
+
+// RunTestsSettings 构建/测试工具配置
+type RunTestsSettings struct {
+	Enabled bool `mapstructure:"enabled"`
+	Timeout int  `mapstructure:"timeout"`
+}
+
+// ToolCacheSettings 工具结果缓存配置
+type ToolCacheSettings struct {
+	Enabled  bool `mapstructure:"enabled"`
+	TTL      int  `mapstructure:"ttl"`
+	Capacity int  `mapstructure:"capacity"`
+}
+
+// ToolRetrySettings 工具临时失败重试配置
+// MaxAttempts 为包含首次执行在内的最多执行次数（1表示不重试），Backoff 为首次重试前的等待毫秒数，之后每次翻倍
+// Tools 为未声明可重试、但同样需要重试的工具（如幂等的写操作）
+type ToolRetrySettings struct {
+	MaxAttempts int      `mapstructure:"max_attempts"`
+	Backoff     int      `mapstructure:"backoff"`
+	Tools       []string `mapstructure:"tools"`
+}
+
+// PythonSettings Python执行工具配置
+// CPUTime 为脚本可用的CPU秒数，MaxMemoryMB 为脚本可用的虚拟内存（MB），0表示不限制
+type PythonSettings struct {
+	Interpreter string `mapstructure:"interpreter"`
+	CPUTime     int    `mapstructure:"cpu_time"`
+	MaxMemoryMB int    `mapstructure:"max_memory_mb"`
+}
+
+// NetworkSettings 网络工具访问策略配置
+type NetworkSettings struct {
+	AllowedDomains  []string `mapstructure:"allowed_domains"`
+	DeniedDomains   []string `mapstructure:"denied_domains"`
+	AllowPrivate    bool     `mapstructure:"allow_private"`
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+}
+
+// BrowserToolSettings HTTP工具请求配置
+type BrowserToolSettings struct {
+	UserAgent string            `mapstructure:"user_agent"`
+	Headers   map[string]string `mapstructure:"headers"`
+}
+
+// ToolsSettings 工具配置
+type ToolsSettings struct {
+	RunTests       *RunTestsSettings    `mapstructure:"run_tests"`
+	Cache          *ToolCacheSettings   `mapstructure:"cache"`
+	Retry          *ToolRetrySettings   `mapstructure:"retry"`
+	Python         *PythonSettings      `mapstructure:"python"`
+	Network        *NetworkSettings     `mapstructure:"network"`
+	Browser        *BrowserToolSettings `mapstructure:"browser"`
+	MaxOutputBytes int                  `mapstructure:"max_output_bytes"`
+	ProtectedPaths []string             `mapstructure:"protected_paths"`
+	Templates      map[string]string    `mapstructure:"templates"`
+}
+
+// WorkspaceSettings 工作空间配置
+type WorkspaceSettings struct {
+	PerRun  bool `mapstructure:"per_run"`
+	Cleanup bool `mapstructure:"cleanup"`
+}
+
+// DebugSettings 调试配置
+type DebugSettings struct {
+	LLM        bool   `mapstructure:"llm"`
+	LLMLogFile string `mapstructure:"llm_log_file"`
+}
+
+// AgentProfile 智能体档案配置
+type AgentProfile struct {
+	Description    string           `mapstructure:"description"`
+	SystemPrompt   string           `mapstructure:"system_prompt"`
+	NextStepPrompt string           `mapstructure:"next_step_prompt"`
+	Tools          []string         `mapstructure:"tools"`
+	LLMConfig      string           `mapstructure:"llm_config"`
+	MaxSteps       int              `mapstructure:"max_steps"`
+	Examples       []ExampleMessage `mapstructure:"examples"`
+	ExamplesFile   string           `mapstructure:"examples_file"`
+}
+
+// ExampleMessage 智能体示例对话中的单条消息
+// 助手消息可指定 tool 和 arguments 演示工具调用，随后的 tool 消息为该调用的结果
+type ExampleMessage struct {
+	Role      string `mapstructure:"role"`
+	Content   string `mapstructure:"content"`
+	Tool      string `mapstructure:"tool"`
+	Arguments string `mapstructure:"arguments"`
+}
+
 // AppConfig 应用配置
 type AppConfig struct {
 	LLM          map[string]LLMSettings  `mapstructure:"llm"`
@@ -100,6 +239,11 @@ type AppConfig struct {
 	MCPConfig    *MCPSettings            `mapstructure:"mcp"`
 	RunflowConfig *RunflowSettings       `mapstructure:"runflow"`
 	DaytonaConfig *DaytonaSettings       `mapstructure:"daytona"`
+	Agents       map[string]AgentProfile `mapstructure:"agents"`
+	AgentConfig  *AgentSettings          `mapstructure:"agent"`
+	ToolsConfig  *ToolsSettings          `mapstructure:"tools"`
+	WorkspaceConfig *WorkspaceSettings   `mapstructure:"workspace"`
+	DebugConfig  *DebugSettings          `mapstructure:"debug"`
 }
 
 // Config 全局配置单例
@@ -138,6 +282,8 @@ func (c *Config) init() {
 	
 	// 设置环境变量前缀
 	c.viper.SetEnvPrefix("GOMANUS")
+	// 嵌套键通过下划线覆盖，如 GOMANUS_LLM_DEFAULT_MODEL 覆盖 llm.default.model
+	c.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	c.viper.AutomaticEnv()
 	
 	// 读取配置文件
@@ -159,10 +305,11 @@ func (c *Config) parseConfig() {
 	defer c.mu.Unlock()
 	
 	var appConfig AppConfig
-	if err := c.viper.Unmarshal(&appConfig); err != nil {
-		panic(fmt.Errorf("无法解析配置文件: %w", err))
+	if err := c.viper.Unmarshal(&appConfig, decodeOptions()); err != nil {
+		panic(fmt.Errorf("无法解析配置文件: %w", logger.RedactError(err)))
 	}
 	
+	appConfig.registerSecrets()
 	c.config = &appConfig
 }
 
@@ -176,14 +323,29 @@ func (c *Config) Reload() error {
 	}
 	
 	var appConfig AppConfig
-	if err := c.viper.Unmarshal(&appConfig); err != nil {
-		return fmt.Errorf("重新解析配置文件失败: %w", err)
+	if err := c.viper.Unmarshal(&appConfig, decodeOptions()); err != nil {
+		return fmt.Errorf("重新解析配置文件失败: %w", logger.RedactError(err))
 	}
 	
+	appConfig.registerSecrets()
 	c.config = &appConfig
 	return nil
 }
 
+// ConfigFile 获取当前使用的配置文件路径
+func (c *Config) ConfigFile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.viper.ConfigFileUsed()
+}
+
+// EffectiveSettings 获取合并环境变量覆盖后的生效配置，敏感字段已屏蔽
+func (c *Config) EffectiveSettings() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return redactSettings(c.viper.AllSettings())
+}
+
 // GetLLMSettings 获取LLM配置
 func (c *Config) GetLLMSettings(name string) (LLMSettings, bool) {
 	c.mu.RLock()
@@ -281,6 +443,63 @@ func (c *Config) GetDaytonaSettings() *DaytonaSettings {
 	return c.config.DaytonaConfig
 }
 
+// GetAgentSettings 获取智能体通用配置
+func (c *Config) GetAgentSettings() *AgentSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.config == nil {
+		return nil
+	}
+	return c.config.AgentConfig
+}
+
+// GetToolsSettings 获取工具配置
+func (c *Config) GetToolsSettings() *ToolsSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.config == nil {
+		return nil
+	}
+	return c.config.ToolsConfig
+}
+
+// GetWorkspaceSettings 获取工作空间配置
+func (c *Config) GetWorkspaceSettings() *WorkspaceSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.config == nil {
+		return nil
+	}
+	return c.config.WorkspaceConfig
+}
+
+// GetDebugSettings 获取调试配置
+func (c *Config) GetDebugSettings() *DebugSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.config == nil {
+		return nil
+	}
+	return c.config.DebugConfig
+}
+
+// GetAgentProfile 获取智能体档案配置
+func (c *Config) GetAgentProfile(name string) (AgentProfile, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.config == nil || c.config.Agents == nil {
+		return AgentProfile{}, false
+	}
+
+	profile, ok := c.config.Agents[name]
+	return profile, ok
+}
+
 // GetWorkspaceRoot 获取工作空间根目录
 func (c *Config) GetWorkspaceRoot() string {
 	execPath, err := os.Getwd()
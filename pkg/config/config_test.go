@@ -0,0 +1,42 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEffectiveSettingsReflectEnvOverrides(t *testing.T) {
+	t.Setenv("GOMANUS_LLM_DEFAULT_MODEL", "gpt-4o-mini")
+	t.Setenv("GOMANUS_LLM_DEFAULT_API_KEY", "sk-from-environment-123")
+	cfg := GetConfig()
+
+	dump, err := json.Marshal(cfg.EffectiveSettings())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(dump), `"model":"gpt-4o-mini"`) {
+		t.Errorf("effective settings do not reflect the env override:\n%s", dump)
+	}
+	for _, secret := range []string{"sk-from-environment-123", "sk-test-default-key"} {
+		if strings.Contains(string(dump), secret) {
+			t.Errorf("effective settings contain %s:\n%s", secret, dump)
+		}
+	}
+	if !strings.Contains(string(dump), `"api_key":"`+redactedValue+`"`) {
+		t.Errorf("api_key not masked:\n%s", dump)
+	}
+
+	if model, _ := cfg.Get("llm.default.model"); model != "gpt-4o-mini" {
+		t.Errorf("Get(llm.default.model) = %v, want the env override", model)
+	}
+	if key, _ := cfg.Get("LLM.Default.API_Key"); key != redactedValue {
+		t.Errorf("Get(api_key) = %v, want it masked", key)
+	}
+	if table, _ := cfg.Get("llm.default"); table.(map[string]interface{})["api_key"] != redactedValue {
+		t.Errorf("Get(llm.default) = %v, want api_key masked", table)
+	}
+	if _, ok := cfg.Get("llm.missing"); ok {
+		t.Error("Get reported an unset key as present")
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"regexp"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
+)
+
+// DefaultEnvFile 启动时默认加载的环境变量文件
+const DefaultEnvFile = ".env"
+
+// envReference 匹配配置值中的 ${VAR} 引用
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadEnvFile 加载 .env 文件中的环境变量，已设置的环境变量不会被覆盖
+// required 为 false 时文件不存在不视为错误
+func LoadEnvFile(path string, required bool) error {
+	if _, err := os.Stat(path); err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("读取环境变量文件失败: %w", err)
+	}
+	if err := gotenv.Load(path); err != nil {
+		return fmt.Errorf("解析环境变量文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// expandEnv 替换字符串中的 ${VAR} 引用，未设置的变量替换为空字符串
+func expandEnv(value string) string {
+	return envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		return os.Getenv(envReference.FindStringSubmatch(ref)[1])
+	})
+}
+
+// expandEnvHook 解析配置时展开字符串值中的环境变量引用
+func expandEnvHook() mapstructure.DecodeHookFuncKind {
+	return func(from, to reflect.Kind, data interface{}) (interface{}, error) {
+		if from != reflect.String {
+			return data, nil
+		}
+		return expandEnv(data.(string)), nil
+	}
+}
+
+// decodeOptions 配置解析选项，在viper默认转换之外展开环境变量引用
+func decodeOptions() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		expandEnvHook(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// unsetEnv 清除环境变量，测试结束后恢复
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func TestEnvFileFeedsConfigExpansion(t *testing.T) {
+	unsetEnv(t, "GOMANUS_TEST_KEY", "GOMANUS_TEST_MODEL")
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("# 本地密钥\nGOMANUS_TEST_KEY=sk-from-dotenv-file\nGOMANUS_TEST_MODEL=\"gpt-4o-mini\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadEnvFile(envFile, true); err != nil {
+		t.Fatal(err)
+	}
+	testconfig.Use(t, `[llm.default]
+model = "${GOMANUS_TEST_MODEL}"
+api_key = "${GOMANUS_TEST_KEY}"
+api_type = "mock"
+`)
+
+	settings := GetConfig().GetDefaultLLMSettings()
+	if settings.APIKey != "sk-from-dotenv-file" || settings.Model != "gpt-4o-mini" {
+		t.Errorf("settings = model %q key %q, want values from the env file", settings.Model, settings.APIKey)
+	}
+}
+
+func TestEnvFileDoesNotOverrideEnvironment(t *testing.T) {
+	t.Setenv("GOMANUS_TEST_KEY", "from-shell")
+	envFile := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(envFile, []byte("GOMANUS_TEST_KEY=from-file\n"), 0600)
+
+	if err := LoadEnvFile(envFile, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("GOMANUS_TEST_KEY"); got != "from-shell" {
+		t.Errorf("GOMANUS_TEST_KEY = %q, want the shell value kept", got)
+	}
+}
+
+func TestMissingEnvFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), ".env")
+	if err := LoadEnvFile(missing, false); err != nil {
+		t.Errorf("optional missing file: %v", err)
+	}
+	if err := LoadEnvFile(missing, true); err == nil {
+		t.Error("expected an error for a required missing file")
+	}
+}
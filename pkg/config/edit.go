@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+)
+
+// tableHeader 匹配TOML表头，数组表 [[...]] 不作为可编辑的表
+var tableHeader = regexp.MustCompile(`^\s*\[([^\[\]]+)\]\s*(#.*)?$`)
+
+// Get 获取生效配置中的单个值，敏感字段已屏蔽
+func (c *Config) Get(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key = strings.ToLower(key)
+	if !c.viper.IsSet(key) {
+		return nil, false
+	}
+
+	value := c.viper.Get(key)
+	if settings, ok := value.(map[string]interface{}); ok {
+		return redactSettings(settings), true
+	}
+	leaf := key[strings.LastIndex(key, ".")+1:]
+	if str, ok := value.(string); ok && secretKeys[leaf] {
+		return redact(str), true
+	}
+	return value, true
+}
+
+// Set 修改配置文件中的单个值并重新加载，保留文件中的其他内容和注释
+func (c *Config) Set(key, value string) error {
+	path := c.ConfigFile()
+	if path == "" {
+		return fmt.Errorf("未找到配置文件")
+	}
+	if err := SetFileValue(path, key, value); err != nil {
+		return err
+	}
+	return c.Reload()
+}
+
+// SetFileValue 修改TOML配置文件中点分键对应的值
+// 键已存在时原位替换值并保留行尾注释，不存在时追加到对应表中
+func SetFileValue(path, key, value string) error {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(key)), ".")
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("无效的配置键: %s", key)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	table := strings.Join(parts[:len(parts)-1], ".")
+	leaf := parts[len(parts)-1]
+	updated := setTOMLValue(string(data), table, leaf, value)
+
+	if err := validateTOML(updated); err != nil {
+		return fmt.Errorf("修改后的配置无效: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.toml")
+	if err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(updated); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// setTOMLValue 在TOML文本中设置 table 表下 leaf 键的值
+func setTOMLValue(content, table, leaf, value string) string {
+	lines := strings.Split(content, "\n")
+
+	// 定位表的范围，顶层键的范围为第一个表头之前
+	start, end := -1, len(lines)
+	if table == "" {
+		start = 0
+	}
+	for i, line := range lines {
+		match := tableHeader.FindStringSubmatch(line)
+		if match == nil && !strings.HasPrefix(strings.TrimSpace(line), "[[") {
+			continue
+		}
+		// 顶层键在第一个表头（包括第一行的表头）处结束，表在下一个表头处结束
+		if table == "" || start >= 0 {
+			end = i
+			break
+		}
+		if match != nil && strings.TrimSpace(match[1]) == table {
+			start = i
+		}
+	}
+
+	if start < 0 {
+		// 表不存在时追加新表
+		trimmed := strings.TrimRight(content, "\n")
+		return fmt.Sprintf("%s\n\n[%s]\n%s = %s\n", trimmed, table, leaf, formatTOMLValue(value, ""))
+	}
+
+	keyLine := regexp.MustCompile(`^(\s*` + regexp.QuoteMeta(leaf) + `\s*=\s*)(.*)$`)
+	first := start
+	if table != "" {
+		first = start + 1
+	}
+	for i := first; i < end; i++ {
+		match := keyLine.FindStringSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		oldValue, comment := splitTOMLComment(match[2])
+		newValue := formatTOMLValue(value, oldValue)
+		if comment != "" {
+			// 保持注释列对齐
+			padding := len(match[2]) - len(comment) - len(newValue)
+			if padding < 1 {
+				padding = 1
+			}
+			newValue += strings.Repeat(" ", padding) + comment
+		}
+		lines[i] = match[1] + newValue
+		return strings.Join(lines, "\n")
+	}
+
+	// 键不存在时插入到表的最后一个非空行之后
+	insert := end
+	for insert > first && strings.TrimSpace(lines[insert-1]) == "" {
+		insert--
+	}
+	entry := []string{fmt.Sprintf("%s = %s", leaf, formatTOMLValue(value, ""))}
+	if insert == end && end < len(lines) {
+		// 紧接下一个表头时空一行分隔
+		entry = append(entry, "")
+	}
+	lines = append(lines[:insert], append(entry, lines[insert:]...)...)
+	return strings.Join(lines, "\n")
+}
+
+// splitTOMLComment 拆分值和行尾注释，忽略字符串内的 #
+func splitTOMLComment(rest string) (string, string) {
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		ch := rest[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return strings.TrimRight(rest[:i], " \t"), rest[i:]
+		}
+	}
+	return strings.TrimRight(rest, " \t"), ""
+}
+
+// formatTOMLValue 将命令行输入转换为TOML值
+// 原值为字符串时始终按字符串写入，否则依次尝试布尔、整数、浮点数和数组
+func formatTOMLValue(value, oldValue string) string {
+	isString := strings.HasPrefix(oldValue, `"`) || strings.HasPrefix(oldValue, `'`)
+	if !isString {
+		if value == "true" || value == "false" {
+			return value
+		}
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return value
+		}
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value
+		}
+		if strings.HasPrefix(value, "[") && validateTOML("v = "+value) == nil {
+			return value
+		}
+	}
+	return strconv.Quote(value)
+}
+
+// validateTOML 检查TOML语法并确认可以解析为配置结构
+func validateTOML(content string) error {
+	var raw map[string]interface{}
+	if err := toml.Unmarshal([]byte(content), &raw); err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	if err := v.ReadConfig(bytes.NewBufferString(content)); err != nil {
+		return err
+	}
+	var appConfig AppConfig
+	return v.Unmarshal(&appConfig, decodeOptions())
+}
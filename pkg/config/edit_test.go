@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+func TestSetScalarValue(t *testing.T) {
+	testconfig.Use(t, `# 模型配置
+[llm.default]
+model = "gpt-4o"          # 使用的模型
+api_key = "sk-test-default-key"
+api_type = "mock"
+max_tokens = 1000
+`)
+	cfg := GetConfig()
+
+	if err := cfg.Set("llm.default.model", "gpt-4.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("LLM.Default.Max_Tokens", "2048"); err != nil {
+		t.Fatal(err)
+	}
+
+	if model, _ := cfg.Get("llm.default.model"); model != "gpt-4.1" {
+		t.Errorf("model = %v, want gpt-4.1", model)
+	}
+	if settings := cfg.GetDefaultLLMSettings(); settings.MaxTokens != 2048 {
+		t.Errorf("max_tokens = %d, want 2048", settings.MaxTokens)
+	}
+
+	data, _ := os.ReadFile(testconfig.Path())
+	content := string(data)
+	if !strings.Contains(content, `model = "gpt-4.1"         # 使用的模型`) || !strings.HasPrefix(content, "# 模型配置\n") {
+		t.Errorf("comments not preserved:\n%s", content)
+	}
+	if !strings.Contains(content, "max_tokens = 2048\n") {
+		t.Errorf("integer written as a string:\n%s", content)
+	}
+}
+
+func TestSetNestedValue(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[tools.run_tests]
+enabled = true
+`)
+	cfg := GetConfig()
+
+	// 已有表中新增键，以及新建表
+	if err := cfg.Set("tools.run_tests.timeout", "60"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Set("tools.network.allowed_domains", `["go.dev", "pkg.go.dev"]`); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := cfg.GetToolsSettings()
+	if settings.RunTests.Timeout != 60 || !settings.RunTests.Enabled {
+		t.Errorf("run_tests settings = %+v", settings.RunTests)
+	}
+	if domains := settings.Network.AllowedDomains; len(domains) != 2 || domains[1] != "pkg.go.dev" {
+		t.Errorf("allowed domains = %v", domains)
+	}
+}
+
+func TestSetRejectsInvalidValue(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+"max_tokens = 1000\n")
+	before, _ := os.ReadFile(testconfig.Path())
+
+	if err := GetConfig().Set("llm.default.max_tokens", "many"); err == nil {
+		t.Error("expected an error for a non-numeric max_tokens")
+	}
+	if after, _ := os.ReadFile(testconfig.Path()); string(after) != string(before) {
+		t.Errorf("config file changed after a rejected edit:\n%s", after)
+	}
+}
+
+func TestSetTopLevelValueBeforeFirstHeader(t *testing.T) {
+	// 第一行就是表头时，顶层键必须插入到表头之前
+	content := "[llm.default]\nmodel = \"gpt-4o\"\n"
+	updated := setTOMLValue(content, "", "name", "demo")
+	if updated != "name = \"demo\"\n\n[llm.default]\nmodel = \"gpt-4o\"\n" {
+		t.Errorf("updated =\n%s", updated)
+	}
+
+	updated = setTOMLValue("name = \"old\"\n[llm.default]\nname = \"keep\"\n", "", "name", "new")
+	if updated != "name = \"new\"\n[llm.default]\nname = \"keep\"\n" {
+		t.Errorf("updated =\n%s", updated)
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// DefaultLocale 未配置且无法识别系统区域设置时使用的区域
+const DefaultLocale = "zh-CN"
+
+// localeEnvVars 按优先级读取的系统区域设置环境变量
+var localeEnvVars = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+// GetLocale 获取区域设置，如 "zh-CN"、"en-US"
+// 优先使用 [agent] locale，未配置时使用系统区域设置
+func (c *Config) GetLocale() string {
+	if settings := c.GetAgentSettings(); settings != nil && settings.Locale != "" {
+		return NormalizeLocale(settings.Locale)
+	}
+	return SystemLocale()
+}
+
+// SystemLocale 从 LC_ALL、LC_MESSAGES、LANG 读取系统区域设置，均未设置或为 C/POSIX 时返回 DefaultLocale
+func SystemLocale() string {
+	for _, name := range localeEnvVars {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if locale := NormalizeLocale(value); locale != "" {
+			return locale
+		}
+		// 已设置但为 C/POSIX 时不再查看优先级更低的变量
+		break
+	}
+	return DefaultLocale
+}
+
+// NormalizeLocale 将 "en_US.UTF-8"、"zh-cn" 等形式规范为 "en-US"、"zh-CN"，C/POSIX 返回空字符串
+func NormalizeLocale(locale string) string {
+	locale = strings.TrimSpace(locale)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || strings.EqualFold(locale, "C") || strings.EqualFold(locale, "POSIX") {
+		return ""
+	}
+
+	lang, country, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if country == "" {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(country)
+}
+
+// SplitLocale 将区域设置拆分为小写的语言和国家/地区代码，如 "zh-CN" 返回 "zh" 和 "cn"
+func SplitLocale(locale string) (lang, country string) {
+	lang, country, _ = strings.Cut(NormalizeLocale(locale), "-")
+	return lang, strings.ToLower(country)
+}
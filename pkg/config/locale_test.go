@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := map[string]string{
+		"en_US.UTF-8": "en-US",
+		"zh-cn":       "zh-CN",
+		"de_DE@euro":  "de-DE",
+		"fr":          "fr",
+		"C.UTF-8":     "",
+		"POSIX":       "",
+		"":            "",
+	}
+	for input, want := range tests {
+		if got := NormalizeLocale(input); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSystemLocaleReadsEnvironment(t *testing.T) {
+	unsetEnv(t, "LC_ALL", "LC_MESSAGES", "LANG")
+	if got := SystemLocale(); got != DefaultLocale {
+		t.Errorf("SystemLocale without env = %q, want %q", got, DefaultLocale)
+	}
+
+	t.Setenv("LANG", "en_GB.UTF-8")
+	if got := SystemLocale(); got != "en-GB" {
+		t.Errorf("SystemLocale with LANG = %q, want en-GB", got)
+	}
+
+	// LC_ALL 优先，为 C 时视为未设置区域
+	t.Setenv("LC_ALL", "C")
+	if got := SystemLocale(); got != DefaultLocale {
+		t.Errorf("SystemLocale with LC_ALL=C = %q, want %q", got, DefaultLocale)
+	}
+}
+
+func TestConfiguredLocaleOverridesSystem(t *testing.T) {
+	t.Setenv("LANG", "en_US.UTF-8")
+	testconfig.Use(t, baseTestConfig+"\n[agent]\nlocale = \"ja_JP\"\n")
+
+	if got := GetConfig().GetLocale(); got != "ja-JP" {
+		t.Errorf("GetLocale = %q, want ja-JP", got)
+	}
+	if lang, country := SplitLocale(GetConfig().GetLocale()); lang != "ja" || country != "jp" {
+		t.Errorf("SplitLocale = %q, %q; want ja, jp", lang, country)
+	}
+}
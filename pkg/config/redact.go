@@ -0,0 +1,98 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+)
+
+// redactedValue 配置中敏感字段的屏蔽值
+const redactedValue = "[REDACTED]"
+
+// redact 非空时返回屏蔽值
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// Redacted 返回屏蔽了API密钥和密码的配置副本，用于输出或记录日志
+func (a *AppConfig) Redacted() AppConfig {
+	redacted := *a
+
+	if a.LLM != nil {
+		redacted.LLM = make(map[string]LLMSettings, len(a.LLM))
+		for name, settings := range a.LLM {
+			settings.APIKey = redact(settings.APIKey)
+			redacted.LLM[name] = settings
+		}
+	}
+
+	if a.BrowserConfig != nil {
+		browser := *a.BrowserConfig
+		if browser.Proxy != nil {
+			proxy := *browser.Proxy
+			proxy.Password = redact(proxy.Password)
+			browser.Proxy = &proxy
+		}
+		redacted.BrowserConfig = &browser
+	}
+
+	if a.DaytonaConfig != nil {
+		daytona := *a.DaytonaConfig
+		daytona.DaytonaAPIKey = redact(daytona.DaytonaAPIKey)
+		daytona.VNCPassword = redact(daytona.VNCPassword)
+		redacted.DaytonaConfig = &daytona
+	}
+
+	return redacted
+}
+
+// secrets 返回配置中的全部敏感值
+func (a *AppConfig) secrets() []string {
+	var secrets []string
+	for _, settings := range a.LLM {
+		secrets = append(secrets, settings.APIKey)
+	}
+	if a.BrowserConfig != nil && a.BrowserConfig.Proxy != nil {
+		secrets = append(secrets, a.BrowserConfig.Proxy.Password)
+	}
+	if a.DaytonaConfig != nil {
+		secrets = append(secrets, a.DaytonaConfig.DaytonaAPIKey, a.DaytonaConfig.VNCPassword)
+	}
+	return secrets
+}
+
+// registerSecrets 登记配置中的敏感值，使日志和错误信息中的对应内容被屏蔽
+func (a *AppConfig) registerSecrets() {
+	logger.RegisterSecrets(a.secrets()...)
+}
+
+// secretKeys 配置中需要屏蔽的键名
+var secretKeys = map[string]bool{
+	"api_key":         true,
+	"password":        true,
+	"daytona_api_key": true,
+	"vnc_password":    true,
+}
+
+// redactSettings 递归屏蔽配置键值表中的敏感字段
+func redactSettings(settings map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			redacted[key] = redactSettings(v)
+		case string:
+			if secretKeys[strings.ToLower(key)] {
+				redacted[key] = redact(v)
+			} else {
+				redacted[key] = v
+			}
+		default:
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+)
+
+// testSecrets 测试配置中的敏感值
+var testSecrets = []string{"sk-live-abcdefghijkl", "proxy-password-123", "daytona-key-456789", "vnc-password-789"}
+
+// newSecretConfig 创建包含各类敏感值的配置
+func newSecretConfig() *AppConfig {
+	return &AppConfig{
+		LLM: map[string]LLMSettings{
+			"default": {Model: "gpt-4o", APIKey: testSecrets[0], APIType: "openai"},
+			"local":   {Model: "llama3", APIType: "ollama"},
+		},
+		BrowserConfig: &BrowserSettings{Proxy: &ProxySettings{Server: "http://proxy:8080", Username: "me", Password: testSecrets[1]}},
+		DaytonaConfig: &DaytonaSettings{DaytonaAPIKey: testSecrets[2], VNCPassword: testSecrets[3]},
+	}
+}
+
+func TestRedactedConfigMasksSecrets(t *testing.T) {
+	original := newSecretConfig()
+	redacted := original.Redacted()
+
+	dump, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range testSecrets {
+		if strings.Contains(string(dump), secret) {
+			t.Errorf("config dump contains %q:\n%s", secret, dump)
+		}
+	}
+	if redacted.LLM["default"].APIKey != redactedValue || redacted.LLM["local"].APIKey != "" {
+		t.Errorf("api keys = %q, %q; want masked and empty left empty", redacted.LLM["default"].APIKey, redacted.LLM["local"].APIKey)
+	}
+	if redacted.LLM["default"].Model != "gpt-4o" || redacted.BrowserConfig.Proxy.Username != "me" {
+		t.Errorf("non-secret fields changed: %+v", redacted)
+	}
+
+	// 原配置不受影响
+	if original.LLM["default"].APIKey != testSecrets[0] || original.BrowserConfig.Proxy.Password != testSecrets[1] {
+		t.Error("Redacted modified the original config")
+	}
+}
+
+func TestRegisteredSecretsMaskedInWrappedErrors(t *testing.T) {
+	newSecretConfig().registerSecrets()
+
+	cause := errors.New("连接失败: proxy-password-123@proxy:8080, vnc=vnc-password-789")
+	err := fmt.Errorf("加载配置失败: %w", logger.RedactError(cause))
+	for _, secret := range testSecrets {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("error %q contains %q", err, secret)
+		}
+	}
+	if !errors.Is(err, cause) {
+		t.Error("redacted error no longer matches its cause")
+	}
+}
@@ -4,7 +4,6 @@ import (
     "context"
     "fmt"
     "sync"
-    "time"
 
     "github.com/yahao333/GoManus/pkg/agent"
     "github.com/yahao333/GoManus/pkg/logger"
@@ -332,6 +331,7 @@ func (f *MultiAgentFlow) Execute(ctx context.Context, input string) (string, err
 }
 
 // generateFlowID 生成工作流ID
+// 使用可替换的 schema.NewID，避免按时间戳生成时并发创建的工作流ID冲突
 func generateFlowID() string {
-	return fmt.Sprintf("flow_%d", time.Now().UnixNano())
+	return "flow_" + schema.NewID()
 }
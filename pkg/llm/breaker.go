@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ErrCircuitOpen 熔断器打开，调用被直接拒绝
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// defaultBreakerCooldown 未配置冷却时间时的默认值
+const defaultBreakerCooldown = 30 * time.Second
+
+// BreakerState 熔断器状态
+type BreakerState string
+
+// 熔断器状态
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// BreakerStats 熔断器状态快照，用于监控
+type BreakerStats struct {
+	State               BreakerState
+	ConsecutiveFailures int
+	OpenedAt            time.Time
+}
+
+// CircuitBreaker 提供者调用熔断器
+// 连续失败达到阈值后打开，冷却期内直接拒绝调用；冷却结束后半开，放行一次探测调用，成功则关闭，失败则重新打开
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	mu       sync.Mutex
+}
+
+// NewCircuitBreaker 创建熔断器，threshold 不大于0时返回nil（不熔断）
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// Allow 检查是否允许调用，打开状态下返回 ErrCircuitOpen
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		// 半开状态同一时间只放行一次探测
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record 记录调用结果，只有提供者故障计为失败
+func (b *CircuitBreaker) Record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	probe := b.probing
+	b.probing = false
+
+	switch {
+	case err == nil:
+		b.state = BreakerClosed
+		b.failures = 0
+	case shouldFailover(err):
+		b.failures++
+		if probe || b.failures >= b.threshold {
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+		}
+	}
+}
+
+// abandon 调用未到达提供者时释放半开状态的探测名额，不计入成功或失败
+func (b *CircuitBreaker) abandon() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Stats 获取熔断器状态快照
+func (b *CircuitBreaker) Stats() BreakerStats {
+	if b == nil {
+		return BreakerStats{State: BreakerClosed}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == BreakerOpen && time.Since(b.openedAt) >= b.cooldown {
+		state = BreakerHalfOpen
+	}
+	return BreakerStats{
+		State:               state,
+		ConsecutiveFailures: b.failures,
+		OpenedAt:            b.openedAt,
+	}
+}
+
+// sharedBreaker 按配置名称共享的熔断器及创建时的设置，配置重新加载后设置变化时重建
+type sharedBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	breaker   *CircuitBreaker
+}
+
+var (
+	breakers   = make(map[string]*sharedBreaker)
+	breakersMu sync.Mutex
+)
+
+// getCircuitBreaker 获取按配置名称共享的熔断器，configName 为实际使用的LLM配置名称
+func getCircuitBreaker(configName string, settings config.LLMSettings) *CircuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	cooldown := time.Duration(settings.BreakerCooldown) * time.Second
+	if shared, ok := breakers[configName]; ok &&
+		shared.threshold == settings.BreakerThreshold && shared.cooldown == cooldown {
+		return shared.breaker
+	}
+
+	breaker := NewCircuitBreaker(settings.BreakerThreshold, cooldown)
+	breakers[configName] = &sharedBreaker{
+		threshold: settings.BreakerThreshold,
+		cooldown:  cooldown,
+		breaker:   breaker,
+	}
+	return breaker
+}
+
+// BreakerStatsByConfig 获取所有已启用熔断器的状态，键为LLM配置名称
+func BreakerStatsByConfig() map[string]BreakerStats {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	stats := make(map[string]BreakerStats, len(breakers))
+	for name, shared := range breakers {
+		if shared.breaker != nil {
+			stats[name] = shared.breaker.Stats()
+		}
+	}
+	return stats
+}
+
+// BreakerStats 获取当前客户端熔断器的状态
+func (l *LLM) BreakerStats() BreakerStats {
+	return l.breaker.Stats()
+}
+
+// recordBreaker 记录调用结果，熔断器状态变化时记录日志
+func (l *LLM) recordBreaker(err error) {
+	if l.breaker == nil {
+		return
+	}
+	before := l.breaker.Stats().State
+	l.breaker.Record(err)
+	if after := l.breaker.Stats().State; after != before {
+		logger.Warn("LLM熔断器状态变化",
+			zap.String("config", l.configName),
+			zap.String("from", string(before)),
+			zap.String("to", string(after)))
+	}
+}
@@ -0,0 +1,246 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// errUnavailable 计为提供者故障的错误
+var errUnavailable = &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable, Message: "unavailable"}
+
+// resetBreakers 清空按配置名称共享的熔断器，测试结束后再次清空
+func resetBreakers(t *testing.T) {
+	reset := func() {
+		breakersMu.Lock()
+		breakers = make(map[string]*sharedBreaker)
+		breakersMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	breaker := NewCircuitBreaker(3, time.Hour)
+	for i := 0; i < 2; i++ {
+		breaker.Record(errUnavailable)
+	}
+	// 成功调用清零连续失败次数
+	breaker.Record(nil)
+	for i := 0; i < 2; i++ {
+		breaker.Record(errUnavailable)
+	}
+	if stats := breaker.Stats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 2 {
+		t.Fatalf("stats = %+v, want closed with 2 failures", stats)
+	}
+
+	breaker.Record(errUnavailable)
+	if stats := breaker.Stats(); stats.State != BreakerOpen || stats.OpenedAt.IsZero() {
+		t.Fatalf("stats = %+v, want open", stats)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow while open = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreakerIgnoresRequestErrors(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Hour)
+	breaker.Record(&openai.APIError{HTTPStatusCode: http.StatusBadRequest})
+	breaker.Record(ErrContentFiltered)
+	breaker.Record(context.Canceled)
+	if stats := breaker.Stats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Errorf("stats = %+v, request errors should not count", stats)
+	}
+}
+
+func TestBreakerHalfOpensAfterCooldown(t *testing.T) {
+	breaker := NewCircuitBreaker(1, 20*time.Millisecond)
+	breaker.Record(errUnavailable)
+	time.Sleep(30 * time.Millisecond)
+
+	if state := breaker.Stats().State; state != BreakerHalfOpen {
+		t.Fatalf("state after cooldown = %s, want half_open", state)
+	}
+	// 半开状态只放行一次探测
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("probe rejected: %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second call during probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// 探测失败重新打开
+	breaker.Record(errUnavailable)
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow after failed probe = %v, want ErrCircuitOpen", err)
+	}
+
+	// 再次冷却后探测成功则关闭
+	time.Sleep(30 * time.Millisecond)
+	if err := breaker.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	breaker.Record(nil)
+	if stats := breaker.Stats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Errorf("stats after successful probe = %+v, want closed", stats)
+	}
+}
+
+func TestNilBreakerNeverOpens(t *testing.T) {
+	breaker := NewCircuitBreaker(0, time.Second)
+	breaker.Record(errUnavailable)
+	if err := breaker.Allow(); err != nil || breaker.Stats().State != BreakerClosed {
+		t.Errorf("disabled breaker: Allow = %v, state = %s", err, breaker.Stats().State)
+	}
+}
+
+func TestBreakerShortCircuitsProviderCalls(t *testing.T) {
+	resetBreakers(t)
+	var mu sync.Mutex
+	healthy := false
+	fake := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		if healthy {
+			return http.StatusOK, textCompletion("恢复了")
+		}
+		return http.StatusServiceUnavailable, map[string]interface{}{"error": map[string]interface{}{"message": "down"}}
+	})
+	testconfig.Use(t, openAIConfig("flaky", fake.URL, "breaker_threshold = 2\nbreaker_cooldown = 60"))
+
+	client, err := NewLLM("flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil); err == nil {
+			t.Fatal("expected the provider error")
+		}
+	}
+
+	// 熔断后不再请求提供者
+	_, err = client.GenerateResponse(context.Background(), userMessages("hi"), nil)
+	if !errors.Is(err, ErrCircuitOpen) || len(fake.Requests()) != 2 {
+		t.Fatalf("err = %v after %d requests, want ErrCircuitOpen after 2", err, len(fake.Requests()))
+	}
+	if stats := BreakerStatsByConfig()["flaky"]; stats.State != BreakerOpen || stats.ConsecutiveFailures != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// 同名配置的客户端共享熔断器
+	other, err := NewLLM("flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.GenerateResponse(context.Background(), userMessages("hi"), nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second client err = %v, want ErrCircuitOpen", err)
+	}
+
+	// 冷却结束后探测成功则恢复
+	client.breaker.mu.Lock()
+	client.breaker.openedAt = time.Now().Add(-time.Minute)
+	client.breaker.mu.Unlock()
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+
+	response, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if *response.Content != "恢复了" || client.BreakerStats().State != BreakerClosed {
+		t.Errorf("content = %q, state = %s", *response.Content, client.BreakerStats().State)
+	}
+}
+
+func TestDifferentlyNamedClientsShareDefaultBreaker(t *testing.T) {
+	resetBreakers(t)
+	fake := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusServiceUnavailable, map[string]interface{}{"error": map[string]interface{}{"message": "down"}}
+	})
+	testconfig.Use(t, openAIConfig("default", fake.URL, "breaker_threshold = 2\nbreaker_cooldown = 60"))
+
+	// 智能体按自己的名称创建客户端，没有同名配置时都使用默认配置
+	manus, _ := NewLLM("Manus")
+	planner, _ := NewLLM("Planner")
+	manus.GenerateResponse(context.Background(), userMessages("hi"), nil)
+	planner.GenerateResponse(context.Background(), userMessages("hi"), nil)
+
+	if _, err := manus.GenerateResponse(context.Background(), userMessages("hi"), nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want ErrCircuitOpen after failures from both clients", err)
+	}
+	stats := BreakerStatsByConfig()
+	if _, ok := stats["Manus"]; ok || stats["default"].State != BreakerOpen {
+		t.Errorf("stats = %+v, want one open breaker keyed by the default config", stats)
+	}
+}
+
+func TestBreakerEnabledAfterReload(t *testing.T) {
+	resetBreakers(t)
+	testconfig.Use(t, baseTestConfig)
+	if client, _ := NewLLM("default"); client.breaker != nil {
+		t.Fatal("breaker created without a threshold")
+	}
+
+	testconfig.Write(t, baseTestConfig+"breaker_threshold = 3\n")
+	client, _ := NewLLM("default")
+	if client.breaker == nil || client.breaker.threshold != 3 {
+		t.Error("breaker not created after breaker_threshold was configured")
+	}
+}
+
+func TestLimiterTimeoutLeavesBreakerClosed(t *testing.T) {
+	resetBreakers(t)
+	testconfig.Use(t, baseTestConfig+`
+[llm.throttled]
+model = "mock"
+api_type = "mock"
+requests_per_minute = 1
+breaker_threshold = 1
+breaker_cooldown = 60
+`)
+	t.Cleanup(func() {
+		rateLimitersMu.Lock()
+		defer rateLimitersMu.Unlock()
+		delete(rateLimiters, "throttled")
+	})
+	client, err := NewLLM("throttled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// 限流额度已耗尽，等待超时的请求没有发出
+	generate := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := client.GenerateResponse(ctx, userMessages("hi"), nil)
+		return err
+	}
+	if err := generate(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want the limiter timeout", err)
+	}
+	if stats := client.BreakerStats(); stats.State != BreakerClosed || stats.ConsecutiveFailures != 0 {
+		t.Errorf("stats after limiter timeout = %+v, want closed", stats)
+	}
+
+	// 半开状态下探测请求在限流处超时，释放探测名额
+	client.breaker.mu.Lock()
+	client.breaker.state = BreakerOpen
+	client.breaker.openedAt = time.Now().Add(-time.Minute)
+	client.breaker.mu.Unlock()
+	if err := generate(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("probe err = %v, want the limiter timeout", err)
+	}
+	if err := client.breaker.Allow(); err != nil {
+		t.Errorf("probe not released after limiter timeout: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// debugForced 命令行强制开启的调试模式
+	debugForced atomic.Bool
+
+	debugFileOnce   sync.Once
+	debugFileLogger *zap.Logger
+)
+
+// SetDebug 开启或关闭LLM请求/响应调试日志（对应 --debug-llm）
+func SetDebug(enabled bool) {
+	debugForced.Store(enabled)
+}
+
+// DebugEnabled 检查是否记录完整的LLM请求和响应
+func DebugEnabled() bool {
+	if debugForced.Load() {
+		return true
+	}
+	settings := config.GetConfig().GetDebugSettings()
+	return settings != nil && settings.LLM
+}
+
+// debugLogger 获取调试日志器，配置了独立日志文件时写入该文件
+func debugLogger() *zap.Logger {
+	settings := config.GetConfig().GetDebugSettings()
+	if settings == nil || settings.LLMLogFile == "" {
+		return logger.GetLogger()
+	}
+
+	debugFileOnce.Do(func() {
+		if err := os.MkdirAll(filepath.Dir(settings.LLMLogFile), 0755); err != nil {
+			logger.Warn("创建LLM调试日志目录失败", zap.Error(err))
+			return
+		}
+		file, err := os.OpenFile(settings.LLMLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			logger.Warn("打开LLM调试日志文件失败", zap.Error(err))
+			return
+		}
+		encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+		debugFileLogger = zap.New(zapcore.NewCore(encoder, zapcore.AddSync(file), zapcore.DebugLevel))
+	})
+
+	if debugFileLogger == nil {
+		return logger.GetLogger()
+	}
+	return debugFileLogger
+}
+
+// redactJSON 序列化为JSON并屏蔽密钥
+func (l *LLM) redactJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err.Error()
+	}
+	return logger.RedactSecrets(string(data), l.settings.APIKey)
+}
+
+// logDebugRequest 记录完整的LLM请求
+func (l *LLM) logDebugRequest(messages []schema.Message, tools []schema.ToolDefinition) {
+	if !DebugEnabled() {
+		return
+	}
+	debugLogger().Debug("LLM请求",
+		zap.String("config", l.configName),
+		zap.String("model", l.settings.Model),
+		zap.String("messages", l.redactJSON(messages)),
+		zap.String("tools", l.redactJSON(tools)))
+}
+
+// logDebugResponse 记录完整的LLM响应或错误
+func (l *LLM) logDebugResponse(response *schema.Message, err error) {
+	if !DebugEnabled() {
+		return
+	}
+	if err != nil {
+		debugLogger().Debug("LLM响应失败",
+			zap.String("config", l.configName),
+			zap.String("error", logger.RedactSecrets(err.Error(), l.settings.APIKey)))
+		return
+	}
+	debugLogger().Debug("LLM响应",
+		zap.String("config", l.configName),
+		zap.String("response", l.redactJSON(response)))
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+func TestDebugLogHasPromptButNotKey(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "llm-debug.log")
+	const key = "custom-secret-value-0042"
+	testconfig.Use(t, baseTestConfig+`
+[llm.debugged]
+model = "mock"
+api_type = "mock"
+api_key = "`+key+`"
+
+[debug]
+llm = true
+llm_log_file = "`+logFile+`"
+`)
+	// 调试日志文件只打开一次，结束时重置以便重复运行
+	t.Cleanup(func() {
+		debugFileOnce = sync.Once{}
+		debugFileLogger = nil
+	})
+	client, err := NewLLM("debugged")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := "介绍一下地鼠 api_key=" + key
+	if _, err := client.GenerateResponse(context.Background(), userMessages(prompt), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if !strings.Contains(log, "介绍一下地鼠") || !strings.Contains(log, "LLM请求") || !strings.Contains(log, "LLM响应") {
+		t.Errorf("debug log lacks the request or response:\n%s", log)
+	}
+	if strings.Contains(log, key) {
+		t.Errorf("debug log contains the API key:\n%s", log)
+	}
+}
+
+func TestDebugLogDisabledByDefault(t *testing.T) {
+	if DebugEnabled() {
+		t.Error("LLM debug logging enabled without configuration")
+	}
+	SetDebug(true)
+	defer SetDebug(false)
+	if !DebugEnabled() {
+		t.Error("SetDebug(true) did not enable debug logging")
+	}
+}
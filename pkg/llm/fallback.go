@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// newFallbacks 按配置顺序创建备用LLM客户端，备用客户端自身不再配置备用
+// configName 为主客户端实际使用的配置名称；未配置的名称（如拼写错误）和与主配置相同的名称记录警告后跳过，
+// 否则会回退到默认配置，切换后仍请求刚刚失败的提供者。无法创建的备用配置（如缺少密钥）同样跳过
+func newFallbacks(configName string, names []string) []*LLM {
+	var fallbacks []*LLM
+	for _, name := range names {
+		if name == configName {
+			logger.Warn("备用LLM配置与主配置相同，已跳过",
+				zap.String("config", configName),
+				zap.String("fallback", name))
+			continue
+		}
+		if _, ok := config.GetConfig().GetLLMSettings(name); !ok {
+			logger.Warn("备用LLM配置不存在，已跳过",
+				zap.String("config", configName),
+				zap.String("fallback", name))
+			continue
+		}
+		fallback, err := newLLM(name, false)
+		if err != nil {
+			logger.Warn("创建备用LLM客户端失败，已跳过",
+				zap.String("config", configName),
+				zap.String("fallback", name),
+				zap.Error(err))
+			continue
+		}
+		fallbacks = append(fallbacks, fallback)
+	}
+	return fallbacks
+}
+
+// shouldFailover 检查错误是否表示提供者不可用，换用备用提供者可能成功
+// 鉴权失败、额度耗尽、服务端故障、网络错误和熔断会切换；请求本身的问题（如内容过滤、参数错误）和取消不会
+func shouldFailover(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrContentFiltered) {
+		return false
+	}
+	if errors.Is(err, ErrMissingAPIKey) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return failoverStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return failoverStatus(reqErr.HTTPStatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// failoverStatus 需要切换提供者的HTTP状态码
+func failoverStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= http.StatusInternalServerError
+}
+
+// GenerateResponse 生成响应，主提供者不可用时依次尝试备用提供者
+func (l *LLM) GenerateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error) {
+	response, err := l.generateResponse(ctx, messages, tools)
+	for _, fallback := range l.fallbacks {
+		if !shouldFailover(err) || ctx.Err() != nil {
+			break
+		}
+		logger.Warn("LLM提供者不可用，切换到备用提供者",
+			zap.String("config", l.configName),
+			zap.String("fallback", fallback.configName),
+			zap.Error(err))
+		response, err = fallback.generateResponse(ctx, messages, tools)
+	}
+	return response, err
+}
+
+// GenerateStreamResponse 生成流式响应，主提供者建立连接失败时依次尝试备用提供者
+func (l *LLM) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan StreamChunk, error) {
+	stream, err := l.generateStreamResponse(ctx, messages, tools)
+	for _, fallback := range l.fallbacks {
+		if !shouldFailover(err) || ctx.Err() != nil {
+			break
+		}
+		logger.Warn("LLM提供者不可用，切换到备用提供者",
+			zap.String("config", l.configName),
+			zap.String("fallback", fallback.configName),
+			zap.Error(err))
+		stream, err = fallback.generateStreamResponse(ctx, messages, tools)
+	}
+	return stream, err
+}
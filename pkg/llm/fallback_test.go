@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// failingOpenAI 总是以给定状态码失败的模拟服务
+func failingOpenAI(t *testing.T, status int) *fakeOpenAI {
+	return newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return status, map[string]interface{}{"error": map[string]interface{}{"message": http.StatusText(status)}}
+	})
+}
+
+func TestFailoverToSecondary(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			primary := failingOpenAI(t, status)
+			secondary := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+				return http.StatusOK, textCompletion("来自备用")
+			})
+			testconfig.Use(t, openAIConfig("primary", primary.URL, `fallbacks = ["secondary"]`)+
+				openAIConfig("secondary", secondary.URL, ""))
+
+			client, err := NewLLM("primary")
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil)
+			if err != nil {
+				t.Fatalf("failover failed: %v", err)
+			}
+			if *response.Content != "来自备用" || len(primary.Requests()) != 1 || len(secondary.Requests()) != 1 {
+				t.Errorf("content = %q, primary requests = %d, secondary requests = %d",
+					*response.Content, len(primary.Requests()), len(secondary.Requests()))
+			}
+		})
+	}
+}
+
+func TestNoFailoverOnBadRequest(t *testing.T) {
+	primary := failingOpenAI(t, http.StatusBadRequest)
+	secondary := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, textCompletion("来自备用")
+	})
+	testconfig.Use(t, openAIConfig("primary", primary.URL, `fallbacks = ["secondary"]`)+
+		openAIConfig("secondary", secondary.URL, ""))
+
+	client, err := NewLLM("primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil); err == nil {
+		t.Error("expected the bad request error")
+	}
+	if len(secondary.Requests()) != 0 {
+		t.Error("a bad request should not fail over")
+	}
+}
+
+func TestFailoverSkipsUnusableAndTriesInOrder(t *testing.T) {
+	primary := failingOpenAI(t, http.StatusBadGateway)
+	second := failingOpenAI(t, http.StatusServiceUnavailable)
+	third := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, textCompletion("第三个")
+	})
+	testconfig.Use(t, openAIConfig("primary", primary.URL, `fallbacks = ["keyless", "second", "third"]`)+
+		`[llm.keyless]
+model = "gpt-4o"
+api_type = "openai"
+`+openAIConfig("second", second.URL, "")+openAIConfig("third", third.URL, ""))
+
+	client, err := NewLLM("primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *response.Content != "第三个" || len(second.Requests()) != 1 {
+		t.Errorf("content = %q, second requests = %d", *response.Content, len(second.Requests()))
+	}
+}
+
+func TestStreamFailsOverWhenOpeningFails(t *testing.T) {
+	primary := failingOpenAI(t, http.StatusServiceUnavailable)
+	secondary := newFakeStream(t, delta("备用", ""), delta("流", openai.FinishReasonStop))
+	testconfig.Use(t, openAIConfig("primary", primary.URL, `fallbacks = ["secondary"]`)+
+		openAIConfig("secondary", secondary.URL, ""))
+
+	client, err := NewLLM("primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.GenerateStreamResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatalf("stream failover failed: %v", err)
+	}
+	if _, final := collect(stream); final == nil || *final.Content != "备用流" {
+		t.Errorf("final message = %+v", final)
+	}
+}
+
+func TestUnknownFallbackIsSkipped(t *testing.T) {
+	primary := failingOpenAI(t, http.StatusServiceUnavailable)
+	defaultProvider := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, textCompletion("来自默认")
+	})
+	// secondary 拼写错误，不能回退到默认配置
+	testconfig.Use(t, openAIConfig("default", defaultProvider.URL, "")+
+		openAIConfig("primary", primary.URL, `fallbacks = ["secondray"]`))
+
+	client, err := NewLLM("primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.fallbacks) != 0 {
+		t.Fatalf("fallbacks = %d, want the unknown name skipped", len(client.fallbacks))
+	}
+	if _, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil); err == nil {
+		t.Error("expected the primary error")
+	}
+	if len(defaultProvider.Requests()) != 0 {
+		t.Error("unknown fallback name fell back to the default config")
+	}
+}
+
+func TestFallbackResolvingToPrimaryIsSkipped(t *testing.T) {
+	provider := failingOpenAI(t, http.StatusServiceUnavailable)
+	testconfig.Use(t, openAIConfig("default", provider.URL, `fallbacks = ["default"]`))
+
+	// 智能体名称没有同名配置，实际使用默认配置
+	client, err := NewLLM("Manus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.fallbacks) != 0 {
+		t.Errorf("fallbacks = %d, want the primary config skipped", len(client.fallbacks))
+	}
+}
@@ -2,8 +2,12 @@ package llm
 
 import (
     "context"
+    "encoding/json"
+    "errors"
     "fmt"
+    "io"
     "strings"
+    "time"
 
     "github.com/sashabaranov/go-openai"
     "github.com/yahao333/GoManus/pkg/config"
@@ -12,57 +16,201 @@ import (
     "go.uber.org/zap"
 )
 
+// ErrMissingAPIKey 需要API密钥的提供者未配置密钥
+var ErrMissingAPIKey = errors.New("no API key configured")
+
+// ErrContentFiltered 响应被提供者的内容过滤拦截
+var ErrContentFiltered = errors.New("response blocked by content filter")
+
+// maxEmptyResponseRetries 空响应的最大重试次数
+const maxEmptyResponseRetries = 3
+
+// emptyResponseBackoff 空响应重试的初始退避时间
+var emptyResponseBackoff = 500 * time.Millisecond
+
+// requireAPIKey 检查需要鉴权的提供者是否配置了API密钥
+func requireAPIKey(settings config.LLMSettings) error {
+	if strings.TrimSpace(settings.APIKey) == "" {
+		return fmt.Errorf("%w for provider '%s'", ErrMissingAPIKey, strings.ToLower(settings.APIType))
+	}
+	return nil
+}
+
 // Provider LLM提供者接口
 type Provider interface {
 	GenerateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error)
-	GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan string, error)
+	GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan StreamChunk, error)
 }
 
 // LLM LLM客户端
 type LLM struct {
 	provider   Provider
 	configName string
+	settings   config.LLMSettings
+	limiter    *RateLimiter
+	breaker    *CircuitBreaker
+	fallbacks  []*LLM
+}
+
+// SettingsOverride 请求级LLM配置覆盖，nil字段沿用原配置
+type SettingsOverride struct {
+	Model          *string
+	BaseURL        *string
+	APIKey         *string
+	MaxTokens      *int
+	Temperature    *float64
+	APIType        *string
+	APIVersion     *string
+	Seed           *int
+	ResponseFormat *string
 }
 
-// NewLLM 创建新的LLM客户端
+// NewLLM 创建新的LLM客户端，配置了 fallbacks 时同时创建备用客户端
 func NewLLM(configName string) (*LLM, error) {
+	return newLLM(configName, true)
+}
+
+// newLLM 创建LLM客户端，withFallbacks 为false时忽略备用配置
+func newLLM(configName string, withFallbacks bool) (*LLM, error) {
+	// 没有同名配置时（如按智能体名称创建）使用默认配置，限流器和熔断器按实际使用的配置共享
 	settings, ok := config.GetConfig().GetLLMSettings(configName)
+	resolvedName := configName
 	if !ok {
 		settings = config.GetConfig().GetDefaultLLMSettings()
+		resolvedName = "default"
 	}
 
-	var provider Provider
-	var err error
+	provider, err := newProvider(settings)
+	if err != nil {
+		return nil, err
+	}
 
+	client := &LLM{
+		provider:   provider,
+		configName: configName,
+		settings:   settings,
+		limiter:    getRateLimiter(resolvedName, settings),
+		breaker:    getCircuitBreaker(resolvedName, settings),
+	}
+	if withFallbacks {
+		client.fallbacks = newFallbacks(resolvedName, settings.Fallbacks)
+	}
+	return client, nil
+}
+
+// newProvider 根据配置创建提供者
+func newProvider(settings config.LLMSettings) (Provider, error) {
 	switch strings.ToLower(settings.APIType) {
 	case "openai":
-		provider, err = NewOpenAIProvider(settings)
+		return NewOpenAIProvider(settings)
 	case "azure":
-		provider, err = NewAzureProvider(settings)
+		return NewAzureProvider(settings)
 	case "ollama":
-		provider, err = NewOllamaProvider(settings)
+		return NewOllamaProvider(settings)
+	case "mock":
+		return NewMockProvider(settings)
 	default:
 		return nil, fmt.Errorf("不支持的API类型: %s", settings.APIType)
 	}
+}
 
+// WithSettings 基于当前配置应用覆盖项，返回派生的LLM客户端
+// 原客户端不受影响，可用于为单个步骤切换模型或降低温度
+func (l *LLM) WithSettings(override SettingsOverride) (*LLM, error) {
+	settings := l.settings
+	if override.Model != nil {
+		settings.Model = *override.Model
+	}
+	if override.BaseURL != nil {
+		settings.BaseURL = *override.BaseURL
+	}
+	if override.APIKey != nil {
+		settings.APIKey = *override.APIKey
+	}
+	if override.MaxTokens != nil {
+		settings.MaxTokens = *override.MaxTokens
+	}
+	if override.Temperature != nil {
+		settings.Temperature = *override.Temperature
+	}
+	if override.APIType != nil {
+		settings.APIType = *override.APIType
+	}
+	if override.APIVersion != nil {
+		settings.APIVersion = *override.APIVersion
+	}
+	if override.Seed != nil {
+		seed := *override.Seed
+		settings.Seed = &seed
+	}
+	if override.ResponseFormat != nil {
+		settings.ResponseFormat = *override.ResponseFormat
+	}
+
+	provider, err := newProvider(settings)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LLM{
 		provider:   provider,
-		configName: configName,
+		configName: l.configName,
+		settings:   settings,
+		limiter:    l.limiter,
+		breaker:    l.breaker,
+		fallbacks:  l.fallbacks,
 	}, nil
 }
 
-// GenerateResponse 生成响应
-func (l *LLM) GenerateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error) {
-	return l.provider.GenerateResponse(ctx, messages, tools)
+// GetSettings 获取当前生效的LLM配置
+func (l *LLM) GetSettings() config.LLMSettings {
+	return l.settings
 }
 
-// GenerateStreamResponse 生成流式响应
-func (l *LLM) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan string, error) {
-	return l.provider.GenerateStreamResponse(ctx, messages, tools)
+// generateResponse 使用当前提供者生成响应
+func (l *LLM) generateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error) {
+	if err := l.breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, l.configName)
+	}
+
+	estimated := EstimateTokens(messages)
+	if err := l.limiter.Wait(ctx, estimated); err != nil {
+		// 请求未发出，限流等待超时不是提供者故障
+		l.breaker.abandon()
+		return nil, fmt.Errorf("等待限流额度失败: %w", err)
+	}
+
+	l.logDebugRequest(messages, tools)
+	response, err := l.provider.GenerateResponse(ctx, messages, tools)
+	l.logDebugResponse(response, err)
+	l.recordBreaker(err)
+	if err != nil {
+		// 提供者错误可能包含密钥或带凭据的地址
+		return nil, logger.RedactError(err)
+	}
+
+	// 按实际用量修正预扣令牌
+	if response.Usage != nil && response.Usage.TotalTokens > 0 {
+		l.limiter.Adjust(response.Usage.TotalTokens - estimated)
+	}
+	recordPromptCache(l.configName, response.Usage)
+	return response, nil
+}
+
+// generateStreamResponse 使用当前提供者生成流式响应
+func (l *LLM) generateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan StreamChunk, error) {
+	if err := l.breaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, l.configName)
+	}
+	if err := l.limiter.Wait(ctx, EstimateTokens(messages)); err != nil {
+		// 请求未发出，限流等待超时不是提供者故障
+		l.breaker.abandon()
+		return nil, fmt.Errorf("等待限流额度失败: %w", err)
+	}
+	l.logDebugRequest(messages, tools)
+	stream, err := l.provider.GenerateStreamResponse(ctx, messages, tools)
+	l.recordBreaker(err)
+	return stream, err
 }
 
 // OpenAIProvider OpenAI提供者
@@ -73,10 +221,15 @@ type OpenAIProvider struct {
 
 // NewOpenAIProvider 创建OpenAI提供者
 func NewOpenAIProvider(settings config.LLMSettings) (*OpenAIProvider, error) {
+	if err := requireAPIKey(settings); err != nil {
+		return nil, err
+	}
+
 	config := openai.DefaultConfig(settings.APIKey)
 	if settings.BaseURL != "" {
 		config.BaseURL = settings.BaseURL
 	}
+	config.HTTPClient = newCacheUsageClient()
 
 	client := openai.NewClientWithConfig(config)
 	return &OpenAIProvider{
@@ -87,31 +240,18 @@ func NewOpenAIProvider(settings config.LLMSettings) (*OpenAIProvider, error) {
 
 // GenerateResponse 生成响应
 func (o *OpenAIProvider) GenerateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error) {
-	openaiMessages := o.convertMessages(messages)
-	openaiTools := o.convertTools(tools)
+	req := o.buildRequest(messages, tools)
 
-	req := openai.ChatCompletionRequest{
-		Model:       o.config.Model,
-		Messages:    openaiMessages,
-		MaxTokens:   o.config.MaxTokens,
-		Temperature: float32(o.config.Temperature),
-	}
-
-	if len(openaiTools) > 0 {
-		req.Tools = openaiTools
-	}
-
-	resp, err := o.client.CreateChatCompletion(ctx, req)
+	ctx, cachedTokens := withCachedTokens(ctx)
+	resp, err := o.createChatCompletion(ctx, req)
 	if err != nil {
-		logger.Error("OpenAI API调用失败", zap.Error(err))
 		return nil, err
 	}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("没有收到响应")
-	}
-
 	choice := resp.Choices[0]
+	if choice.FinishReason == openai.FinishReasonContentFilter {
+		return nil, fmt.Errorf("%w: model %s", ErrContentFiltered, o.config.Model)
+	}
 	content := choice.Message.Content
 
 	// 转换工具调用
@@ -131,53 +271,102 @@ func (o *OpenAIProvider) GenerateResponse(ctx context.Context, messages []schema
 	}
 
 	return &schema.Message{
-		Role:      schema.RoleAssistant,
-		Content:   &content,
-		ToolCalls: toolCalls,
+		Role:         schema.RoleAssistant,
+		Content:      &content,
+		ToolCalls:    toolCalls,
+		FinishReason: string(choice.FinishReason),
+		Usage: &schema.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+			CachedTokens:     *cachedTokens,
+		},
 	}, nil
 }
 
-// GenerateStreamResponse 生成流式响应
-func (o *OpenAIProvider) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan string, error) {
-	openaiMessages := o.convertMessages(messages)
-	openaiTools := o.convertTools(tools)
+// createChatCompletion 调用聊天补全接口，空响应视为临时故障并按退避重试
+func (o *OpenAIProvider) createChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	backoff := emptyResponseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := o.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			logger.Error("OpenAI API调用失败", zap.Error(err))
+			return resp, err
+		}
+		if len(resp.Choices) > 0 {
+			return resp, nil
+		}
 
-	req := openai.ChatCompletionRequest{
-		Model:       o.config.Model,
-		Messages:    openaiMessages,
-		MaxTokens:   o.config.MaxTokens,
-		Temperature: float32(o.config.Temperature),
-		Stream:      true,
-	}
+		raw, _ := json.Marshal(resp)
+		logger.Warn("收到空响应",
+			zap.Int("attempt", attempt+1),
+			zap.ByteString("response", raw))
+		if attempt >= maxEmptyResponseRetries {
+			return resp, fmt.Errorf("没有收到响应（已重试%d次）", maxEmptyResponseRetries)
+		}
 
-	if len(openaiTools) > 0 {
-		req.Tools = openaiTools
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return resp, ctx.Err()
+		}
+		backoff *= 2
 	}
+}
+
+// GenerateStreamResponse 生成流式响应
+func (o *OpenAIProvider) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan StreamChunk, error) {
+	req := o.buildRequest(messages, tools)
+	req.Stream = true
 
 	stream, err := o.client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	resultChan := make(chan string, 100)
+	resultChan := make(chan StreamChunk, 100)
 
 	go func() {
 		defer close(resultChan)
 		defer stream.Close()
 
+		// send 发送片段，上下文取消时放弃发送，避免消费者退出后协程阻塞
+		send := func(chunk StreamChunk) bool {
+			select {
+			case resultChan <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		accumulator := newStreamAccumulator()
 		for {
 			response, err := stream.Recv()
 			if err != nil {
-				if err.Error() != "EOF" {
+				switch {
+				case errors.Is(err, io.EOF):
+					// 流结束时发送组装完成的消息
+					send(StreamChunk{Message: accumulator.message()})
+				case ctx.Err() != nil:
+					logger.Info("流式响应已取消", zap.Error(ctx.Err()))
+					// 消费者可能已不再读取，缓冲区满时放弃发送
+					select {
+					case resultChan <- StreamChunk{Err: ctx.Err()}:
+					default:
+					}
+				default:
 					logger.Error("流式响应接收失败", zap.Error(err))
+					send(StreamChunk{Err: fmt.Errorf("接收流式响应失败: %w", err)})
 				}
 				return
 			}
 
 			if len(response.Choices) > 0 {
-				content := response.Choices[0].Delta.Content
-				if content != "" {
-					resultChan <- content
+				choice := response.Choices[0]
+				accumulator.add(choice)
+				if choice.Delta.Content != "" && !send(StreamChunk{Content: choice.Delta.Content}) {
+					return
 				}
 			}
 		}
@@ -186,6 +375,31 @@ func (o *OpenAIProvider) GenerateStreamResponse(ctx context.Context, messages []
 	return resultChan, nil
 }
 
+// buildRequest 构建聊天补全请求，未配置的采样参数保持零值，由omitempty省略
+func (o *OpenAIProvider) buildRequest(messages []schema.Message, tools []schema.ToolDefinition) openai.ChatCompletionRequest {
+	req := openai.ChatCompletionRequest{
+		Model:            o.config.Model,
+		Messages:         o.convertMessages(messages),
+		MaxTokens:        o.config.MaxTokens,
+		Temperature:      float32(o.config.Temperature),
+		TopP:             float32(o.config.TopP),
+		PresencePenalty:  float32(o.config.PresencePenalty),
+		FrequencyPenalty: float32(o.config.FrequencyPenalty),
+		Stop:             o.config.Stop,
+		Seed:             o.config.Seed,
+	}
+
+	if o.config.ResponseFormat != "" {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatType(o.config.ResponseFormat),
+		}
+	}
+	if openaiTools := o.convertTools(tools); len(openaiTools) > 0 {
+		req.Tools = openaiTools
+	}
+	return req
+}
+
 // convertMessages 转换消息格式
 func (o *OpenAIProvider) convertMessages(messages []schema.Message) []openai.ChatCompletionMessage {
 	openaiMessages := make([]openai.ChatCompletionMessage, len(messages))
@@ -264,10 +478,15 @@ type AzureProvider struct {
 
 // NewAzureProvider 创建Azure提供者
 func NewAzureProvider(settings config.LLMSettings) (*AzureProvider, error) {
+	if err := requireAPIKey(settings); err != nil {
+		return nil, err
+	}
+
 	config := openai.DefaultAzureConfig(settings.APIKey, settings.BaseURL)
 	if settings.APIVersion != "" {
 		config.APIVersion = settings.APIVersion
 	}
+	config.HTTPClient = newCacheUsageClient()
 
 	client := openai.NewClientWithConfig(config)
 	return &AzureProvider{
@@ -304,11 +523,16 @@ func (o *OllamaProvider) GenerateResponse(ctx context.Context, messages []schema
 }
 
 // GenerateStreamResponse 生成流式响应
-func (o *OllamaProvider) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan string, error) {
-	resultChan := make(chan string, 1)
+func (o *OllamaProvider) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan StreamChunk, error) {
+	resultChan := make(chan StreamChunk, 2)
 	go func() {
 		defer close(resultChan)
-		resultChan <- "Ollama流式响应（未实现）"
+		content := "Ollama流式响应（未实现）"
+		resultChan <- StreamChunk{Content: content}
+		resultChan <- StreamChunk{Message: &schema.Message{
+			Role:    schema.RoleAssistant,
+			Content: &content,
+		}}
 	}()
 	return resultChan, nil
 }
@@ -0,0 +1,278 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// fakeOpenAI 记录收到的聊天补全请求并返回 respond 生成的响应的OpenAI兼容服务
+type fakeOpenAI struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []openai.ChatCompletionRequest
+	// fields 各次请求体的顶层字段，用于检查字段是否出现
+	fields []map[string]json.RawMessage
+}
+
+// newFakeOpenAI 启动模拟服务，respond 返回第n次（从0开始）请求的状态码和响应体
+func newFakeOpenAI(t *testing.T, respond func(n int, req openai.ChatCompletionRequest) (int, interface{})) *fakeOpenAI {
+	t.Helper()
+	fake := &fakeOpenAI{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		var fields map[string]json.RawMessage
+		data, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		json.Unmarshal(data, &fields)
+		fake.mu.Lock()
+		n := len(fake.requests)
+		fake.requests = append(fake.requests, req)
+		fake.fields = append(fake.fields, fields)
+		fake.mu.Unlock()
+
+		status, body := respond(n, req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+// Requests 返回收到的请求
+func (f *fakeOpenAI) Requests() []openai.ChatCompletionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]openai.ChatCompletionRequest(nil), f.requests...)
+}
+
+// Fields 返回第n次请求体的顶层字段
+func (f *fakeOpenAI) Fields(n int) map[string]json.RawMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fields[n]
+}
+
+// openAIConfig 指向模拟服务的OpenAI配置段，extra 为追加的配置行
+func openAIConfig(name, baseURL, extra string) string {
+	return fmt.Sprintf(`[llm.%s]
+model = "gpt-4o"
+api_key = "sk-test"
+api_type = "openai"
+base_url = "%s/v1"
+%s
+`, name, baseURL, extra)
+}
+
+// textCompletion 只包含文本内容的补全响应
+func textCompletion(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			FinishReason: openai.FinishReasonStop,
+		}},
+	}
+}
+
+// newTestProvider 创建连接到 baseURL 的OpenAI提供者
+func newTestProvider(t *testing.T, baseURL string) *OpenAIProvider {
+	t.Helper()
+	provider, err := NewOpenAIProvider(config.LLMSettings{Model: "gpt-4o", APIKey: "sk-test", BaseURL: baseURL + "/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// userMessages 单条用户消息
+func userMessages(content string) []schema.Message {
+	return []schema.Message{schema.NewUserMessage(content)}
+}
+
+func TestWithSettingsOverridesOutgoingRequest(t *testing.T) {
+	fake := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, textCompletion("ok")
+	})
+	testconfig.Use(t, openAIConfig("override", fake.URL, "temperature = 0.7"))
+
+	client, err := NewLLM("override")
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, temperature := "gpt-4o-mini", 0.1
+	derived, err := client.WithSettings(SettingsOverride{Model: &model, Temperature: &temperature})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := derived.GenerateResponse(ctx, userMessages("plan"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GenerateResponse(ctx, userMessages("execute"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	if got := requests[0]; got.Model != model || got.Temperature != float32(temperature) {
+		t.Errorf("override request model=%q temperature=%v, want %q %v", got.Model, got.Temperature, model, temperature)
+	}
+	if got := requests[1]; got.Model != "gpt-4o" || got.Temperature != float32(0.7) {
+		t.Errorf("original request model=%q temperature=%v, want gpt-4o 0.7", got.Model, got.Temperature)
+	}
+	if client.GetSettings().Model != "gpt-4o" {
+		t.Errorf("WithSettings modified the original client: %q", client.GetSettings().Model)
+	}
+}
+
+func TestKeylessProviderFailsEarly(t *testing.T) {
+	for _, apiType := range []string{"openai", "azure"} {
+		for _, key := range []string{"", "   "} {
+			_, err := newProvider(config.LLMSettings{Model: "gpt-4o", APIType: apiType, APIKey: key, BaseURL: "http://localhost"})
+			if !errors.Is(err, ErrMissingAPIKey) {
+				t.Errorf("%s with key %q: err = %v, want ErrMissingAPIKey", apiType, key, err)
+				continue
+			}
+			if !strings.Contains(err.Error(), "'"+apiType+"'") {
+				t.Errorf("error %q does not name the provider", err)
+			}
+		}
+	}
+}
+
+func TestKeylessOpenAIConfigFailsInNewLLM(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[llm.keyless]
+model = "gpt-4o"
+api_type = "OpenAI"
+`)
+	if _, err := NewLLM("keyless"); !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("NewLLM error = %v, want ErrMissingAPIKey", err)
+	}
+}
+
+func TestOllamaNeedsNoKey(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[llm.local]
+model = "llama3"
+api_type = "ollama"
+base_url = "http://localhost:11434"
+`)
+	if _, err := NewLLM("local"); err != nil {
+		t.Errorf("NewLLM for keyless Ollama: %v", err)
+	}
+}
+
+// useEmptyResponseBackoff 缩短空响应重试的退避时间，测试结束后恢复
+func useEmptyResponseBackoff(t *testing.T) {
+	original := emptyResponseBackoff
+	emptyResponseBackoff = time.Millisecond
+	t.Cleanup(func() { emptyResponseBackoff = original })
+}
+
+func TestEmptyResponseIsRetried(t *testing.T) {
+	useEmptyResponseBackoff(t)
+	fake := newFakeOpenAI(t, func(n int, req openai.ChatCompletionRequest) (int, interface{}) {
+		if n < 2 {
+			return http.StatusOK, openai.ChatCompletionResponse{}
+		}
+		return http.StatusOK, textCompletion("终于有内容")
+	})
+
+	response, err := newTestProvider(t, fake.URL).GenerateResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *response.Content != "终于有内容" || len(fake.Requests()) != 3 {
+		t.Errorf("content = %q after %d requests", *response.Content, len(fake.Requests()))
+	}
+}
+
+func TestEmptyResponsesGiveUpAfterRetries(t *testing.T) {
+	useEmptyResponseBackoff(t)
+	fake := newFakeOpenAI(t, func(n int, req openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, openai.ChatCompletionResponse{}
+	})
+
+	if _, err := newTestProvider(t, fake.URL).GenerateResponse(context.Background(), userMessages("hi"), nil); err == nil {
+		t.Error("expected an error after repeated empty responses")
+	}
+	if got := len(fake.Requests()); got != maxEmptyResponseRetries+1 {
+		t.Errorf("sent %d requests, want %d", got, maxEmptyResponseRetries+1)
+	}
+}
+
+func TestContentFilteredResponse(t *testing.T) {
+	fake := newFakeOpenAI(t, func(n int, req openai.ChatCompletionRequest) (int, interface{}) {
+		response := textCompletion("")
+		response.Choices[0].FinishReason = openai.FinishReasonContentFilter
+		return http.StatusOK, response
+	})
+
+	_, err := newTestProvider(t, fake.URL).GenerateResponse(context.Background(), userMessages("hi"), nil)
+	if !errors.Is(err, ErrContentFiltered) {
+		t.Errorf("err = %v, want ErrContentFiltered", err)
+	}
+	// 内容过滤不是临时故障，不重试
+	if len(fake.Requests()) != 1 {
+		t.Errorf("sent %d requests, want 1", len(fake.Requests()))
+	}
+}
+
+func TestSamplingFieldsSentOnlyWhenConfigured(t *testing.T) {
+	fake := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, textCompletion("ok")
+	})
+	sampling := []string{"top_p", "presence_penalty", "frequency_penalty", "stop"}
+
+	testconfig.Use(t, openAIConfig("plain", fake.URL, ""))
+	plain, _ := NewLLM("plain")
+	if _, err := plain.GenerateResponse(context.Background(), userMessages("hi"), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range sampling {
+		if value, ok := fake.Fields(0)[field]; ok {
+			t.Errorf("unconfigured %s sent as %s", field, value)
+		}
+	}
+
+	testconfig.Use(t, openAIConfig("sampling", fake.URL, `top_p = 0.5
+presence_penalty = 0.25
+frequency_penalty = -0.5
+stop = ["END", "###"]`))
+	tuned, _ := NewLLM("sampling")
+	if _, err := tuned.GenerateResponse(context.Background(), userMessages("hi"), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"top_p":             "0.5",
+		"presence_penalty":  "0.25",
+		"frequency_penalty": "-0.5",
+		"stop":              `["END","###"]`,
+	}
+	for _, field := range sampling {
+		if got := string(fake.Fields(1)[field]); got != want[field] {
+			t.Errorf("%s = %s, want %s", field, got, want[field])
+		}
+	}
+}
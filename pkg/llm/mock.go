@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// mockTerminateTool 回显模式下用于结束运行的工具名称
+const mockTerminateTool = "Terminate"
+
+// MockProvider 离线的确定性提供者，用于测试和演示
+// 按顺序返回配置的脚本化响应；脚本为空或用尽后回显最后一条用户消息
+type MockProvider struct {
+	responses []config.MockResponse
+	next      int
+	calls     int
+	mu        sync.Mutex
+}
+
+// NewMockProvider 创建mock提供者
+func NewMockProvider(settings config.LLMSettings) (*MockProvider, error) {
+	return &MockProvider{
+		responses: settings.MockResponses,
+	}, nil
+}
+
+// GenerateResponse 生成响应
+func (m *MockProvider) GenerateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.calls++
+	callID := fmt.Sprintf("mock_call_%d", m.calls)
+	var scripted *config.MockResponse
+	if m.next < len(m.responses) {
+		scripted = &m.responses[m.next]
+		m.next++
+	}
+	m.mu.Unlock()
+
+	var response schema.Message
+	if scripted != nil {
+		response = schema.NewAssistantMessage(scripted.Content)
+		if scripted.Tool != "" {
+			arguments := scripted.Arguments
+			if arguments == "" {
+				arguments = "{}"
+			}
+			response.ToolCalls = []schema.ToolCall{newMockToolCall(callID, scripted.Tool, arguments)}
+		}
+	} else {
+		response = m.echo(callID, messages, tools)
+	}
+
+	response.FinishReason = schema.FinishReasonStop
+	if len(response.ToolCalls) > 0 {
+		response.FinishReason = schema.FinishReasonToolCalls
+	}
+
+	completion := 0
+	if response.Content != nil {
+		completion = len(*response.Content) / 4
+	}
+	prompt := EstimateTokens(messages)
+	response.Usage = &schema.Usage{
+		PromptTokens:     prompt,
+		CompletionTokens: completion,
+		TotalTokens:      prompt + completion,
+	}
+	return &response, nil
+}
+
+// echo 回显最后一条用户消息，可用时通过Terminate工具结束运行
+func (m *MockProvider) echo(callID string, messages []schema.Message, tools []schema.ToolDefinition) schema.Message {
+	prompt := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schema.RoleUser && messages[i].Content != nil {
+			prompt = *messages[i].Content
+			break
+		}
+	}
+	content := "echo: " + prompt
+
+	response := schema.NewAssistantMessage(content)
+	for _, tool := range tools {
+		if tool.Name == mockTerminateTool {
+			arguments, _ := json.Marshal(map[string]string{"message": content})
+			response.ToolCalls = []schema.ToolCall{newMockToolCall(callID, mockTerminateTool, string(arguments))}
+			break
+		}
+	}
+	return response
+}
+
+// newMockToolCall 创建mock工具调用
+func newMockToolCall(id, name, arguments string) schema.ToolCall {
+	return schema.ToolCall{
+		ID:   id,
+		Type: "function",
+		Function: schema.Function{
+			Name:      name,
+			Arguments: arguments,
+		},
+	}
+}
+
+// GenerateStreamResponse 生成流式响应，按词输出响应内容，最后发送完整消息
+func (m *MockProvider) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan StreamChunk, error) {
+	response, err := m.GenerateResponse(ctx, messages, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	content := ""
+	if response.Content != nil {
+		content = *response.Content
+	}
+
+	resultChan := make(chan StreamChunk)
+	go func() {
+		defer close(resultChan)
+		for _, word := range strings.SplitAfter(content, " ") {
+			select {
+			case resultChan <- StreamChunk{Content: word}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case resultChan <- StreamChunk{Message: response}:
+		case <-ctx.Done():
+		}
+	}()
+	return resultChan, nil
+}
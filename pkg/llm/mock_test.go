@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// terminateTool 只包含Terminate的工具定义
+var terminateTool = []schema.ToolDefinition{{Name: "Terminate"}}
+
+func TestMockProviderFollowsScriptThenEchoes(t *testing.T) {
+	provider, _ := NewMockProvider(config.LLMSettings{MockResponses: []config.MockResponse{
+		{Content: "先读取文件", Tool: "StrReplaceEditor", Arguments: `{"command": "view", "path": "a.txt"}`},
+		{Content: "文件已读取"},
+	}})
+	ctx := context.Background()
+
+	first, _ := provider.GenerateResponse(ctx, userMessages("读取 a.txt"), terminateTool)
+	if *first.Content != "先读取文件" || len(first.ToolCalls) != 1 || first.ToolCalls[0].Function.Name != "StrReplaceEditor" {
+		t.Errorf("first response = %+v", first)
+	}
+	if first.FinishReason != schema.FinishReasonToolCalls {
+		t.Errorf("finish reason = %s, want %s", first.FinishReason, schema.FinishReasonToolCalls)
+	}
+
+	second, _ := provider.GenerateResponse(ctx, userMessages("读取 a.txt"), terminateTool)
+	if *second.Content != "文件已读取" || len(second.ToolCalls) != 0 || second.FinishReason != schema.FinishReasonStop {
+		t.Errorf("second response = %+v", second)
+	}
+
+	// 脚本用尽后回显最后一条用户消息，并通过Terminate结束
+	third, _ := provider.GenerateResponse(ctx, userMessages("你好"), terminateTool)
+	if *third.Content != "echo: 你好" || len(third.ToolCalls) != 1 || third.ToolCalls[0].Function.Arguments != `{"message":"echo: 你好"}` {
+		t.Errorf("echo response = %+v", third)
+	}
+	if third.ToolCalls[0].ID == first.ToolCalls[0].ID {
+		t.Errorf("tool call IDs repeat: %s", third.ToolCalls[0].ID)
+	}
+	if third.Usage == nil || third.Usage.PromptTokens == 0 {
+		t.Errorf("usage = %+v", third.Usage)
+	}
+
+	// 没有Terminate工具时只回显
+	if plain, _ := provider.GenerateResponse(ctx, userMessages("你好"), nil); len(plain.ToolCalls) != 0 {
+		t.Errorf("echo without Terminate has tool calls: %+v", plain.ToolCalls)
+	}
+}
+
+func TestMockProviderStreamsWords(t *testing.T) {
+	provider, _ := NewMockProvider(config.LLMSettings{MockResponses: []config.MockResponse{{Content: "one two three"}}})
+	stream, err := provider.GenerateStreamResponse(context.Background(), userMessages("count"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var words []string
+	var final *schema.Message
+	for chunk := range stream {
+		if chunk.Message != nil {
+			final = chunk.Message
+			continue
+		}
+		words = append(words, chunk.Content)
+	}
+	if strings.Join(words, "|") != "one |two |three" {
+		t.Errorf("streamed words = %q", words)
+	}
+	if final == nil || *final.Content != "one two three" {
+		t.Errorf("final message = %+v", final)
+	}
+}
+
+func TestMockProviderRespectsCancelledContext(t *testing.T) {
+	provider, _ := NewMockProvider(config.LLMSettings{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.GenerateResponse(ctx, userMessages("hi"), nil); err == nil {
+		t.Error("expected an error for a cancelled context")
+	}
+}
+
+func TestBaseTestConfigIsUsable(t *testing.T) {
+	// 测试默认配置依赖mock提供者，不应只因每个测试都覆盖配置而通过
+	client, err := NewLLM("default")
+	if err != nil {
+		t.Fatalf("base test config is unusable: %v", err)
+	}
+	response, err := client.GenerateResponse(context.Background(), userMessages("你好"), nil)
+	if err != nil || *response.Content != "echo: 你好" {
+		t.Errorf("response = %+v, err = %v", response, err)
+	}
+}
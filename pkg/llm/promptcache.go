@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// cachedTokensKey 上下文中接收缓存命中令牌数的键
+type cachedTokensKey struct{}
+
+// withCachedTokens 返回可接收本次请求缓存命中令牌数的上下文
+func withCachedTokens(ctx context.Context) (context.Context, *int) {
+	cached := new(int)
+	return context.WithValue(ctx, cachedTokensKey{}, cached), cached
+}
+
+// cacheUsageTransport 从响应的 usage.prompt_tokens_details.cached_tokens 读取提示缓存命中的令牌数
+// 当前版本的go-openai不解析该字段，因此在HTTP层读取；只处理请求上下文中带有接收位置的非流式响应
+type cacheUsageTransport struct {
+	base http.RoundTripper
+}
+
+// newCacheUsageClient 创建记录提示缓存用量的HTTP客户端
+func newCacheUsageClient() *http.Client {
+	return &http.Client{Transport: &cacheUsageTransport{base: http.DefaultTransport}}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *cacheUsageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	cached, ok := req.Context().Value(cachedTokensKey{}).(*int)
+	if !ok || resp.StatusCode != http.StatusOK ||
+		!strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+
+	var usage struct {
+		Usage struct {
+			PromptTokensDetails struct {
+				CachedTokens int `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+		} `json:"usage"`
+	}
+	if json.Unmarshal(body, &usage) == nil {
+		*cached = usage.Usage.PromptTokensDetails.CachedTokens
+	}
+	return resp, nil
+}
+
+// PromptCacheStats 提示缓存统计
+type PromptCacheStats struct {
+	Requests     int
+	Hits         int
+	PromptTokens int
+	CachedTokens int
+}
+
+// HitRate 缓存命中的输入令牌比例
+func (s PromptCacheStats) HitRate() float64 {
+	if s.PromptTokens == 0 {
+		return 0
+	}
+	return float64(s.CachedTokens) / float64(s.PromptTokens)
+}
+
+var (
+	promptCacheStats   = make(map[string]*PromptCacheStats)
+	promptCacheStatsMu sync.Mutex
+)
+
+// recordPromptCache 按配置名称累计提示缓存用量
+func recordPromptCache(configName string, usage *schema.Usage) {
+	if usage == nil {
+		return
+	}
+
+	promptCacheStatsMu.Lock()
+	defer promptCacheStatsMu.Unlock()
+
+	stats, ok := promptCacheStats[configName]
+	if !ok {
+		stats = &PromptCacheStats{}
+		promptCacheStats[configName] = stats
+	}
+	stats.Requests++
+	stats.PromptTokens += usage.PromptTokens
+	stats.CachedTokens += usage.CachedTokens
+	if usage.CachedTokens > 0 {
+		stats.Hits++
+	}
+}
+
+// PromptCacheStatsByConfig 获取各LLM配置的提示缓存统计，键为配置名称
+func PromptCacheStatsByConfig() map[string]PromptCacheStats {
+	promptCacheStatsMu.Lock()
+	defer promptCacheStatsMu.Unlock()
+
+	stats := make(map[string]PromptCacheStats, len(promptCacheStats))
+	for name, s := range promptCacheStats {
+		stats[name] = *s
+	}
+	return stats
+}
+
+// PromptCacheStats 获取当前客户端配置的提示缓存统计
+func (l *LLM) PromptCacheStats() PromptCacheStats {
+	promptCacheStatsMu.Lock()
+	defer promptCacheStatsMu.Unlock()
+
+	if stats, ok := promptCacheStats[l.configName]; ok {
+		return *stats
+	}
+	return PromptCacheStats{}
+}
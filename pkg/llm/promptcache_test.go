@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// resetPromptCacheStats 清空提示缓存统计，测试结束后再次清空
+func resetPromptCacheStats(t *testing.T) {
+	reset := func() {
+		promptCacheStatsMu.Lock()
+		promptCacheStats = make(map[string]*PromptCacheStats)
+		promptCacheStatsMu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// completionWithUsage 带令牌用量的补全响应，cached 为提示缓存命中的令牌数
+func completionWithUsage(content string, prompt, cached int) map[string]interface{} {
+	return map[string]interface{}{
+		"choices": []map[string]interface{}{{
+			"message":       map[string]interface{}{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]interface{}{
+			"prompt_tokens":         prompt,
+			"completion_tokens":     10,
+			"total_tokens":          prompt + 10,
+			"prompt_tokens_details": map[string]interface{}{"cached_tokens": cached},
+		},
+	}
+}
+
+func TestPromptCacheHitsAreRecorded(t *testing.T) {
+	resetPromptCacheStats(t)
+	fake := newFakeOpenAI(t, func(n int, req openai.ChatCompletionRequest) (int, interface{}) {
+		// 首次请求写入缓存，之后命中系统提示前缀
+		return http.StatusOK, completionWithUsage("ok", 2000, min(n, 1)*1536)
+	})
+	testconfig.Use(t, openAIConfig("cached", fake.URL, ""))
+
+	client, err := NewLLM("cached")
+	if err != nil {
+		t.Fatal(err)
+	}
+	system := schema.NewSystemMessage(strings.Repeat("你是一名严谨的助手。", 600))
+	var usages []*schema.Usage
+	for _, question := range []string{"第一个问题", "第二个问题"} {
+		response, err := client.GenerateResponse(context.Background(), []schema.Message{system, schema.NewUserMessage(question)}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		usages = append(usages, response.Usage)
+	}
+
+	if usages[0].CachedTokens != 0 || usages[1].CachedTokens != 1536 || usages[1].PromptTokens != 2000 {
+		t.Errorf("usages = %+v, %+v", usages[0], usages[1])
+	}
+	stats := client.PromptCacheStats()
+	if stats.Requests != 2 || stats.Hits != 1 || stats.CachedTokens != 1536 || stats.HitRate() != 1536.0/4000 {
+		t.Errorf("stats = %+v, hit rate %v", stats, stats.HitRate())
+	}
+	if PromptCacheStatsByConfig()["cached"] != stats {
+		t.Error("stats by config differ from the client stats")
+	}
+
+	// 两次请求的系统提示前缀完全相同，提供者才能命中缓存
+	requests := fake.Requests()
+	if requests[0].Messages[0].Content != *system.Content || requests[1].Messages[0].Content != *system.Content {
+		t.Error("the system prompt is not an identical prefix of every request")
+	}
+}
+
+func TestPromptCacheStatsWithoutUsageDetails(t *testing.T) {
+	resetPromptCacheStats(t)
+	fake := newFakeOpenAI(t, func(int, openai.ChatCompletionRequest) (int, interface{}) {
+		return http.StatusOK, textCompletion("ok")
+	})
+	testconfig.Use(t, openAIConfig("plain", fake.URL, ""))
+
+	client, err := NewLLM("plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.GenerateResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Usage != nil && response.Usage.CachedTokens != 0 {
+		t.Errorf("cached tokens = %d, want 0", response.Usage.CachedTokens)
+	}
+	if stats := client.PromptCacheStats(); stats.Hits != 0 || stats.HitRate() != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
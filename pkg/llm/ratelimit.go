@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// tokenBucket 令牌桶，按分钟速率匀速补充
+type tokenBucket struct {
+	capacity     float64
+	tokens       float64
+	refillPerSec float64
+	last         time.Time
+}
+
+// newTokenBucket 创建每分钟容量为perMinute的令牌桶
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity:     float64(perMinute),
+		tokens:       float64(perMinute),
+		refillPerSec: float64(perMinute) / 60,
+		last:         time.Now(),
+	}
+}
+
+// refill 按流逝时间补充令牌
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.refillPerSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// waitFor 计算获得n个令牌需要等待的时间
+func (b *tokenBucket) waitFor(n float64) time.Duration {
+	if n > b.capacity {
+		n = b.capacity
+	}
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.refillPerSec * float64(time.Second))
+}
+
+// RateLimiter 每分钟请求数和令牌数限流器
+type RateLimiter struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+	mu       sync.Mutex
+}
+
+// NewRateLimiter 创建限流器，两项限制都未配置时返回nil
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		requests: newTokenBucket(requestsPerMinute),
+		tokens:   newTokenBucket(tokensPerMinute),
+	}
+}
+
+// Wait 阻塞直到有足够的请求和令牌额度，上下文取消时返回错误
+func (r *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if r == nil {
+		return nil
+	}
+
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		var wait time.Duration
+		if r.requests != nil {
+			r.requests.refill(now)
+			wait = r.requests.waitFor(1)
+		}
+		if r.tokens != nil {
+			r.tokens.refill(now)
+			if w := r.tokens.waitFor(float64(tokens)); w > wait {
+				wait = w
+			}
+		}
+
+		if wait == 0 {
+			if r.requests != nil {
+				r.requests.tokens--
+			}
+			if r.tokens != nil {
+				r.tokens.tokens -= float64(tokens)
+			}
+			r.mu.Unlock()
+			return nil
+		}
+		r.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Adjust 根据实际用量修正预扣的令牌数
+func (r *RateLimiter) Adjust(delta int) {
+	if r == nil || r.tokens == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens.tokens -= float64(delta)
+}
+
+// sharedRateLimiter 按配置名称共享的限流器及创建时的限制，配置重新加载后限制变化时重建
+type sharedRateLimiter struct {
+	requestsPerMinute int
+	tokensPerMinute   int
+	limiter           *RateLimiter
+}
+
+var (
+	rateLimiters   = make(map[string]*sharedRateLimiter)
+	rateLimitersMu sync.Mutex
+)
+
+// getRateLimiter 获取按配置名称共享的限流器，configName 为实际使用的LLM配置名称
+func getRateLimiter(configName string, settings config.LLMSettings) *RateLimiter {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	if shared, ok := rateLimiters[configName]; ok &&
+		shared.requestsPerMinute == settings.RequestsPerMinute &&
+		shared.tokensPerMinute == settings.TokensPerMinute {
+		return shared.limiter
+	}
+
+	limiter := NewRateLimiter(settings.RequestsPerMinute, settings.TokensPerMinute)
+	rateLimiters[configName] = &sharedRateLimiter{
+		requestsPerMinute: settings.RequestsPerMinute,
+		tokensPerMinute:   settings.TokensPerMinute,
+		limiter:           limiter,
+	}
+	return limiter
+}
+
+// EstimateTokens 粗略估算消息的令牌数（约4个字符一个令牌）
+func EstimateTokens(messages []schema.Message) int {
+	chars := 0
+	for _, msg := range messages {
+		if msg.Content != nil {
+			chars += len(*msg.Content)
+		}
+		for _, tc := range msg.ToolCalls {
+			chars += len(tc.Function.Name) + len(tc.Function.Arguments)
+		}
+	}
+	return chars/4 + 1
+}
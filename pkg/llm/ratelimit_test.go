@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// elapsed 返回执行 fn 花费的时间
+func elapsed(fn func()) time.Duration {
+	start := time.Now()
+	fn()
+	return time.Since(start)
+}
+
+func TestRateLimiterThrottlesRequests(t *testing.T) {
+	// 每分钟120次即每秒2次，桶容量用尽后每次请求约等待0.5秒
+	limiter := NewRateLimiter(120, 0)
+	ctx := context.Background()
+
+	burst := elapsed(func() {
+		for i := 0; i < 120; i++ {
+			limiter.Wait(ctx, 1)
+		}
+	})
+	if burst > 100*time.Millisecond {
+		t.Errorf("burst within capacity took %v", burst)
+	}
+
+	if wait := elapsed(func() { limiter.Wait(ctx, 1) }); wait < 400*time.Millisecond {
+		t.Errorf("request over the rate waited %v, want about 500ms", wait)
+	}
+}
+
+func TestRateLimiterThrottlesTokens(t *testing.T) {
+	// 每分钟6000令牌即每秒100个
+	limiter := NewRateLimiter(0, 6000)
+	ctx := context.Background()
+
+	if wait := elapsed(func() { limiter.Wait(ctx, 6000) }); wait > 50*time.Millisecond {
+		t.Errorf("first request within capacity waited %v", wait)
+	}
+	if wait := elapsed(func() { limiter.Wait(ctx, 30) }); wait < 250*time.Millisecond {
+		t.Errorf("request for 30 tokens waited %v, want about 300ms", wait)
+	}
+}
+
+func TestRateLimiterAdjustRefundsTokens(t *testing.T) {
+	limiter := NewRateLimiter(0, 6000)
+	ctx := context.Background()
+	limiter.Wait(ctx, 6000)
+
+	// 实际只用了预扣的一半，退回的令牌可立即使用
+	limiter.Adjust(-3000)
+	if wait := elapsed(func() { limiter.Wait(ctx, 3000) }); wait > 50*time.Millisecond {
+		t.Errorf("refunded tokens not available, waited %v", wait)
+	}
+}
+
+func TestRateLimiterWaitHonorsContext(t *testing.T) {
+	limiter := NewRateLimiter(1, 0)
+	limiter.Wait(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestNilRateLimiterDoesNotThrottle(t *testing.T) {
+	if limiter := NewRateLimiter(0, 0); limiter != nil {
+		t.Fatalf("limiter without limits = %v, want nil", limiter)
+	}
+	var limiter *RateLimiter
+	if err := limiter.Wait(context.Background(), 1<<20); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLLMCallsAreThrottledPerConfig(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[llm.throttled]
+model = "mock"
+api_type = "mock"
+requests_per_minute = 120
+`)
+	// 限流器按配置名称全局共享，结束时移除以免重复运行时桶已耗尽
+	t.Cleanup(func() {
+		rateLimitersMu.Lock()
+		defer rateLimitersMu.Unlock()
+		delete(rateLimiters, "throttled")
+	})
+	client, err := NewLLM("throttled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for i := 0; i < 120; i++ {
+		if _, err := client.GenerateResponse(ctx, userMessages("hi"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 同名配置的客户端共享限流器
+	other, _ := NewLLM("throttled")
+	if wait := elapsed(func() { other.GenerateResponse(ctx, userMessages("hi"), nil) }); wait < 400*time.Millisecond {
+		t.Errorf("call over the configured rate waited %v, want about 500ms", wait)
+	}
+}
+
+func TestDifferentlyNamedClientsShareDefaultLimiter(t *testing.T) {
+	testconfig.Use(t, `[llm.default]
+model = "mock"
+api_type = "mock"
+requests_per_minute = 120
+`)
+	t.Cleanup(func() {
+		rateLimitersMu.Lock()
+		defer rateLimitersMu.Unlock()
+		delete(rateLimiters, "default")
+	})
+	// 智能体按自己的名称创建客户端，没有同名配置时都使用默认配置
+	manus, err := NewLLM("Manus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	planner, err := NewLLM("Planner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manus.limiter == nil || manus.limiter != planner.limiter {
+		t.Fatal("clients using the default config should share one limiter")
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 120; i++ {
+		if _, err := manus.GenerateResponse(ctx, userMessages("hi"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait := elapsed(func() { planner.GenerateResponse(ctx, userMessages("hi"), nil) }); wait < 400*time.Millisecond {
+		t.Errorf("second client waited %v, want about 500ms from the shared bucket", wait)
+	}
+}
+
+func TestRateLimiterRebuiltWhenLimitsChange(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[llm.reloaded]
+model = "mock"
+api_type = "mock"
+requests_per_minute = 60
+`)
+	t.Cleanup(func() {
+		rateLimitersMu.Lock()
+		defer rateLimitersMu.Unlock()
+		delete(rateLimiters, "reloaded")
+	})
+	before, _ := NewLLM("reloaded")
+	same, _ := NewLLM("reloaded")
+	if before.limiter != same.limiter {
+		t.Fatal("unchanged limits should reuse the limiter")
+	}
+
+	testconfig.Write(t, baseTestConfig+`
+[llm.reloaded]
+model = "mock"
+api_type = "mock"
+requests_per_minute = 600
+`)
+	after, _ := NewLLM("reloaded")
+	if after.limiter == before.limiter || after.limiter.requests.capacity != 600 {
+		t.Error("limiter not rebuilt after the configured rate changed")
+	}
+}
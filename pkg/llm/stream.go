@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// StreamChunk 流式响应片段
+// 内容增量通过Content逐段发送；流正常结束时发送一个携带完整消息的片段，
+// 其中包含拼接后的内容和组装完成的工具调用；流中途失败时最后发送一个携带Err的片段，
+// 不再发送完整消息
+type StreamChunk struct {
+	Content string
+	Message *schema.Message
+	Err     error
+}
+
+// streamAccumulator 累积流式增量，组装最终消息
+type streamAccumulator struct {
+	content      strings.Builder
+	toolCalls    []schema.ToolCall
+	byIndex      map[int]int
+	finishReason string
+}
+
+// newStreamAccumulator 创建流式增量累积器
+func newStreamAccumulator() *streamAccumulator {
+	return &streamAccumulator{
+		byIndex: make(map[int]int),
+	}
+}
+
+// add 累积一次增量，工具调用按index归并，参数片段依次拼接
+func (a *streamAccumulator) add(choice openai.ChatCompletionStreamChoice) {
+	delta := choice.Delta
+	if choice.FinishReason != "" {
+		a.finishReason = string(choice.FinishReason)
+	}
+	a.content.WriteString(delta.Content)
+
+	for _, tc := range delta.ToolCalls {
+		pos, ok := -1, false
+		if tc.Index != nil {
+			pos, ok = a.byIndex[*tc.Index]
+		} else if tc.ID == "" && len(a.toolCalls) > 0 {
+			// 没有index和id的片段属于最近的工具调用
+			pos, ok = len(a.toolCalls)-1, true
+		}
+
+		if !ok {
+			a.toolCalls = append(a.toolCalls, schema.ToolCall{Type: string(openai.ToolTypeFunction)})
+			pos = len(a.toolCalls) - 1
+			if tc.Index != nil {
+				a.byIndex[*tc.Index] = pos
+			}
+		}
+
+		call := &a.toolCalls[pos]
+		if tc.ID != "" {
+			call.ID = tc.ID
+		}
+		if tc.Type != "" {
+			call.Type = string(tc.Type)
+		}
+		if tc.Function.Name != "" {
+			call.Function.Name = tc.Function.Name
+		}
+		call.Function.Arguments += tc.Function.Arguments
+	}
+}
+
+// message 生成累积完成的助手消息
+func (a *streamAccumulator) message() *schema.Message {
+	content := a.content.String()
+	return &schema.Message{
+		Role:         schema.RoleAssistant,
+		Content:      &content,
+		ToolCalls:    a.toolCalls,
+		FinishReason: a.finishReason,
+	}
+}
@@ -0,0 +1,205 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// newFakeStream 启动以SSE格式依次发送 chunks 的OpenAI兼容服务
+func newFakeStream(t *testing.T, chunks ...openai.ChatCompletionStreamResponse) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			data, _ := json.Marshal(chunk)
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// delta 创建只包含一个选项的流式片段
+func delta(content string, finishReason openai.FinishReason, toolCalls ...openai.ToolCall) openai.ChatCompletionStreamResponse {
+	return openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+		Delta:        openai.ChatCompletionStreamChoiceDelta{Content: content, ToolCalls: toolCalls},
+		FinishReason: finishReason,
+	}}}
+}
+
+// toolCallDelta 创建工具调用增量，index 为nil时省略
+func toolCallDelta(index *int, id, name, arguments string) openai.ToolCall {
+	call := openai.ToolCall{Index: index, ID: id, Function: openai.FunctionCall{Name: name, Arguments: arguments}}
+	if id != "" {
+		call.Type = openai.ToolTypeFunction
+	}
+	return call
+}
+
+// collect 读取流直到关闭，返回内容片段和最终消息，忽略错误片段
+func collect(stream <-chan StreamChunk) ([]string, *schema.Message) {
+	var contents []string
+	var final *schema.Message
+	for chunk := range stream {
+		if chunk.Message != nil {
+			final = chunk.Message
+			continue
+		}
+		if chunk.Err == nil {
+			contents = append(contents, chunk.Content)
+		}
+	}
+	return contents, final
+}
+
+func TestStreamAssemblesToolCallsSplitAcrossDeltas(t *testing.T) {
+	zero, one := 0, 1
+	server := newFakeStream(t,
+		delta("我来", ""),
+		delta("查询", ""),
+		delta("", "", toolCallDelta(&zero, "call_a", "SimpleSearch", "")),
+		delta("", "", toolCallDelta(&zero, "", "", `{"query":`)),
+		// 两个工具调用的增量交替到达
+		delta("", "", toolCallDelta(&one, "call_b", "SimpleBrowser", `{"url"`)),
+		delta("", "", toolCallDelta(&zero, "", "", ` "go 泛型"}`)),
+		delta("", "", toolCallDelta(&one, "", "", `: "https://go.dev"}`)),
+		delta("", openai.FinishReasonToolCalls),
+	)
+
+	stream, err := newTestProvider(t, server.URL).GenerateStreamResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, final := collect(stream)
+
+	if len(contents) != 2 || *final.Content != "我来查询" {
+		t.Errorf("contents = %q, final content = %q", contents, *final.Content)
+	}
+	if final.FinishReason != string(openai.FinishReasonToolCalls) {
+		t.Errorf("finish reason = %s", final.FinishReason)
+	}
+	want := []schema.ToolCall{
+		{ID: "call_a", Type: "function", Function: schema.Function{Name: "SimpleSearch", Arguments: `{"query": "go 泛型"}`}},
+		{ID: "call_b", Type: "function", Function: schema.Function{Name: "SimpleBrowser", Arguments: `{"url": "https://go.dev"}`}},
+	}
+	if fmt.Sprint(final.ToolCalls) != fmt.Sprint(want) {
+		t.Errorf("tool calls = %+v, want %+v", final.ToolCalls, want)
+	}
+}
+
+func TestStreamAccumulatorWithoutIndex(t *testing.T) {
+	// 部分兼容服务不发送index，后续片段归入最近的工具调用
+	accumulator := newStreamAccumulator()
+	for _, chunk := range []openai.ChatCompletionStreamResponse{
+		delta("", "", toolCallDelta(nil, "call_a", "Terminate", `{"mess`)),
+		delta("", "", toolCallDelta(nil, "", "", `age": "done"}`)),
+		delta("", "", toolCallDelta(nil, "call_b", "Terminate", `{}`)),
+	} {
+		accumulator.add(chunk.Choices[0])
+	}
+
+	calls := accumulator.message().ToolCalls
+	if len(calls) != 2 || calls[0].Function.Arguments != `{"message": "done"}` || calls[1].ID != "call_b" {
+		t.Errorf("tool calls = %+v", calls)
+	}
+}
+
+func TestStreamFailureMidwayIsReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		data, _ := json.Marshal(delta("半截", ""))
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		w.(http.Flusher).Flush()
+		// 发送部分内容后断开连接
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	stream, err := newTestProvider(t, server.URL).GenerateStreamResponse(context.Background(), userMessages("hi"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []StreamChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+
+	if len(chunks) != 2 || chunks[0].Content != "半截" {
+		t.Fatalf("chunks = %+v, want the content delta followed by an error", chunks)
+	}
+	if last := chunks[1]; last.Err == nil || last.Message != nil {
+		t.Errorf("last chunk = %+v, want an error without a final message", last)
+	}
+}
+
+func TestStreamCancelledMidwayShutsDown(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; ; i++ {
+			data, _ := json.Marshal(delta(fmt.Sprintf("片段%d ", i), ""))
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-release:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	provider := newTestProvider(t, server.URL)
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := provider.GenerateStreamResponse(ctx, userMessages("hi"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := <-stream; first.Content != "片段0 " {
+		t.Errorf("first chunk = %+v", first)
+	}
+
+	// 取消后不再读取，流仍应关闭且不发送最终消息
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	timeout := time.After(2 * time.Second)
+	for chunk := range stream {
+		if chunk.Message != nil {
+			t.Errorf("final message sent after cancellation: %+v", chunk.Message)
+		}
+		if chunk.Err != nil && !errors.Is(chunk.Err, context.Canceled) {
+			t.Errorf("error chunk = %v, want context.Canceled", chunk.Err)
+		}
+		select {
+		case <-timeout:
+			t.Fatal("stream not closed after cancellation")
+		default:
+		}
+	}
+
+	// 接收协程和连接协程都应退出
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		buf := make([]byte, 1<<16)
+		t.Errorf("goroutines: %d before, %d after cancellation\n%s", before, after, buf[:runtime.Stack(buf, true)])
+	}
+}
@@ -87,27 +87,27 @@ func GetLogger() *zap.Logger {
 
 // Debug 记录调试日志
 func Debug(msg string, fields ...zap.Field) {
-	GetLogger().Debug(msg, fields...)
+	GetLogger().Debug(msg, redactFields(fields)...)
 }
 
 // Info 记录信息日志
 func Info(msg string, fields ...zap.Field) {
-	GetLogger().Info(msg, fields...)
+	GetLogger().Info(msg, redactFields(fields)...)
 }
 
 // Warn 记录警告日志
 func Warn(msg string, fields ...zap.Field) {
-	GetLogger().Warn(msg, fields...)
+	GetLogger().Warn(msg, redactFields(fields)...)
 }
 
 // Error 记录错误日志
 func Error(msg string, fields ...zap.Field) {
-	GetLogger().Error(msg, fields...)
+	GetLogger().Error(msg, redactFields(fields)...)
 }
 
 // Fatal 记录致命日志
 func Fatal(msg string, fields ...zap.Field) {
-	GetLogger().Fatal(msg, fields...)
+	GetLogger().Fatal(msg, redactFields(fields)...)
 }
 
 // Sync 同步日志
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedMask 敏感信息替换后的占位符
+const redactedMask = "[REDACTED]"
+
+// secretPatterns 匹配常见密钥格式的正则
+var secretPatterns = []*regexp.Regexp{
+	// OpenAI等提供者的API密钥
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`),
+	// HTTP鉴权头中的令牌
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._\-~+/]+=*`),
+}
+
+// secretAssignment 匹配 key=value、"key": "value" 形式的敏感字段
+var secretAssignment = regexp.MustCompile(
+	`(?i)("?(?:api[_-]?key|access[_-]?token|token|password|secret)"?\s*[:=]\s*"?)([^"\s,}&]+)`)
+
+// minSecretLength 登记敏感值的最小长度
+const minSecretLength = 8
+
+var (
+	// registeredSecrets 已登记的敏感值（如配置中的API密钥），日志中一律屏蔽
+	registeredSecrets []string
+	secretsMu         sync.RWMutex
+)
+
+// RegisterSecrets 登记需要在日志和错误信息中屏蔽的敏感值
+func RegisterSecrets(secrets ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, secret := range secrets {
+		// 过短的值（如ollama的占位密钥）屏蔽后会误伤正常文本
+		if len(secret) < minSecretLength {
+			continue
+		}
+		registeredSecrets = append(registeredSecrets, secret)
+	}
+}
+
+// RedactSecrets 屏蔽文本中的密钥、令牌和密码，包括已登记和额外指定的敏感值
+func RedactSecrets(text string, secrets ...string) string {
+	secretsMu.RLock()
+	known := append(append([]string{}, registeredSecrets...), secrets...)
+	secretsMu.RUnlock()
+
+	for _, secret := range known {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redactedMask)
+		}
+	}
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if sub := pattern.FindStringSubmatch(match); len(sub) > 1 {
+				return sub[1] + redactedMask
+			}
+			return redactedMask
+		})
+	}
+	return secretAssignment.ReplaceAllString(text, "${1}"+redactedMask)
+}
+
+// redactedError 错误信息已屏蔽敏感值的错误，仍可通过errors.Is/As匹配原错误
+type redactedError struct {
+	err error
+}
+
+// Error 返回屏蔽后的错误信息
+func (e *redactedError) Error() string {
+	return RedactSecrets(e.err.Error())
+}
+
+// Unwrap 返回原始错误
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// RedactError 包装错误，使其错误信息屏蔽敏感值
+func RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+// redactFields 屏蔽日志字段中字符串和错误里的敏感值
+func redactFields(fields []zap.Field) []zap.Field {
+	for i, field := range fields {
+		switch field.Type {
+		case zapcore.StringType:
+			fields[i].String = RedactSecrets(field.String)
+		case zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok {
+				fields[i] = zap.String(field.Key, RedactSecrets(err.Error()))
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,57 @@
+package schema
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator ID生成函数
+type IDGenerator func() string
+
+var (
+	idGenerator IDGenerator = newUUID
+	idMu        sync.RWMutex
+)
+
+// newUUID 默认的ID生成器，生成随机UUID
+func newUUID() string {
+	return uuid.New().String()
+}
+
+// NewID 使用当前的ID生成器生成唯一ID，用于智能体、工作流等对象的ID
+func NewID() string {
+	idMu.RLock()
+	gen := idGenerator
+	idMu.RUnlock()
+	return gen()
+}
+
+// SetIDGenerator 替换ID生成器，返回恢复原生成器的函数
+// 测试中可注入 SequenceIDGenerator 获得确定性的ID；gen 为nil时恢复默认的UUID生成器
+func SetIDGenerator(gen IDGenerator) (restore func()) {
+	if gen == nil {
+		gen = newUUID
+	}
+
+	idMu.Lock()
+	previous := idGenerator
+	idGenerator = gen
+	idMu.Unlock()
+
+	return func() {
+		idMu.Lock()
+		idGenerator = previous
+		idMu.Unlock()
+	}
+}
+
+// SequenceIDGenerator 创建确定性的ID生成器，依次生成 prefix-1、prefix-2 ……，并发安全
+func SequenceIDGenerator(prefix string) IDGenerator {
+	var n int64
+	return func() string {
+		return fmt.Sprintf("%s-%d", prefix, atomic.AddInt64(&n, 1))
+	}
+}
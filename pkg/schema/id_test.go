@@ -0,0 +1,73 @@
+package schema
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// concurrentIDs 并发生成 n 个ID
+func concurrentIDs(n int) []string {
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = NewID()
+		}(i)
+	}
+	wg.Wait()
+	return ids
+}
+
+// assertUnique 检查ID互不相同
+func assertUnique(t *testing.T, ids []string) {
+	t.Helper()
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			t.Fatalf("duplicate or empty id %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestDefaultIDsAreUniqueUnderConcurrency(t *testing.T) {
+	assertUnique(t, concurrentIDs(1000))
+}
+
+func TestStubbedIDsAreDeterministic(t *testing.T) {
+	restore := SetIDGenerator(SequenceIDGenerator("test"))
+	got := []string{NewID(), NewID(), NewID()}
+	restore()
+
+	if strings.Join(got, ",") != "test-1,test-2,test-3" {
+		t.Errorf("ids = %v", got)
+	}
+	// 恢复后重新使用UUID
+	if id := NewID(); strings.HasPrefix(id, "test-") || len(id) != 36 {
+		t.Errorf("id after restore = %q, want a UUID", id)
+	}
+}
+
+func TestSequenceIsUniqueUnderConcurrency(t *testing.T) {
+	defer SetIDGenerator(SequenceIDGenerator("seq"))()
+
+	ids := concurrentIDs(1000)
+	assertUnique(t, ids)
+	for _, id := range ids {
+		if !strings.HasPrefix(id, "seq-") {
+			t.Fatalf("id %q not from the stubbed generator", id)
+		}
+	}
+}
+
+func TestNilGeneratorRestoresDefault(t *testing.T) {
+	defer SetIDGenerator(SequenceIDGenerator("seq"))()
+	defer SetIDGenerator(nil)()
+
+	if id := NewID(); len(id) != 36 {
+		t.Errorf("id = %q, want a UUID", id)
+	}
+}
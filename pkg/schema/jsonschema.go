@@ -0,0 +1,192 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+)
+
+// ValidateJSONSchema 按JSON Schema校验已解析的JSON值（encoding/json 解码得到的值）
+// 支持常用关键字：type、properties、required、additionalProperties、items、enum、
+// minimum、maximum、minLength、maxLength、minItems、maxItems；其他关键字被忽略
+func ValidateJSONSchema(value interface{}, schema map[string]interface{}) error {
+	// 代码中构造的Schema可能使用 []string、int 等类型，先统一为JSON解码后的形式
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("无效的JSON Schema: %w", err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return fmt.Errorf("无效的JSON Schema: %w", err)
+	}
+	return validateSchema("$", value, normalized)
+}
+
+// validateSchema 校验path处的值
+func validateSchema(path string, value interface{}, schema map[string]interface{}) error {
+	if schema == nil {
+		return nil
+	}
+
+	if err := validateType(path, value, schema["type"]); err != nil {
+		return err
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		matched := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(normalizeNumber(candidate), normalizeNumber(value)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: 值不在允许的枚举中", path)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(path, v, schema)
+	case []interface{}:
+		if err := checkBounds(path, "元素个数", float64(len(v)), schema["minItems"], schema["maxItems"]); err != nil {
+			return err
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(fmt.Sprintf("%s[%d]", path, i), item, items); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		return checkBounds(path, "长度", float64(utf8.RuneCountInString(v)), schema["minLength"], schema["maxLength"])
+	case float64:
+		return checkBounds(path, "值", v, schema["minimum"], schema["maximum"])
+	}
+	return nil
+}
+
+// validateObject 校验对象的必需字段、属性和额外属性
+func validateObject(path string, object map[string]interface{}, schema map[string]interface{}) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, ok := object[key]; !ok {
+				return fmt.Errorf("%s: 缺少必需字段 %s", path, key)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propertyPath := path + "." + key
+		if property, ok := properties[key].(map[string]interface{}); ok {
+			if err := validateSchema(propertyPath, object[key], property); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: 不允许的字段", propertyPath)
+			}
+		case map[string]interface{}:
+			if err := validateSchema(propertyPath, object[key], additional); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateType 校验值的类型，expected 可以是类型名或类型名数组
+func validateType(path string, value interface{}, expected interface{}) error {
+	var types []string
+	switch t := expected.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+	default:
+		return nil
+	}
+
+	actual := jsonType(value)
+	for _, name := range types {
+		if name == actual || (name == "number" && actual == "integer") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: 类型应为 %v，实际为 %s", path, expected, actual)
+}
+
+// jsonType 获取JSON值的类型名，整数值的数字为 integer
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// checkBounds 校验数值上下限，min/max 为nil时不限制
+func checkBounds(path, name string, actual float64, min, max interface{}) error {
+	if limit, ok := normalizeNumber(min).(float64); ok && actual < limit {
+		return fmt.Errorf("%s: %s不能小于 %v", path, name, limit)
+	}
+	if limit, ok := normalizeNumber(max).(float64); ok && actual > limit {
+		return fmt.Errorf("%s: %s不能大于 %v", path, name, limit)
+	}
+	return nil
+}
+
+// normalizeNumber 将各种数字类型统一为float64，便于比较来自配置或代码的Schema
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
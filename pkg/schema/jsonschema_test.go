@@ -0,0 +1,53 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	personSchema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 5},
+			"age":  map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 150},
+			"role": map[string]interface{}{"enum": []string{"admin", "user"}},
+			"tags": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 2},
+			"note": map[string]interface{}{"type": []string{"string", "null"}},
+		},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{`{"name": "张三", "age": 30, "role": "admin", "tags": ["a"], "note": null}`, ""},
+		{`{"age": 30}`, "缺少必需字段 name"},
+		{`{"name": ""}`, "$.name"},
+		{`{"name": "张三丰大侠客"}`, "$.name"},
+		{`{"name": "a", "age": 30.5}`, "$.age: 类型应为"},
+		{`{"name": "a", "age": 200}`, "$.age"},
+		{`{"name": "a", "role": "root"}`, "$.role: 值不在允许的枚举中"},
+		{`{"name": "a", "tags": ["x", 1]}`, "$.tags[1]"},
+		{`{"name": "a", "tags": ["x", "y", "z"]}`, "$.tags"},
+		{`{"name": "a", "extra": 1}`, "$.extra: 不允许的字段"},
+		{`["name"]`, "$: 类型应为"},
+	}
+
+	for _, tt := range tests {
+		var value interface{}
+		if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+			t.Fatal(err)
+		}
+		err := ValidateJSONSchema(value, personSchema)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.value, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: error = %v, want it to mention %q", tt.value, err, tt.err)
+		}
+	}
+}
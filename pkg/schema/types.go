@@ -2,6 +2,8 @@ package schema
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -47,15 +49,34 @@ type ToolCall struct {
 	Function Function `json:"function"`
 }
 
+// 响应结束原因
+const (
+	FinishReasonStop          = "stop"
+	FinishReasonLength        = "length"
+	FinishReasonToolCalls     = "tool_calls"
+	FinishReasonContentFilter = "content_filter"
+)
+
+// Usage 令牌用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// CachedTokens 输入令牌中命中提供者提示缓存的部分
+	CachedTokens int `json:"cached_tokens,omitempty"`
+}
+
 // Message 消息结构
 type Message struct {
-	Role        Role      `json:"role"`
-	Content     *string   `json:"content,omitempty"`
-	ToolCalls   []ToolCall `json:"tool_calls,omitempty"`
-	Name        *string   `json:"name,omitempty"`
-	ToolCallID  *string   `json:"tool_call_id,omitempty"`
-	Base64Image *string   `json:"base64_image,omitempty"`
-	Timestamp   time.Time `json:"timestamp"`
+	Role         Role       `json:"role"`
+	Content      *string    `json:"content,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	Name         *string    `json:"name,omitempty"`
+	ToolCallID   *string    `json:"tool_call_id,omitempty"`
+	Base64Image  *string    `json:"base64_image,omitempty"`
+	Usage        *Usage     `json:"usage,omitempty"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	Timestamp    time.Time  `json:"timestamp"`
 }
 
 // NewUserMessage 创建用户消息
@@ -161,17 +182,23 @@ func NewMemory(maxMessages int) *Memory {
 // AddMessage 添加消息到内存
 func (m *Memory) AddMessage(message Message) {
 	m.Messages = append(m.Messages, message)
-	if len(m.Messages) > m.MaxMessages {
-		m.Messages = m.Messages[len(m.Messages)-m.MaxMessages:]
-	}
+	m.trim()
 }
 
 // AddMessages 添加多条消息到内存
 func (m *Memory) AddMessages(messages []Message) {
 	m.Messages = append(m.Messages, messages...)
-	if len(m.Messages) > m.MaxMessages {
-		m.Messages = m.Messages[len(m.Messages)-m.MaxMessages:]
+	m.trim()
+}
+
+// trim 超出 MaxMessages 时丢弃最早的消息，开头残留的工具消息随其工具调用一起丢弃
+// 工具调用在之前的截断中已被丢弃时，之后添加的结果也在开头，同样丢弃
+func (m *Memory) trim() {
+	start := max(len(m.Messages)-m.MaxMessages, 0)
+	for start < len(m.Messages) && m.Messages[start].Role == RoleTool {
+		start++
 	}
+	m.Messages = m.Messages[start:]
 }
 
 // Clear 清空内存
@@ -203,6 +230,67 @@ type ToolResult struct {
 	Error   string      `json:"error,omitempty"`
 }
 
+// ToolOutput 工具输出
+// Content 为发送给模型的主要文本，Data 为附带的结构化数据，Base64Image 为可选的图片
+type ToolOutput struct {
+	Content     string      `json:"content"`
+	Data        interface{} `json:"data,omitempty"`
+	Base64Image string      `json:"base64_image,omitempty"`
+	IsError     bool        `json:"is_error,omitempty"`
+}
+
+// NewToolOutput 创建工具输出，data 可为nil
+func NewToolOutput(content string, data interface{}) *ToolOutput {
+	return &ToolOutput{Content: content, Data: data}
+}
+
+// NewToolError 创建表示执行失败的工具输出
+func NewToolError(content string, data interface{}) *ToolOutput {
+	return &ToolOutput{Content: content, Data: data, IsError: true}
+}
+
+// ToToolOutput 将工具返回值转换为ToolOutput
+// 兼容直接返回字符串或任意值的工具：字符串作为内容，其他值作为结构化数据
+func ToToolOutput(result interface{}) *ToolOutput {
+	switch v := result.(type) {
+	case *ToolOutput:
+		if v == nil {
+			return &ToolOutput{}
+		}
+		return v
+	case ToolOutput:
+		return &v
+	case nil:
+		return &ToolOutput{}
+	case string:
+		return &ToolOutput{Content: v}
+	default:
+		return &ToolOutput{Data: v}
+	}
+}
+
+// Format 格式化为发送给模型的文本：内容在前，结构化数据以JSON（键有序）附在其后，失败时带错误前缀
+// 图片不包含在文本中，随工具消息单独发送
+func (o *ToolOutput) Format() string {
+	var parts []string
+	if o.Content != "" {
+		parts = append(parts, o.Content)
+	}
+	if o.Data != nil {
+		if data, err := json.Marshal(o.Data); err == nil {
+			parts = append(parts, string(data))
+		} else {
+			parts = append(parts, fmt.Sprintf("%v", o.Data))
+		}
+	}
+
+	text := strings.Join(parts, "\n\n")
+	if o.IsError {
+		text = "错误: " + text
+	}
+	return text
+}
+
 // AgentMetadata 智能体元数据
 type AgentMetadata struct {
 	Name        string            `json:"name"`
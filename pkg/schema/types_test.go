@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"fmt"
+	"testing"
+)
+
+// transcriptWithToolCalls 用户提问后，助手依次发起单个和多个工具调用，最后回答
+func transcriptWithToolCalls() []Message {
+	call := func(ids ...string) Message {
+		message := NewAssistantMessage("")
+		for _, id := range ids {
+			message.ToolCalls = append(message.ToolCalls, ToolCall{ID: id, Type: "function", Function: Function{Name: "Lookup"}})
+		}
+		return message
+	}
+	return []Message{
+		NewSystemMessage("system"),
+		NewUserMessage("问题"),
+		call("a"),
+		NewToolMessage("结果a", "Lookup", "a"),
+		call("b", "c", "d"),
+		NewToolMessage("结果b", "Lookup", "b"),
+		NewToolMessage("结果c", "Lookup", "c"),
+		NewToolMessage("结果d", "Lookup", "d"),
+		NewAssistantMessage("中间回答"),
+		call("e", "f"),
+		NewToolMessage("结果e", "Lookup", "e"),
+		NewToolMessage("结果f", "Lookup", "f"),
+		NewAssistantMessage("最终回答"),
+	}
+}
+
+// checkToolCallPairs 检查每条工具消息都紧跟在发起该调用的助手消息单元中，每个工具调用都有结果
+func checkToolCallPairs(messages []Message) error {
+	pending := map[string]bool{}
+	for i, message := range messages {
+		switch {
+		case message.Role == RoleTool:
+			if message.ToolCallID == nil || !pending[*message.ToolCallID] {
+				return fmt.Errorf("message %d: orphaned tool result", i)
+			}
+			delete(pending, *message.ToolCallID)
+		case len(pending) > 0:
+			return fmt.Errorf("message %d: %d tool calls left without results", i, len(pending))
+		}
+		for _, tc := range message.ToolCalls {
+			pending[tc.ID] = true
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d tool calls at the end have no results", len(pending))
+	}
+	return nil
+}
+
+func TestMemoryTrimKeepsToolCallsWithResults(t *testing.T) {
+	transcript := transcriptWithToolCalls()
+	for limit := 1; limit <= len(transcript); limit++ {
+		memory := NewMemory(limit)
+		for _, message := range transcript {
+			memory.AddMessage(message)
+			if err := checkToolCallPairs(memory.Messages); err != nil && memory.Messages[0].Role == RoleTool {
+				t.Fatalf("max %d: %v", limit, err)
+			}
+		}
+		if err := checkToolCallPairs(memory.Messages); err != nil {
+			t.Errorf("max %d: %v", limit, err)
+		}
+		if len(memory.Messages) > limit {
+			t.Errorf("max %d: kept %d messages", limit, len(memory.Messages))
+		}
+	}
+}
+
+func TestMemoryAddMessagesTrims(t *testing.T) {
+	memory := NewMemory(3)
+	memory.AddMessages(transcriptWithToolCalls())
+	if err := checkToolCallPairs(memory.Messages); err != nil {
+		t.Error(err)
+	}
+	// 最后3条从工具结果e开始，结果随其调用一起丢弃
+	if len(memory.Messages) != 1 || *memory.Messages[0].Content != "最终回答" {
+		t.Errorf("kept %d messages", len(memory.Messages))
+	}
+}
@@ -0,0 +1,115 @@
+package tool
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// codeFence Markdown代码块标记
+const codeFence = "```"
+
+// errorContextRunes 解析失败时错误信息中展示的出错位置前后字符数
+const errorContextRunes = 20
+
+// parseArguments 容错解析工具调用参数
+// 模型有时会用Markdown代码块包裹参数，或在JSON对象后附加说明文字：整体解析失败时先去除代码块，再解析第一个JSON对象并忽略其后内容
+// 确实无法解析时，错误信息指出出错的行列和附近内容
+func parseArguments(arguments string) (map[string]interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(arguments), &value); err == nil {
+		args, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, invalidArguments("参数必须是JSON对象")
+		}
+		return args, nil
+	}
+
+	// 以对象开头时代码块标记只可能出现在字符串值中，不做处理
+	text, base := arguments, 0
+	if !strings.HasPrefix(strings.TrimSpace(arguments), "{") {
+		text, base = stripCodeFence(arguments)
+	}
+
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return nil, invalidArguments("参数中没有JSON对象")
+	}
+
+	var args map[string]interface{}
+	if err := json.NewDecoder(strings.NewReader(text[start:])).Decode(&args); err != nil {
+		return nil, positionedError(arguments, base+start, err)
+	}
+	return args, nil
+}
+
+// stripCodeFence 去除包裹参数的Markdown代码块，返回代码块内容及其在原文中的起始位置
+// 没有代码块时原样返回；缺少结束标记时取到末尾
+func stripCodeFence(arguments string) (string, int) {
+	open := strings.Index(arguments, codeFence)
+	if open < 0 {
+		return arguments, 0
+	}
+	// 跳过语言标识所在的行，如 ```json
+	start := open + len(codeFence)
+	if newline := strings.IndexByte(arguments[start:], '\n'); newline >= 0 {
+		start += newline + 1
+	} else {
+		start = len(arguments)
+	}
+	end := len(arguments)
+	if closing := strings.Index(arguments[start:], codeFence); closing >= 0 {
+		end = start + closing
+	}
+	return arguments[start:end], start
+}
+
+// positionedError 生成指出出错位置的参数错误，offset 为解析起点在原文中的字节位置
+func positionedError(arguments string, offset int, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset += int(syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		offset += int(typeErr.Offset)
+	default:
+		// 输入在对象结束前截断
+		offset = len(arguments)
+	}
+	// 偏移量为已读取的字节数，出错的字符在其前一个位置
+	if offset > 0 {
+		offset--
+	}
+	if offset > len(arguments) {
+		offset = len(arguments)
+	}
+	for offset > 0 && offset < len(arguments) && !utf8.RuneStart(arguments[offset]) {
+		offset--
+	}
+
+	line, column := lineColumn(arguments, offset)
+	return invalidArguments("解析参数失败: 第%d行第%d列附近 %q: %w", line, column, errorContext(arguments, offset), err)
+}
+
+// lineColumn 计算字节位置所在的行列，从1开始，列按字符计
+func lineColumn(text string, offset int) (int, int) {
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCountInString(before[lineStart:]) + 1
+}
+
+// errorContext 截取出错位置前后的内容，并标出出错位置
+func errorContext(text string, offset int) string {
+	before := []rune(text[:offset])
+	after := []rune(text[offset:])
+	if len(before) > errorContextRunes {
+		before = before[len(before)-errorContextRunes:]
+	}
+	if len(after) > errorContextRunes {
+		after = after[:errorContextRunes]
+	}
+	return string(before) + "【此处】" + string(after)
+}
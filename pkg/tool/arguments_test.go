@@ -0,0 +1,82 @@
+package tool
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgumentsTolerance(t *testing.T) {
+	want := map[string]interface{}{"path": "a.txt", "n": float64(2)}
+	tests := []struct {
+		name      string
+		arguments string
+	}{
+		{"plain", `{"path": "a.txt", "n": 2}`},
+		{"fenced", "```json\n{\"path\": \"a.txt\", \"n\": 2}\n```"},
+		{"fenced without language", "```\n{\"path\": \"a.txt\", \"n\": 2}\n```"},
+		{"prose around fence", "参数如下：\n```json\n{\"path\": \"a.txt\", \"n\": 2}\n```\n以上。"},
+		{"trailing prose", `{"path": "a.txt", "n": 2} 这是读取文件的参数`},
+		{"leading prose", `Arguments: {"path": "a.txt", "n": 2}`},
+		{"second object ignored", `{"path": "a.txt", "n": 2}{"path": "b.txt"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := parseArguments(tt.arguments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, want) {
+				t.Errorf("args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestParseArgumentsKeepsFenceInsideStrings(t *testing.T) {
+	arguments := `{"text": "` + "```go\\nx := 1\\n```" + `"}`
+	args, err := parseArguments(arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args["text"] != "```go\nx := 1\n```" {
+		t.Errorf("text = %q", args["text"])
+	}
+}
+
+func TestParseArgumentsReportsPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      []string
+	}{
+		{"trailing comma", "{\n  \"path\": \"a.txt\",\n}", []string{"第3行第1列", "【此处】}"}},
+		{"fenced unquoted key", "```json\n{path: 1}\n```", []string{"第2行第2列", "{【此处】path"}},
+		{"truncated", `{"path": "a.t`, []string{"第1行第13列"}},
+		{"no object", "我无法确定参数", []string{"没有JSON对象"}},
+		{"array", `[1, 2]`, []string{"必须是JSON对象"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseArguments(tt.arguments)
+			if !errors.Is(err, ErrInvalidArguments) {
+				t.Fatalf("err = %v, want ErrInvalidArguments", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateArgumentsAcceptsFencedJSON(t *testing.T) {
+	if err := ValidateArguments(NewTerminate(), "```json\n{\"message\": \"完成\"}\n```"); err != nil {
+		t.Errorf("fenced arguments rejected: %v", err)
+	}
+	if err := ValidateArguments(NewTerminate(), "```json\n{}\n```"); !errors.Is(err, ErrInvalidArguments) {
+		t.Errorf("missing required argument accepted: %v", err)
+	}
+}
@@ -2,13 +2,44 @@ package tool
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/yahao333/GoManus/pkg/schema"
 )
 
+// ErrInvalidArguments 工具参数无法解析或不符合参数Schema
+var ErrInvalidArguments = errors.New("invalid tool arguments")
+
+// argumentError 参数错误，errors.Is 可匹配 ErrInvalidArguments，错误信息保持原样
+type argumentError struct {
+	err error
+}
+
+// Error 实现 error
+func (e *argumentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *argumentError) Unwrap() error {
+	return e.err
+}
+
+// Is 匹配 ErrInvalidArguments
+func (e *argumentError) Is(target error) bool {
+	return target == ErrInvalidArguments
+}
+
+// invalidArguments 创建参数错误
+func invalidArguments(format string, args ...interface{}) error {
+	return &argumentError{err: fmt.Errorf(format, args...)}
+}
+
 // Tool 工具接口
+// Execute 应返回 *schema.ToolOutput；为兼容旧工具，返回字符串时作为文本内容，返回其他值时作为结构化数据
 type Tool interface {
 	GetName() string
 	GetDescription() string
@@ -76,16 +107,19 @@ func (tc *ToolCollection) RemoveTool(name string) {
 	delete(tc.tools, name)
 }
 
-// GetAllTools 获取所有工具
+// GetAllTools 获取所有工具，按名称排序
 func (tc *ToolCollection) GetAllTools() []Tool {
 	tools := make([]Tool, 0, len(tc.tools))
 	for _, tool := range tc.tools {
 		tools = append(tools, tool)
 	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].GetName() < tools[j].GetName()
+	})
 	return tools
 }
 
-// GetDefinitions 获取工具定义
+// GetDefinitions 获取工具定义，按名称排序，每次请求发送给模型的工具顺序一致，便于提示缓存命中
 func (tc *ToolCollection) GetDefinitions() []schema.ToolDefinition {
 	tools := tc.GetAllTools()
 	definitions := make([]schema.ToolDefinition, len(tools))
@@ -102,21 +136,40 @@ func (tc *ToolCollection) GetDefinitions() []schema.ToolDefinition {
 	return definitions
 }
 
-// parseArguments 解析参数
-func parseArguments(arguments string) (map[string]interface{}, error) {
-	var args map[string]interface{}
-	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-		return nil, fmt.Errorf("解析参数失败: %w", err)
-	}
-	return args, nil
-}
-
 // validateArguments 验证参数
 func validateArguments(args map[string]interface{}, required []string) error {
 	for _, req := range required {
 		if _, ok := args[req]; !ok {
-			return fmt.Errorf("缺少必需参数: %s", req)
+			return invalidArguments("缺少必需参数: %s", req)
 		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+// ParametersSchema 获取工具参数的JSON Schema，与发送给模型的函数定义一致
+func ParametersSchema(t Tool) map[string]interface{} {
+	params := map[string]interface{}{
+		"type":       "object",
+		"properties": t.GetParameters(),
+	}
+	if required := t.GetRequired(); len(required) > 0 {
+		params["required"] = required
+	}
+	return params
+}
+
+// ValidateArguments 按参数Schema校验工具调用参数，失败时返回可用 errors.Is 匹配 ErrInvalidArguments 的错误
+// 空参数视为空对象
+func ValidateArguments(t Tool, arguments string) error {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return err
+	}
+	if err := schema.ValidateJSONSchema(args, ParametersSchema(t)); err != nil {
+		return &argumentError{err: err}
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTestEventBytes go test -json 单行事件保留的最大字节数
+	maxTestEventBytes = 1 << 20
+	// maxTestOutputBytes 单个测试保留的最大输出字节数
+	maxTestOutputBytes = 16 << 10
+)
+
+// compileErrorPattern 匹配 file.go:line:col: message 形式的编译错误
+var compileErrorPattern = regexp.MustCompile(`^\S+\.go:\d+(:\d+)?: .+`)
+
+// RunTests Go项目构建/测试工具
+type RunTests struct {
+	BaseTool
+	timeout time.Duration
+}
+
+// testEvent go test -json 输出事件
+type testEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+	Output  string `json:"Output"`
+}
+
+// NewRunTests 创建构建/测试工具
+func NewRunTests() *RunTests {
+	timeout := 120 * time.Second
+	if settings := config.GetConfig().GetToolsSettings(); settings != nil &&
+		settings.RunTests != nil && settings.RunTests.Timeout > 0 {
+		timeout = time.Duration(settings.RunTests.Timeout) * time.Second
+	}
+
+	return &RunTests{
+		BaseTool: BaseTool{
+			Name:        "RunTests",
+			Description: "在工作目录中构建、检查或测试Go代码，返回结构化的通过/失败结果",
+			Parameters: map[string]interface{}{
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "命令类型: build, test, vet",
+					"enum":        []string{"build", "test", "vet"},
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "工作目录下的项目相对路径",
+					"default":     ".",
+				},
+				"packages": map[string]interface{}{
+					"type":        "string",
+					"description": "包匹配模式，多个以空格分隔",
+					"default":     "./...",
+				},
+			},
+			Required: []string{"command"},
+		},
+		timeout: timeout,
+	}
+}
+
+// RunTestsEnabled 检查构建/测试工具是否在配置中启用
+func RunTestsEnabled() bool {
+	settings := config.GetConfig().GetToolsSettings()
+	return settings != nil && settings.RunTests != nil && settings.RunTests.Enabled
+}
+
+// Execute 执行构建/测试
+func (r *RunTests) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateArguments(args, r.Required); err != nil {
+		return nil, err
+	}
+
+	command, _ := args["command"].(string)
+	relPath := "."
+	if pathArg, ok := args["path"].(string); ok && pathArg != "" {
+		relPath = pathArg
+	}
+	packages := []string{"./..."}
+	if pkgArg, ok := args["packages"].(string); ok && strings.TrimSpace(pkgArg) != "" {
+		packages = strings.Fields(pkgArg)
+	}
+	// 包模式作为位置参数传给go命令，以-开头的值会被当作标志（如 -toolexec 可执行任意程序）
+	for _, pattern := range packages {
+		if strings.HasPrefix(pattern, "-") {
+			return nil, invalidArguments("包匹配模式不能以-开头: %s", pattern)
+		}
+	}
+
+	workDir, err := resolveWorkspacePath(ctx, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmdArgs []string
+	switch command {
+	case "build":
+		cmdArgs = append([]string{"build"}, packages...)
+	case "vet":
+		cmdArgs = append([]string{"vet"}, packages...)
+	case "test":
+		cmdArgs = append([]string{"test", "-json"}, packages...)
+	default:
+		return nil, fmt.Errorf("不支持的命令: %s", command)
+	}
+
+	logger.Info("执行Go命令",
+		zap.String("command", command),
+		zap.String("dir", workDir))
+
+	runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, "go", cmdArgs...)
+	cmd.Dir = workDir
+	buffer := newLimitedBuffer(maxOutputBytes())
+	cmd.Stdout = buffer
+	cmd.Stderr = buffer
+	// 测试事件边输出边解析，上限只作用于单个测试的输出和非JSON输出，不会因截断而少计测试
+	var parser *testOutputParser
+	if command == "test" {
+		parser = newTestOutputParser(buffer)
+		cmd.Stdout = parser
+	}
+	runErr := cmd.Run()
+	output := buffer.String()
+
+	if runCtx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("命令执行超时: go %s", strings.Join(cmdArgs, " "))
+	}
+	if runErr != nil {
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("执行go命令失败: %w", runErr)
+		}
+	}
+
+	if parser != nil {
+		parser.Flush()
+		return parser.Result(output, runErr == nil), nil
+	}
+
+	return &schema.ToolOutput{
+		Content: output,
+		Data: map[string]interface{}{
+			"command": command,
+			"errors":  parseCompileErrors(output),
+		},
+		IsError: runErr != nil,
+	}, nil
+}
+
+// testOutputParser 逐行解析 go test -json 输出的 io.Writer
+// 通过和失败的测试在事件到达时计数；非JSON行和无测试的输出写入 plain，受其上限约束
+type testOutputParser struct {
+	line       []byte
+	passed     []string
+	failed     []map[string]interface{}
+	testOutput map[string]*limitedBuffer
+	plain      io.Writer
+}
+
+// newTestOutputParser 创建测试输出解析器，plain 接收构建错误等非测试输出
+func newTestOutputParser(plain io.Writer) *testOutputParser {
+	return &testOutputParser{
+		passed:     make([]string, 0),
+		failed:     make([]map[string]interface{}, 0),
+		testOutput: make(map[string]*limitedBuffer),
+		plain:      plain,
+	}
+}
+
+// Write 实现io.Writer，按行处理事件；超长的行只保留前 maxTestEventBytes 字节
+func (p *testOutputParser) Write(data []byte) (int, error) {
+	n := len(data)
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			p.appendLine(data)
+			break
+		}
+		p.appendLine(data[:end])
+		p.handleLine(p.line)
+		p.line = p.line[:0]
+		data = data[end+1:]
+	}
+	return n, nil
+}
+
+// Flush 处理末尾没有换行符的行
+func (p *testOutputParser) Flush() {
+	if len(p.line) > 0 {
+		p.handleLine(p.line)
+		p.line = p.line[:0]
+	}
+}
+
+// appendLine 将数据追加到当前行，超出 maxTestEventBytes 的部分丢弃
+func (p *testOutputParser) appendLine(data []byte) {
+	if remaining := maxTestEventBytes - len(p.line); remaining > 0 {
+		p.line = append(p.line, data[:min(len(data), remaining)]...)
+	}
+}
+
+// handleLine 处理一行输出
+func (p *testOutputParser) handleLine(line []byte) {
+	var event testEvent
+	if err := json.Unmarshal(line, &event); err != nil {
+		// 构建失败等信息不是JSON格式
+		p.plain.Write(append(line, '\n'))
+		return
+	}
+
+	if event.Test == "" {
+		if event.Action == "output" {
+			io.WriteString(p.plain, event.Output)
+		}
+		return
+	}
+
+	key := event.Package + "." + event.Test
+	switch event.Action {
+	case "output":
+		if p.testOutput[key] == nil {
+			p.testOutput[key] = newLimitedBuffer(maxTestOutputBytes)
+		}
+		p.testOutput[key].Write([]byte(event.Output))
+	case "pass":
+		p.passed = append(p.passed, event.Test)
+		delete(p.testOutput, key)
+	case "fail":
+		snippet := ""
+		if b := p.testOutput[key]; b != nil {
+			snippet = b.String()
+		}
+		delete(p.testOutput, key)
+		p.failed = append(p.failed, map[string]interface{}{
+			"name":    event.Test,
+			"package": event.Package,
+			"output":  snippet,
+		})
+	case "skip":
+		delete(p.testOutput, key)
+	}
+}
+
+// Result 生成测试结果，plain 为写入非测试输出的内容
+// 输出内容为通过和失败的测试数，逐个测试的结果放在结构化数据中
+func (p *testOutputParser) Result(plain string, success bool) *schema.ToolOutput {
+	content := fmt.Sprintf("通过 %d 个测试，失败 %d 个", len(p.passed), len(p.failed))
+	if text := strings.TrimSpace(plain); text != "" {
+		content += "\n" + text
+	}
+
+	return &schema.ToolOutput{
+		Content: content,
+		Data: map[string]interface{}{
+			"command": "test",
+			"passed":  p.passed,
+			"failed":  p.failed,
+			"errors":  parseCompileErrors(plain),
+		},
+		IsError: !success,
+	}
+}
+
+// parseCompileErrors 提取编译错误行
+func parseCompileErrors(output string) []string {
+	errors := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if compileErrorPattern.MatchString(line) {
+			errors = append(errors, line)
+		}
+	}
+	return errors
+}
@@ -0,0 +1,134 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// writeFiles 在目录中写入文件，键为相对路径
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// runTool 执行工具并返回ToolOutput
+func runTool(t *testing.T, ctx context.Context, tool Tool, arguments string) *schema.ToolOutput {
+	t.Helper()
+	result, err := tool.Execute(ctx, arguments)
+	if err != nil {
+		t.Fatalf("%s(%s): %v", tool.GetName(), arguments, err)
+	}
+	return schema.ToToolOutput(result)
+}
+
+// newGoModule 在工作目录中创建只包含给定文件的Go模块
+func newGoModule(t *testing.T, files map[string]string) context.Context {
+	t.Helper()
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/tiny\n\ngo 1.21\n"
+	writeFiles(t, dir, files)
+	return WithWorkspace(context.Background(), dir)
+}
+
+func TestRunTestsReportsPassingAndFailingTests(t *testing.T) {
+	ctx := newGoModule(t, map[string]string{
+		"tiny.go": "package tiny\n\nfunc Add(a, b int) int { return a + b }\n",
+		"tiny_test.go": `package tiny
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+}
+
+func TestBroken(t *testing.T) {
+	t.Fatalf("want %d, got %d", 4, Add(2, 3))
+}
+`,
+	})
+
+	output := runTool(t, ctx, NewRunTests(), `{"command": "test"}`)
+	if !output.IsError {
+		t.Error("failing test run should be an error result")
+	}
+	if !strings.HasPrefix(output.Content, "通过 1 个测试，失败 1 个") {
+		t.Errorf("content = %q", output.Content)
+	}
+
+	data := output.Data.(map[string]interface{})
+	if passed := data["passed"].([]string); !reflect.DeepEqual(passed, []string{"TestAdd"}) {
+		t.Errorf("passed = %v", passed)
+	}
+	failed := data["failed"].([]map[string]interface{})
+	if len(failed) != 1 || failed[0]["name"] != "TestBroken" {
+		t.Fatalf("failed = %v", failed)
+	}
+	if snippet := failed[0]["output"].(string); !strings.Contains(snippet, "want 4, got 5") {
+		t.Errorf("failure snippet = %q", snippet)
+	}
+}
+
+func TestRunTestsParsesCompileErrors(t *testing.T) {
+	ctx := newGoModule(t, map[string]string{
+		"tiny.go": "package tiny\n\nfunc Add(a, b int) int { return a + c }\n",
+	})
+
+	output := runTool(t, ctx, NewRunTests(), `{"command": "build"}`)
+	if !output.IsError {
+		t.Error("failing build should be an error result")
+	}
+	compileErrors := output.Data.(map[string]interface{})["errors"].([]string)
+	if len(compileErrors) != 1 || !strings.Contains(compileErrors[0], "tiny.go:3") || !strings.Contains(compileErrors[0], "undefined: c") {
+		t.Errorf("errors = %q", compileErrors)
+	}
+}
+
+func TestRunTestsRejectsPathOutsideWorkspace(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	if _, err := NewRunTests().Execute(ctx, `{"command": "vet", "path": "../.."}`); err == nil {
+		t.Error("expected error for a path outside the workspace")
+	}
+}
+
+func TestRunTestsRejectsFlagPackages(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	for _, packages := range []string{"-toolexec=/bin/sh", "./... -exec=/bin/sh"} {
+		arguments, _ := json.Marshal(map[string]string{"command": "build", "packages": packages})
+		if _, err := NewRunTests().Execute(ctx, string(arguments)); !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("packages %q: err = %v, want ErrInvalidArguments", packages, err)
+		}
+	}
+}
+
+func TestRunTestsAcceptsMultiplePackages(t *testing.T) {
+	ctx := newGoModule(t, map[string]string{
+		"a/a.go": "package a\n\nfunc A() int { return 1 }\n",
+		"b/b.go": "package b\n\nfunc B() int { return 2 }\n",
+	})
+
+	if output := runTool(t, ctx, NewRunTests(), `{"command": "vet", "packages": "./a ./b"}`); output.IsError {
+		t.Errorf("vet of two packages failed: %+v", output)
+	}
+}
@@ -0,0 +1,109 @@
+package tool
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CacheableTool 可缓存工具接口，只读工具实现该接口以允许缓存其结果
+type CacheableTool interface {
+	Cacheable(arguments string) bool
+}
+
+// IsCacheable 检查工具在给定参数下的结果是否可缓存
+func IsCacheable(t Tool, arguments string) bool {
+	cacheable, ok := t.(CacheableTool)
+	return ok && cacheable.Cacheable(arguments)
+}
+
+// cacheEntry 缓存条目
+type cacheEntry struct {
+	key       string
+	result    interface{}
+	expiresAt time.Time
+}
+
+// ResultCache 工具结果LRU缓存
+type ResultCache struct {
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List
+	mu       sync.Mutex
+}
+
+// NewResultCache 创建工具结果缓存
+func NewResultCache(capacity int, ttl time.Duration) *ResultCache {
+	if capacity <= 0 {
+		capacity = 128
+	}
+	return &ResultCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get 获取缓存的工具结果
+func (c *ResultCache) Get(toolName, arguments string) (interface{}, bool) {
+	key := cacheKey(toolName, arguments)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// Set 缓存工具结果
+func (c *ResultCache) Set(toolName, arguments string, result interface{}) {
+	key := cacheKey(toolName, arguments)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{
+		key:       key,
+		result:    result,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey 根据工具名和规范化后的参数生成缓存键
+func cacheKey(toolName, arguments string) string {
+	if args, err := parseArguments(arguments); err == nil {
+		// 重新序列化以消除键顺序和空白差异
+		if normalized, err := json.Marshal(args); err == nil {
+			arguments = string(normalized)
+		}
+	}
+	return toolName + ":" + arguments
+}
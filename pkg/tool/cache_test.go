@@ -0,0 +1,89 @@
+package tool
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+func TestResultCacheNormalizesArguments(t *testing.T) {
+	cache := NewResultCache(4, time.Minute)
+	cache.Set("Search", `{"query": "go", "limit": 5}`, "result")
+
+	if got, ok := cache.Get("Search", `{"limit":5,"query":"go"}`); !ok || got != "result" {
+		t.Errorf("Get with reordered arguments = %v, %v", got, ok)
+	}
+	if _, ok := cache.Get("Browse", `{"query": "go", "limit": 5}`); ok {
+		t.Error("cache entry shared across tools")
+	}
+}
+
+func TestResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewResultCache(2, 0)
+	cache.Set("T", "1", "one")
+	cache.Set("T", "2", "two")
+	cache.Get("T", "1")
+	cache.Set("T", "3", "three")
+
+	if _, ok := cache.Get("T", "2"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if _, ok := cache.Get("T", "1"); !ok {
+		t.Error("recently used entry was evicted")
+	}
+}
+
+func TestResultCacheExpires(t *testing.T) {
+	cache := NewResultCache(2, time.Millisecond)
+	cache.Set("T", "1", "one")
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("T", "1"); ok {
+		t.Error("expired entry returned")
+	}
+}
+
+func TestEditorIsNotCacheable(t *testing.T) {
+	if IsCacheable(NewStrReplaceEditor(), `{"command": "view", "path": "a.txt"}`) {
+		t.Error("StrReplaceEditor must not be cacheable")
+	}
+}
+
+func TestSimpleBrowserCacheability(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+"\n[tools.network]\nallow_private = true\n")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	browser := NewSimpleBrowser()
+	page := `{"url": "` + server.URL + `/page"}`
+	if !browser.Cacheable(page) {
+		t.Error("GET without cookies should be cacheable")
+	}
+	if browser.Cacheable(`{"url": "` + server.URL + `", "method": "POST"}`) {
+		t.Error("POST must not be cacheable")
+	}
+	if browser.Cacheable(`{"url": "` + server.URL + `", "form": {"a": "b"}}`) {
+		t.Error("form submission must not be cacheable")
+	}
+
+	ctx := context.Background()
+	if output := runTool(t, ctx, browser, `{"url": "`+server.URL+`/missing"}`); !output.IsError {
+		t.Error("404 response should be an error result so it is not cached")
+	}
+
+	runTool(t, ctx, browser, `{"url": "`+server.URL+`/login"}`)
+	if browser.Cacheable(page) {
+		t.Error("GET with session cookies for the host should not be cacheable")
+	}
+}
@@ -0,0 +1,327 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// 支持的图表类型
+const (
+	ChartLine = "line"
+	ChartBar  = "bar"
+	ChartPie  = "pie"
+)
+
+// 支持的图片格式
+const (
+	ChartPNG = "png"
+	ChartSVG = "svg"
+)
+
+// 图表默认尺寸（像素）
+const (
+	defaultChartWidth  = 800
+	defaultChartHeight = 500
+)
+
+// chartSeries 一组数据，Values 与标签一一对应
+type chartSeries struct {
+	Name   string
+	Values []float64
+}
+
+// chartSpec 校验后的图表描述
+type chartSpec struct {
+	Type   string
+	Title  string
+	Labels []string
+	Series []chartSeries
+}
+
+// Chart 图表绘制工具，使用纯Go绘图库，不依赖Python绘图环境
+type Chart struct {
+	BaseTool
+}
+
+// NewChart 创建图表绘制工具
+func NewChart() *Chart {
+	return &Chart{
+		BaseTool: BaseTool{
+			Name:        "Chart",
+			Description: "根据数据绘制折线图、柱状图或饼图，保存为PNG或SVG文件到工作目录。line可包含多组数据，bar和pie只能包含一组。PNG图片会附在结果中供查看",
+			Parameters: map[string]interface{}{
+				"type": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{ChartLine, ChartBar, ChartPie},
+					"description": "图表类型",
+				},
+				"title": map[string]interface{}{
+					"type":        "string",
+					"description": "图表标题（可选）",
+				},
+				"labels": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "横轴标签（饼图为各扇区名称）",
+				},
+				"series": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"name":   map[string]interface{}{"type": "string"},
+							"values": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}},
+						},
+						"required": []string{"values"},
+					},
+					"description": "数据组，每组的values数量须与labels相同",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{ChartPNG, ChartSVG},
+					"description": "图片格式，默认png",
+				},
+				"output_path": map[string]interface{}{
+					"type":        "string",
+					"description": "图片保存路径，相对路径基于工作目录（可选，默认 charts/chart_<时间戳>.<格式>）",
+				},
+			},
+			Required: []string{"type", "labels", "series"},
+		},
+	}
+}
+
+// Execute 绘制图表并保存
+func (c *Chart) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateArguments(args, c.Required); err != nil {
+		return nil, err
+	}
+
+	spec, err := parseChartSpec(args)
+	if err != nil {
+		return nil, err
+	}
+
+	format := ChartPNG
+	if value, ok := args["format"].(string); ok && value != "" {
+		format = strings.ToLower(value)
+	}
+	if format != ChartPNG && format != ChartSVG {
+		return nil, invalidArguments("不支持的图片格式: %s", format)
+	}
+
+	image, err := renderChart(spec, format)
+	if err != nil {
+		return nil, fmt.Errorf("绘制图表失败: %w", err)
+	}
+
+	outputPath, _ := args["output_path"].(string)
+	if outputPath == "" {
+		outputPath = filepath.Join("charts", fmt.Sprintf("chart_%d.%s", time.Now().UnixNano(), format))
+	}
+	resolved, err := resolveUnprotectedPath(ctx, outputPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := writeFileAtomic(resolved, image, 0644); err != nil {
+		return nil, fmt.Errorf("写入文件失败: %w", err)
+	}
+
+	output := schema.NewToolOutput(fmt.Sprintf("已绘制%s图并保存到 %s", spec.Type, resolved), map[string]interface{}{
+		"path":   resolved,
+		"format": format,
+		"bytes":  len(image),
+	})
+	// 视觉模型只接受位图，SVG不附带图片
+	if format == ChartPNG {
+		output.Base64Image = base64.StdEncoding.EncodeToString(image)
+	}
+	return output, nil
+}
+
+// parseChartSpec 从参数中读取并校验图表描述
+func parseChartSpec(args map[string]interface{}) (chartSpec, error) {
+	spec := chartSpec{}
+	spec.Type, _ = args["type"].(string)
+	spec.Type = strings.ToLower(spec.Type)
+	spec.Title, _ = args["title"].(string)
+
+	switch spec.Type {
+	case ChartLine, ChartBar, ChartPie:
+	default:
+		return spec, invalidArguments("不支持的图表类型: %s", spec.Type)
+	}
+
+	labels, ok := args["labels"].([]interface{})
+	if !ok || len(labels) == 0 {
+		return spec, invalidArguments("参数labels必须是非空数组")
+	}
+	for i, label := range labels {
+		text, ok := label.(string)
+		if !ok {
+			return spec, invalidArguments("labels第%d项必须是字符串", i+1)
+		}
+		spec.Labels = append(spec.Labels, text)
+	}
+
+	series, ok := args["series"].([]interface{})
+	if !ok || len(series) == 0 {
+		return spec, invalidArguments("参数series必须是非空数组")
+	}
+	for i, item := range series {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return spec, invalidArguments("series第%d项必须是对象", i+1)
+		}
+		name, _ := object["name"].(string)
+		values, _ := object["values"].([]interface{})
+		if len(values) != len(spec.Labels) {
+			return spec, invalidArguments("series第%d项有%d个值，与labels的%d个不一致", i+1, len(values), len(spec.Labels))
+		}
+		data := chartSeries{Name: name}
+		for j, value := range values {
+			number, ok := value.(float64)
+			if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+				return spec, invalidArguments("series第%d项的第%d个值必须是数字", i+1, j+1)
+			}
+			data.Values = append(data.Values, number)
+		}
+		spec.Series = append(spec.Series, data)
+	}
+
+	switch spec.Type {
+	case ChartLine:
+		if len(spec.Labels) < 2 {
+			return spec, invalidArguments("折线图至少需要2个数据点")
+		}
+	case ChartBar:
+		if len(spec.Series) != 1 {
+			return spec, invalidArguments("柱状图只能包含一组数据")
+		}
+	case ChartPie:
+		if len(spec.Series) != 1 {
+			return spec, invalidArguments("饼图只能包含一组数据")
+		}
+		total := 0.0
+		for _, value := range spec.Series[0].Values {
+			if value < 0 {
+				return spec, invalidArguments("饼图的值不能为负数")
+			}
+			total += value
+		}
+		if total == 0 {
+			return spec, invalidArguments("饼图的值之和必须大于0")
+		}
+	}
+	return spec, nil
+}
+
+// renderChart 按格式渲染图表
+func renderChart(spec chartSpec, format string) ([]byte, error) {
+	renderer := chart.PNG
+	if format == ChartSVG {
+		renderer = chart.SVG
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch spec.Type {
+	case ChartLine:
+		err = lineChart(spec).Render(renderer, &buf)
+	case ChartBar:
+		err = barChart(spec).Render(renderer, &buf)
+	case ChartPie:
+		err = pieChart(spec).Render(renderer, &buf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// lineChart 折线图，横轴为标签序号，多组数据时显示图例
+func lineChart(spec chartSpec) *chart.Chart {
+	ticks := make([]chart.Tick, len(spec.Labels))
+	xValues := make([]float64, len(spec.Labels))
+	for i, label := range spec.Labels {
+		xValues[i] = float64(i)
+		ticks[i] = chart.Tick{Value: float64(i), Label: label}
+	}
+
+	graph := &chart.Chart{
+		Title:  spec.Title,
+		Width:  defaultChartWidth,
+		Height: defaultChartHeight,
+		XAxis:  chart.XAxis{Ticks: ticks},
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40, Left: 20, Right: 20, Bottom: 20},
+		},
+	}
+	for _, series := range spec.Series {
+		graph.Series = append(graph.Series, chart.ContinuousSeries{
+			Name:    series.Name,
+			XValues: xValues,
+			YValues: series.Values,
+		})
+	}
+	if len(spec.Series) > 1 {
+		graph.Elements = []chart.Renderable{chart.Legend(graph)}
+	}
+	return graph
+}
+
+// barChart 柱状图
+func barChart(spec chartSpec) *chart.BarChart {
+	bars := make([]chart.Value, len(spec.Labels))
+	for i, label := range spec.Labels {
+		bars[i] = chart.Value{Label: label, Value: spec.Series[0].Values[i]}
+	}
+
+	// 柱宽随数量缩小，保证所有柱子放得下
+	barWidth := (defaultChartWidth - 100) / (2 * len(bars))
+	if barWidth > 60 {
+		barWidth = 60
+	}
+	return &chart.BarChart{
+		Title:    spec.Title,
+		Width:    defaultChartWidth,
+		Height:   defaultChartHeight,
+		BarWidth: barWidth,
+		Bars:     bars,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 40},
+		},
+	}
+}
+
+// pieChart 饼图
+func pieChart(spec chartSpec) *chart.PieChart {
+	values := make([]chart.Value, len(spec.Labels))
+	for i, label := range spec.Labels {
+		values[i] = chart.Value{Label: label, Value: spec.Series[0].Values[i]}
+	}
+	return &chart.PieChart{
+		Title:  spec.Title,
+		Width:  defaultChartWidth,
+		Height: defaultChartHeight,
+		Values: values,
+	}
+}
@@ -0,0 +1,94 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChartRendersBarChartPNG(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), dir)
+
+	output := runTool(t, ctx, NewChart(), `{
+		"type": "bar",
+		"title": "Sales",
+		"labels": ["Q1", "Q2", "Q3", "Q4"],
+		"series": [{"name": "2024", "values": [120, 98.5, 143, 160]}],
+		"output_path": "charts/sales.png"
+	}`)
+
+	path := filepath.Join(dir, "charts", "sales.png")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("chart not written: %v", err)
+	}
+	image, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("chart is not a valid PNG: %v", err)
+	}
+	if bounds := image.Bounds(); bounds.Dx() != defaultChartWidth || bounds.Dy() != defaultChartHeight {
+		t.Errorf("image size = %v", bounds)
+	}
+
+	if output.Data.(map[string]interface{})["path"] != path {
+		t.Errorf("data = %+v", output.Data)
+	}
+	encoded, err := base64.StdEncoding.DecodeString(output.Base64Image)
+	if err != nil || !bytes.Equal(encoded, data) {
+		t.Error("base64 image does not match the written file")
+	}
+}
+
+func TestChartRendersSVG(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), dir)
+
+	for _, arguments := range []string{
+		`{"type": "line", "labels": ["a", "b", "c"], "series": [{"name": "x", "values": [1, 3, 2]}, {"name": "y", "values": [2, 2, 4]}], "format": "svg", "output_path": "line.svg"}`,
+		`{"type": "pie", "labels": ["a", "b"], "series": [{"values": [1, 3]}], "format": "svg", "output_path": "pie.svg"}`,
+	} {
+		output := runTool(t, ctx, NewChart(), arguments)
+		data, err := os.ReadFile(output.Data.(map[string]interface{})["path"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "<svg") {
+			t.Errorf("%s: output is not SVG", arguments)
+		}
+		if output.Base64Image != "" {
+			t.Errorf("%s: SVG should not be attached as an image", arguments)
+		}
+	}
+}
+
+func TestChartValidatesSpec(t *testing.T) {
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	tests := []struct {
+		name      string
+		arguments string
+	}{
+		{"unknown type", `{"type": "radar", "labels": ["a"], "series": [{"values": [1]}]}`},
+		{"empty labels", `{"type": "bar", "labels": [], "series": [{"values": []}]}`},
+		{"length mismatch", `{"type": "bar", "labels": ["a", "b"], "series": [{"values": [1]}]}`},
+		{"non-numeric value", `{"type": "bar", "labels": ["a"], "series": [{"values": ["x"]}]}`},
+		{"multiple bar series", `{"type": "bar", "labels": ["a"], "series": [{"values": [1]}, {"values": [2]}]}`},
+		{"negative pie value", `{"type": "pie", "labels": ["a", "b"], "series": [{"values": [1, -1]}]}`},
+		{"single line point", `{"type": "line", "labels": ["a"], "series": [{"values": [1]}]}`},
+		{"unknown format", `{"type": "bar", "labels": ["a"], "series": [{"values": [1]}], "format": "gif"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewChart().Execute(ctx, tt.arguments)
+			if !errors.Is(err, ErrInvalidArguments) {
+				t.Errorf("err = %v, want ErrInvalidArguments", err)
+			}
+		})
+	}
+}
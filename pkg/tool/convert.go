@@ -0,0 +1,386 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持转换的格式
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatYAML     = "yaml"
+)
+
+// converters 支持的转换，键为 源格式->目标格式
+var converters = map[string]func(string) (string, error){
+	FormatMarkdown + "->" + FormatHTML: markdownToHTML,
+	FormatHTML + "->" + FormatMarkdown: htmlToMarkdown,
+	FormatJSON + "->" + FormatCSV:      jsonToCSV,
+	FormatCSV + "->" + FormatJSON:      csvToJSON,
+	FormatJSON + "->" + FormatYAML:     jsonToYAML,
+	FormatYAML + "->" + FormatJSON:     yamlToJSON,
+}
+
+// ConvertFormat 文件格式转换工具，不依赖Python
+type ConvertFormat struct {
+	BaseTool
+}
+
+// NewConvertFormat 创建格式转换工具
+func NewConvertFormat() *ConvertFormat {
+	formats := []string{FormatMarkdown, FormatHTML, FormatJSON, FormatCSV, FormatYAML}
+	return &ConvertFormat{
+		BaseTool: BaseTool{
+			Name:        "ConvertFormat",
+			Description: "在常见格式之间转换文本：markdown→html、html→markdown、json↔csv、json↔yaml。输入为text或工作目录中的文件，结果写入output_path或直接返回",
+			Parameters: map[string]interface{}{
+				"from": map[string]interface{}{
+					"type":        "string",
+					"enum":        formats,
+					"description": "源格式",
+				},
+				"to": map[string]interface{}{
+					"type":        "string",
+					"enum":        formats,
+					"description": "目标格式",
+				},
+				"text": map[string]interface{}{
+					"type":        "string",
+					"description": "要转换的文本（与path二选一）",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "要转换的文件路径，相对路径基于工作目录",
+				},
+				"output_path": map[string]interface{}{
+					"type":        "string",
+					"description": "结果写入的文件路径（可选，不提供时直接返回结果）",
+				},
+			},
+			Required: []string{"from", "to"},
+		},
+	}
+}
+
+// Execute 执行格式转换
+func (c *ConvertFormat) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateArguments(args, c.Required); err != nil {
+		return nil, err
+	}
+
+	from, _ := args["from"].(string)
+	to, _ := args["to"].(string)
+	convert, ok := converters[strings.ToLower(from)+"->"+strings.ToLower(to)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的转换: %s -> %s", from, to)
+	}
+
+	text, _ := args["text"].(string)
+	if path, ok := args["path"].(string); ok && path != "" {
+		resolved, err := resolveUnprotectedPath(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, invalidArguments("需要提供text或path参数")
+	}
+
+	result, err := convert(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s 转换为 %s 失败: %w", from, to, err)
+	}
+
+	outputPath, _ := args["output_path"].(string)
+	if outputPath == "" {
+		return schema.NewToolOutput(result, nil), nil
+	}
+
+	resolved, err := resolveUnprotectedPath(ctx, outputPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := writeFileAtomic(resolved, []byte(result), 0644); err != nil {
+		return nil, fmt.Errorf("写入文件失败: %w", err)
+	}
+	return schema.NewToolOutput(fmt.Sprintf("已转换为 %s 并写入 %s", to, resolved), map[string]interface{}{
+		"path":  resolved,
+		"bytes": len(result),
+	}), nil
+}
+
+// parseOrdered 解析JSON或YAML为保留键顺序的节点
+// JSON是YAML的子集，统一按YAML解析以保留对象键的原始顺序
+func parseOrdered(text string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, fmt.Errorf("内容为空")
+	}
+	return doc.Content[0], nil
+}
+
+// resolveAlias 展开YAML别名
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// writeJSON 按节点顺序输出JSON
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	node = resolveAlias(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(resolveAlias(node.Content[i]).Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("第%d行的值无法表示为JSON: %w", node.Line, err)
+		}
+		buf.Write(data)
+	default:
+		return fmt.Errorf("第%d行的节点类型不支持", node.Line)
+	}
+	return nil
+}
+
+// nodeJSON 将节点输出为缩进的JSON
+func nodeJSON(node *yaml.Node) (string, error) {
+	var compact bytes.Buffer
+	if err := writeJSON(&compact, node); err != nil {
+		return "", err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, compact.Bytes(), "", "  "); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
+
+// jsonToYAML JSON转YAML，保留对象键顺序
+func jsonToYAML(text string) (string, error) {
+	if !json.Valid([]byte(text)) {
+		return "", fmt.Errorf("不是有效的JSON")
+	}
+	node, err := parseOrdered(text)
+	if err != nil {
+		return "", err
+	}
+	blockStyle(node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// blockStyle 清除JSON的流式风格和引号，输出为块风格YAML
+// 编码时会为需要保持字符串类型的值（如 "true"、"123"）自动加引号
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// yamlToJSON YAML转JSON，保留映射键顺序
+func yamlToJSON(text string) (string, error) {
+	node, err := parseOrdered(text)
+	if err != nil {
+		return "", err
+	}
+	return nodeJSON(node)
+}
+
+// jsonToCSV JSON转CSV
+// 输入为对象数组时，表头为各对象键按首次出现顺序的并集；输入为数组的数组时逐行输出
+// 嵌套的对象和数组以JSON文本写入单元格
+func jsonToCSV(text string) (string, error) {
+	if !json.Valid([]byte(text)) {
+		return "", fmt.Errorf("不是有效的JSON")
+	}
+	node, err := parseOrdered(text)
+	if err != nil {
+		return "", err
+	}
+	if node.Kind != yaml.SequenceNode {
+		return "", fmt.Errorf("CSV转换需要JSON数组")
+	}
+
+	var rows [][]string
+	var header []string
+	columns := make(map[string]int)
+	var records []map[string]string
+
+	for i, item := range node.Content {
+		item = resolveAlias(item)
+		switch item.Kind {
+		case yaml.MappingNode:
+			record := make(map[string]string)
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				key := resolveAlias(item.Content[j]).Value
+				if _, ok := columns[key]; !ok {
+					columns[key] = len(header)
+					header = append(header, key)
+				}
+				cell, err := csvCell(item.Content[j+1])
+				if err != nil {
+					return "", err
+				}
+				record[key] = cell
+			}
+			records = append(records, record)
+		case yaml.SequenceNode:
+			row := make([]string, 0, len(item.Content))
+			for _, value := range item.Content {
+				cell, err := csvCell(value)
+				if err != nil {
+					return "", err
+				}
+				row = append(row, cell)
+			}
+			rows = append(rows, row)
+		default:
+			return "", fmt.Errorf("第%d个元素不是对象或数组", i+1)
+		}
+	}
+	if len(records) > 0 && len(rows) > 0 {
+		return "", fmt.Errorf("数组元素需全部为对象或全部为数组")
+	}
+
+	if len(records) > 0 {
+		rows = append(rows, header)
+		for _, record := range records {
+			row := make([]string, len(header))
+			for key, value := range record {
+				row[columns[key]] = value
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// csvCell 将JSON值转换为CSV单元格文本，null为空
+func csvCell(node *yaml.Node) (string, error) {
+	node = resolveAlias(node)
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!null" {
+			return "", nil
+		}
+		return node.Value, nil
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, node); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// csvToJSON CSV转JSON，第一行为表头，每行转为一个对象，值均为字符串
+func csvToJSON(text string) (string, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("CSV为空")
+	}
+
+	header := rows[0]
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range rows[1:] {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		for j, key := range header {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			keyJSON, _ := json.Marshal(key)
+			valueJSON, _ := json.Marshal(row[j])
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+			buf.Write(valueJSON)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
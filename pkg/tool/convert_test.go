@@ -0,0 +1,128 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// convertArguments 格式转换参数
+func convertArguments(from, to, text string) string {
+	arguments, _ := json.Marshal(map[string]string{"from": from, "to": to, "text": text})
+	return string(arguments)
+}
+
+func TestConvertFormatPairs(t *testing.T) {
+	tests := []struct {
+		from, to string
+		input    string
+		want     string
+	}{
+		{
+			FormatMarkdown, FormatHTML,
+			"# 标题\n\n段落有 **粗体**、*斜体*、`代码` 和 [链接](https://example.com)。\n\n- 一\n- 二\n\n1. 甲\n2. 乙\n\n```go\nx := 1 < 2\n```\n",
+			`<h1>标题</h1>
+<p>段落有 <strong>粗体</strong>、<em>斜体</em>、<code>代码</code> 和 <a href="https://example.com">链接</a>。</p>
+<ul>
+<li>一</li>
+<li>二</li>
+</ul>
+<ol>
+<li>甲</li>
+<li>乙</li>
+</ol>
+<pre><code class="language-go">x := 1 &lt; 2</code></pre>`,
+		},
+		{
+			FormatHTML, FormatMarkdown,
+			`<h1>标题</h1><p>段落有 <strong>粗体</strong> 和 <a href="https://example.com">链接</a>&amp;符号</p><ul><li>一</li><li>二</li></ul><script>alert(1)</script>`,
+			"# 标题\n\n段落有 **粗体** 和 [链接](https://example.com)&符号\n\n- 一\n- 二",
+		},
+		{
+			// 表头按键首次出现的顺序，嵌套值以JSON写入单元格
+			FormatJSON, FormatCSV,
+			`[{"name": "张三", "age": 30}, {"name": "李四, Jr.", "city": "北京", "tags": ["a"]}]`,
+			"name,age,city,tags\n张三,30,,\n\"李四, Jr.\",,北京,\"[\"\"a\"\"]\"",
+		},
+		{
+			FormatCSV, FormatJSON,
+			"name,age\n张三,30\n\"李四, Jr.\",\n",
+			`[
+  {
+    "name": "张三",
+    "age": "30"
+  },
+  {
+    "name": "李四, Jr.",
+    "age": ""
+  }
+]`,
+		},
+		{
+			// 保留键的原始顺序
+			FormatJSON, FormatYAML,
+			`{"b": 1, "a": [1, "x"], "c": {"d": null}}`,
+			"b: 1\na:\n  - 1\n  - x\nc:\n  d: null",
+		},
+		{
+			FormatYAML, FormatJSON,
+			"b: 1\na:\n  - 1\n  - x\nc:\n  d: ~\n",
+			`{
+  "b": 1,
+  "a": [
+    1,
+    "x"
+  ],
+  "c": {
+    "d": null
+  }
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			output := runTool(t, context.Background(), NewConvertFormat(), convertArguments(tt.from, tt.to, tt.input))
+			if got := strings.TrimSpace(output.Content); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertFormatFiles(t *testing.T) {
+	workspace := t.TempDir()
+	ctx := WithWorkspace(context.Background(), workspace)
+	if err := os.WriteFile(filepath.Join(workspace, "data.json"), []byte(`[{"x": 1}, {"x": 2}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := runTool(t, ctx, NewConvertFormat(), `{"from": "json", "to": "csv", "path": "data.json", "output_path": "out/data.csv"}`)
+	data, err := os.ReadFile(filepath.Join(workspace, "out", "data.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "x\n1\n2" {
+		t.Errorf("written csv = %q", data)
+	}
+	if !strings.Contains(output.Content, "data.csv") {
+		t.Errorf("output = %q, want the written path", output.Content)
+	}
+}
+
+func TestConvertFormatErrors(t *testing.T) {
+	tests := map[string]string{
+		"unsupported pair": convertArguments(FormatCSV, FormatHTML, "a,b\n1,2"),
+		"invalid json":     convertArguments(FormatJSON, FormatYAML, `{"a": `),
+		"csv needs array":  convertArguments(FormatJSON, FormatCSV, `"text"`),
+		"no input":         `{"from": "json", "to": "yaml"}`,
+	}
+	for name, arguments := range tests {
+		if _, err := NewConvertFormat().Execute(context.Background(), arguments); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
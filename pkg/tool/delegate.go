@@ -0,0 +1,80 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// DelegateFunc 子任务执行函数，由全新的子智能体完成任务并返回最终结果
+// maxSteps 不大于0时使用默认步数
+type DelegateFunc func(ctx context.Context, task, background string, maxSteps int) (string, error)
+
+// DelegateTask 子任务委派工具
+type DelegateTask struct {
+	BaseTool
+	// Delegate 执行委派的子任务，为nil时工具不可用
+	Delegate DelegateFunc
+}
+
+// NewDelegateTask 创建子任务委派工具
+func NewDelegateTask() *DelegateTask {
+	return &DelegateTask{
+		BaseTool: BaseTool{
+			Name:        "DelegateTask",
+			Description: "将一个独立的子任务委派给新的子智能体完成，返回子智能体的最终结果。子智能体看不到当前对话，任务描述需包含完成任务所需的全部信息",
+			Parameters: map[string]interface{}{
+				"task": map[string]interface{}{
+					"type":        "string",
+					"description": "子任务描述，说明要完成什么以及期望返回的结果",
+				},
+				"context": map[string]interface{}{
+					"type":        "string",
+					"description": "子任务需要的背景信息（可选）",
+				},
+				"max_steps": map[string]interface{}{
+					"type":        "integer",
+					"description": "子智能体最多执行的步数（可选，不超过当前智能体的步数上限）",
+				},
+			},
+			Required: []string{"task"},
+		},
+	}
+}
+
+// Execute 委派子任务并等待子智能体完成
+func (d *DelegateTask) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateArguments(args, d.Required); err != nil {
+		return nil, err
+	}
+
+	if d.Delegate == nil {
+		return nil, fmt.Errorf("委派工具未配置子智能体")
+	}
+
+	task, ok := args["task"].(string)
+	if !ok || task == "" {
+		return nil, invalidArguments("参数task必须是非空字符串")
+	}
+	background, _ := args["context"].(string)
+	maxSteps := 0
+	if steps, ok := args["max_steps"].(float64); ok {
+		maxSteps = int(steps)
+	}
+
+	logger.Info("委派子任务", zap.String("task", task), zap.Int("max_steps", maxSteps))
+
+	result, err := d.Delegate(ctx, task, background, maxSteps)
+	if err != nil {
+		return nil, fmt.Errorf("子任务执行失败: %w", err)
+	}
+	return schema.NewToolOutput(result, nil), nil
+}
@@ -0,0 +1,243 @@
+package tool
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// diffContextLines 统一diff的上下文行数
+const diffContextLines = 3
+
+// diffOp 单行diff操作
+type diffOp struct {
+	kind    byte // ' ' 未变, '-' 删除, '+' 新增
+	text    string
+	oldLine int
+	newLine int
+}
+
+// unifiedDiff 生成before/after内容的统一diff，内容相同时返回空字符串
+func unifiedDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	name := strings.TrimPrefix(filepath.ToSlash(path), "/")
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+
+	for _, hunk := range groupHunks(ops, diffContextLines) {
+		oldStart, oldCount, newStart, newCount := hunkRange(hunk)
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, op := range hunk {
+			b.WriteByte(op.kind)
+			b.WriteString(op.text)
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String()
+}
+
+// splitLines 按行拆分内容，忽略末尾换行
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// maxDiffEdits Myers算法搜索的最大编辑距离，超出时整体视为删除原内容、写入新内容
+// 回溯记录的内存与编辑距离的平方成正比，上限避免大文件重写时占用过多内存
+const maxDiffEdits = 1000
+
+// diffLines 基于Myers算法计算行级diff，时间 O((N+M)·D)，D 为编辑距离
+func diffLines(a, b []string) []diffOp {
+	// 去掉公共前缀和后缀，缩小计算规模
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+
+	kinds := make([]byte, 0, len(a)+len(b))
+	for range a[:prefix] {
+		kinds = append(kinds, ' ')
+	}
+	if edits, ok := myersDiff(midA, midB, maxDiffEdits); ok {
+		kinds = append(kinds, edits...)
+	} else {
+		for range midA {
+			kinds = append(kinds, '-')
+		}
+		for range midB {
+			kinds = append(kinds, '+')
+		}
+	}
+	for range a[len(a)-suffix:] {
+		kinds = append(kinds, ' ')
+	}
+
+	ops := make([]diffOp, 0, len(kinds))
+	i, j := 0, 0
+	for _, kind := range kinds {
+		op := diffOp{kind: kind, oldLine: i + 1, newLine: j + 1}
+		switch kind {
+		case ' ':
+			op.text = a[i]
+			i++
+			j++
+		case '-':
+			op.text = a[i]
+			i++
+		default:
+			op.text = b[j]
+			j++
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// myersDiff 计算把 a 变为 b 的最短编辑序列（' ' 保留、'-' 删除、'+' 新增），编辑距离超过 maxEdits 时返回false
+func myersDiff(a, b []string, maxEdits int) ([]byte, bool) {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	// v[offset+k] 为对角线 k 上已到达的最远 x
+	v := make([]int, 2*offset+1)
+	// trace[d] 为第 d 轮开始时对角线 -d..d 上的 v，用于回溯
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEdits {
+			return nil, false
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrackMyers(trace, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrackMyers 根据每轮记录的 v 从终点回溯出编辑序列
+func backtrackMyers(trace [][]int, n, m int) []byte {
+	var edits []byte
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, ' ')
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, '+')
+			y--
+		} else {
+			edits = append(edits, '-')
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, ' ')
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// groupHunks 将diff操作按上下文行数分组为hunk
+func groupHunks(ops []diffOp, context int) [][]diffOp {
+	var hunks [][]diffOp
+	start, end := -1, -1
+
+	for idx, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		from := idx - context
+		if from < 0 {
+			from = 0
+		}
+		to := idx + context + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		if start >= 0 && from <= end {
+			end = to
+			continue
+		}
+		if start >= 0 {
+			hunks = append(hunks, ops[start:end])
+		}
+		start, end = from, to
+	}
+
+	if start >= 0 {
+		hunks = append(hunks, ops[start:end])
+	}
+	return hunks
+}
+
+// hunkRange 计算hunk头部的行号范围
+func hunkRange(hunk []diffOp) (oldStart, oldCount, newStart, newCount int) {
+	oldStart, newStart = hunk[0].oldLine, hunk[0].newLine
+	for _, op := range hunk {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	// 按统一diff约定，空范围的起始行号为前一行
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+	return
+}
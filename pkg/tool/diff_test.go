@@ -0,0 +1,162 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// numberedLines 生成内容为 1..n 的行，每行以换行结尾
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	return b.String()
+}
+
+func TestUnifiedDiffSingleChange(t *testing.T) {
+	got := unifiedDiff("notes.txt", "a\nb\nc\n", "a\nB\nc\n")
+	want := "--- a/notes.txt\n+++ b/notes.txt\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"
+	if got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	before := numberedLines(20)
+	after := strings.Replace(strings.Replace(before, "\n2\n", "\ntwo\n", 1), "\n18\n", "\neighteen\n", 1)
+
+	got := unifiedDiff("n.txt", before, after)
+	want := "--- a/n.txt\n+++ b/n.txt\n" +
+		"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+		"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n"
+	if got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffIdenticalContent(t *testing.T) {
+	if got := unifiedDiff("same.txt", "x\n", "x\n"); got != "" {
+		t.Errorf("diff of identical content = %q", got)
+	}
+}
+
+func TestStrReplaceReportsDiffAndCount(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithWorkspace(context.Background(), dir)
+	path := filepath.Join(dir, "config.ini")
+	writeFiles(t, dir, map[string]string{"config.ini": "debug = false\nname = app\nverbose = false\n"})
+
+	tests := []struct {
+		name         string
+		oldStr       string
+		newStr       string
+		replacements int
+		diff         []string
+	}{
+		{"single", "name = app", "name = demo", 1, []string{"-name = app", "+name = demo"}},
+		{"multiple", "false", "true", 2, []string{"-debug = false", "+debug = true", "-verbose = false", "+verbose = true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := runTool(t, ctx, NewStrReplaceEditor(),
+				fmt.Sprintf(`{"command": "str_replace", "path": "config.ini", "old_str": %q, "new_str": %q}`, tt.oldStr, tt.newStr))
+			data := output.Data.(map[string]interface{})
+			if data["replacements"] != tt.replacements {
+				t.Errorf("replacements = %v, want %d", data["replacements"], tt.replacements)
+			}
+			if !strings.Contains(output.Content, "+++ b/") {
+				t.Errorf("output has no diff header:\n%s", output.Content)
+			}
+			for _, line := range tt.diff {
+				if !strings.Contains(output.Content+"\n", "\n"+line+"\n") {
+					t.Errorf("diff missing %q:\n%s", line, output.Content)
+				}
+			}
+		})
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "debug = true\nname = demo\nverbose = true\n" {
+		t.Errorf("file content = %q", content)
+	}
+}
+
+// lcsLength 用动态规划计算最长公共子序列长度，作为最短编辑距离的参照
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+func TestDiffLinesIsMinimalAndReconstructs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+
+		var oldLines, newLines []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				oldLines = append(oldLines, op.text)
+			}
+			if op.kind != '-' {
+				newLines = append(newLines, op.text)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(oldLines, "\n") != strings.Join(a, "\n") || strings.Join(newLines, "\n") != strings.Join(b, "\n") {
+			t.Fatalf("diff of %q -> %q does not reconstruct the inputs", a, b)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diff of %q -> %q has %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesFallsBackPastEditCap(t *testing.T) {
+	a, b := make([]string, maxDiffEdits), make([]string, maxDiffEdits)
+	for i := range a {
+		a[i], b[i] = fmt.Sprintf("old %d", i), fmt.Sprintf("new %d", i)
+	}
+
+	ops := diffLines(a, b)
+	if len(ops) != len(a)+len(b) {
+		t.Fatalf("got %d ops, want %d", len(ops), len(a)+len(b))
+	}
+	for i, op := range ops {
+		want := byte('-')
+		if i >= len(a) {
+			want = '+'
+		}
+		if op.kind != want {
+			t.Fatalf("op %d kind %q, want %q", i, op.kind, want)
+		}
+	}
+}
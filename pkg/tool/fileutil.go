@@ -0,0 +1,119 @@
+package tool
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// renameFile 重命名文件，测试时可替换以模拟写入中断
+var renameFile = os.Rename
+
+// writeFileAtomic 原子写入文件：先写入同目录临时文件再重命名，
+// 写入中途失败时原文件保持不变。已存在的文件保留原有权限；
+// 路径为符号链接时写入链接指向的文件，链接本身保持不变
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// 任一步骤失败都清理临时文件
+	success := false
+	defer func() {
+		if !success {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("同步临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("关闭临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := renameFile(tmpPath, path); err != nil {
+		return fmt.Errorf("重命名临时文件失败: %w", err)
+	}
+
+	success = true
+	return nil
+}
+
+// utf8BOM UTF-8字节顺序标记
+const utf8BOM = "\xef\xbb\xbf"
+
+// binarySniffLen 二进制检测读取的字节数
+const binarySniffLen = 8000
+
+// textFormat 文本文件格式（BOM和换行符）
+type textFormat struct {
+	bom  bool
+	crlf bool
+	// mixed 同时包含CRLF和LF换行，统一换行后无法按原样写回
+	mixed bool
+}
+
+// decodeText 检测文件格式并返回去除BOM、统一为LF换行的内容
+// 包含NUL字节的二进制文件和非UTF-8文件返回错误，避免写回时改变原有字节
+func decodeText(data []byte) (textFormat, string, error) {
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return textFormat{}, "", fmt.Errorf("文件疑似二进制文件")
+	}
+	if !utf8.Valid(data) {
+		return textFormat{}, "", fmt.Errorf("文件不是有效的UTF-8编码")
+	}
+
+	var format textFormat
+	content := string(data)
+	if strings.HasPrefix(content, utf8BOM) {
+		format.bom = true
+		content = strings.TrimPrefix(content, utf8BOM)
+	}
+
+	crlfCount := strings.Count(content, "\r\n")
+	lfCount := strings.Count(content, "\n") - crlfCount
+	format.crlf = crlfCount > 0 && lfCount == 0
+	format.mixed = crlfCount > 0 && lfCount > 0
+
+	return format, normalizeNewlines(content), nil
+}
+
+// encode 按原文件格式还原BOM和换行符
+func (f textFormat) encode(content string) []byte {
+	content = normalizeNewlines(content)
+	if f.crlf {
+		content = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	if f.bom {
+		content = utf8BOM + content
+	}
+	return []byte(content)
+}
+
+// normalizeNewlines 将CRLF统一为LF
+func normalizeNewlines(content string) string {
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
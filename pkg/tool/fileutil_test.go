@@ -0,0 +1,183 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readFile 读取文件内容，失败时终止测试
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteFileAtomicKeepsOriginalOnInterruption(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.txt")
+	writeFiles(t, dir, map[string]string{"data.txt": "original"})
+
+	renameFile = func(string, string) error { return errors.New("disk full") }
+	defer func() { renameFile = os.Rename }()
+
+	if err := writeFileAtomic(path, []byte("partial"), 0644); err == nil {
+		t.Fatal("expected error when the final rename fails")
+	}
+	if got := readFile(t, path); got != "original" {
+		t.Errorf("content after interrupted write = %q, want original", got)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+func TestWriteFileAtomicPreservesPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("#!/bin/sh\necho hi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("mode = %v, want 0750", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileAtomicWritesThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	writeFiles(t, dir, map[string]string{"target.txt": "old"})
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link was replaced by a regular file")
+	}
+	if got := readFile(t, target); got != "new" {
+		t.Errorf("target content = %q, want new", got)
+	}
+}
+
+// editFile 在工作目录中写入文件后执行一次 str_replace，返回编辑后的原始字节和工具错误
+func editFile(t *testing.T, original []byte, oldStr, newStr string) ([]byte, error) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	arguments, _ := json.Marshal(map[string]string{
+		"command": "str_replace", "path": "file.txt", "old_str": oldStr, "new_str": newStr,
+	})
+	_, err := NewStrReplaceEditor().Execute(WithWorkspace(context.Background(), dir), string(arguments))
+	return []byte(readFile(t, path)), err
+}
+
+func TestStrReplacePreservesFileFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		original []byte
+		oldStr   string
+		newStr   string
+		want     []byte
+	}{
+		{"crlf", []byte("one\r\ntwo\r\nthree\r\n"), "two\nthree", "2\n3", []byte("one\r\n2\r\n3\r\n")},
+		{"bom", []byte(utf8BOM + "key = a\n"), "a", "b", []byte(utf8BOM + "key = b\n")},
+		{"bom and crlf", []byte(utf8BOM + "名称 = 旧\r\n"), "旧", "新", []byte(utf8BOM + "名称 = 新\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := editFile(t, tt.original, tt.oldStr, tt.newStr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrReplaceRejectsBinaryFiles(t *testing.T) {
+	original := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	got, err := editFile(t, original, "IHDR", "XXXX")
+	if err == nil || !strings.Contains(err.Error(), "二进制") {
+		t.Errorf("error = %v, want binary file rejection", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Error("binary file was modified")
+	}
+}
+
+func TestStrReplaceRejectsNonUTF8Files(t *testing.T) {
+	// GBK编码的“名称 = 旧”，不是有效的UTF-8
+	original := []byte("\xc3\xfb\xb3\xc6 = \xbe\xc9\n")
+	got, err := editFile(t, original, " = ", ": ")
+	if err == nil || !strings.Contains(err.Error(), "UTF-8") {
+		t.Errorf("error = %v, want non-UTF-8 rejection", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Errorf("file was modified: %q", got)
+	}
+}
+
+func TestStrReplaceRejectsMixedLineEndings(t *testing.T) {
+	original := []byte("one\r\ntwo\nthree\r\nfour\n")
+	got, err := editFile(t, original, "three", "3")
+	if err == nil || !strings.Contains(err.Error(), "换行符") {
+		t.Errorf("error = %v, want mixed line ending rejection", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Errorf("file was modified: %q", got)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"mixed.txt": string(original)})
+	output := runTool(t, WithWorkspace(context.Background(), dir), NewStrReplaceEditor(), `{"command": "view", "path": "mixed.txt"}`)
+	if data := output.Data.(map[string]interface{}); data["line_ending"] != "mixed" {
+		t.Errorf("line_ending = %v, want mixed", data["line_ending"])
+	}
+}
+
+func TestViewReportsLineEnding(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"crlf.txt": utf8BOM + "你好\r\n"})
+
+	output := runTool(t, WithWorkspace(context.Background(), dir), NewStrReplaceEditor(), `{"command": "view", "path": "crlf.txt"}`)
+	data := output.Data.(map[string]interface{})
+	if output.Content != "你好\n" || data["line_ending"] != "CRLF" || data["bom"] != true {
+		t.Errorf("view = %q %v", output.Content, data)
+	}
+}
+
+func TestViewReturnsBinaryFilesAsIs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"image.png": "\x89PNG\r\n\x1a\n\x00\x00"})
+
+	output := runTool(t, WithWorkspace(context.Background(), dir), NewStrReplaceEditor(), `{"command": "view", "path": "image.png"}`)
+	if output.IsError || output.Content != "\x89PNG\r\n\x1a\n\x00\x00" {
+		t.Errorf("view of binary file = %+v", output)
+	}
+}
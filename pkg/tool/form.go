@@ -0,0 +1,77 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// encodeForm 编码表单字段，包含文件时使用multipart，否则使用URL编码
+func encodeForm(ctx context.Context, fields, files map[string]interface{}) (io.Reader, string, error) {
+	if len(files) == 0 {
+		values := url.Values{}
+		for name, value := range fields {
+			values.Set(name, fmt.Sprintf("%v", value))
+		}
+		return bytes.NewBufferString(values.Encode()), "application/x-www-form-urlencoded", nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, name := range sortedKeys(fields) {
+		if err := writer.WriteField(name, fmt.Sprintf("%v", fields[name])); err != nil {
+			return nil, "", fmt.Errorf("写入表单字段失败: %w", err)
+		}
+	}
+
+	for _, name := range sortedKeys(files) {
+		path, ok := files[name].(string)
+		if !ok || path == "" {
+			return nil, "", fmt.Errorf("表单文件字段 %s 需要文件路径", name)
+		}
+		// 只允许上传工作目录中的文件，避免把主机上的任意文件发送出去
+		if filepath.IsAbs(path) {
+			return nil, "", invalidArguments("表单文件字段 %s 必须是工作目录中的相对路径: %s", name, path)
+		}
+		resolved, err := resolveWorkspacePath(ctx, path)
+		if err != nil {
+			return nil, "", invalidArguments("表单文件字段 %s: %w", name, err)
+		}
+		if err := checkProtectedPath(resolved); err != nil {
+			return nil, "", err
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, "", fmt.Errorf("读取表单文件失败: %w", err)
+		}
+		part, err := writer.CreateFormFile(name, filepath.Base(path))
+		if err != nil {
+			return nil, "", fmt.Errorf("写入表单文件失败: %w", err)
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", fmt.Errorf("写入表单文件失败: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("编码表单失败: %w", err)
+	}
+	return &body, writer.FormDataContentType(), nil
+}
+
+// sortedKeys 返回按字母排序的键，保证编码结果稳定
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
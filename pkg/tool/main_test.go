@@ -0,0 +1,21 @@
+package tool
+
+import (
+	"os"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// baseTestConfig 测试默认使用的配置，各测试通过 testconfig.Use 替换
+const baseTestConfig = `[llm.default]
+model = "gpt-4o"
+api_key = "sk-test"
+api_type = "mock"
+`
+
+// TestMain 在临时目录中写入配置并切换到该目录，配置单例从这里读取
+func TestMain(m *testing.M) {
+	os.Exit(testconfig.Run(m, "gomanus-tool-test-", baseTestConfig, func() error { return config.GetConfig().Reload() }))
+}
@@ -0,0 +1,303 @@
+package tool
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdRule        = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdUnordered   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOrdered     = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdQuote       = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	mdFence       = regexp.MustCompile("^\\s*(```|~~~)\\s*(\\S*)")
+	mdInlineCode  = regexp.MustCompile("`([^`]+)`")
+	mdImage       = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold        = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	mdItalic      = regexp.MustCompile(`(^|[^*\w])[*_]([^*_]+)[*_]`)
+	mdPlaceholder = regexp.MustCompile("\x00(\\d+)\x00")
+
+	htmlTag     = regexp.MustCompile(`(?s)<!--.*?-->|<(/?)([a-zA-Z][a-zA-Z0-9]*)([^>]*?)(/?)>`)
+	htmlAttr    = regexp.MustCompile(`([a-zA-Z-]+)\s*=\s*("([^"]*)"|'([^']*)'|([^\s>]+))`)
+	htmlSkipped = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	whitespace  = regexp.MustCompile(`\s+`)
+)
+
+// markdownToHTML Markdown转HTML，支持标题、段落、列表、引用、代码块、分隔线和常用行内语法
+func markdownToHTML(text string) (string, error) {
+	var out strings.Builder
+	var paragraph []string
+	list := ""
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			fmt.Fprintf(&out, "<p>%s</p>\n", markdownInline(strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			fmt.Fprintf(&out, "</%s>\n", list)
+			list = ""
+		}
+	}
+	openList := func(tag string) {
+		if list != tag {
+			closeList()
+			fmt.Fprintf(&out, "<%s>\n", tag)
+			list = tag
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if match := mdFence.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), match[1]); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if match[2] != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(match[2]))
+			}
+			fmt.Fprintf(&out, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(code, "\n")))
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			closeList()
+			continue
+		}
+
+		if match := mdHeading.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			closeList()
+			level := len(match[1])
+			fmt.Fprintf(&out, "<h%d>%s</h%d>\n", level, markdownInline(match[2]), level)
+			continue
+		}
+
+		if mdRule.MatchString(line) {
+			flushParagraph()
+			closeList()
+			out.WriteString("<hr>\n")
+			continue
+		}
+
+		if match := mdUnordered.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			openList("ul")
+			fmt.Fprintf(&out, "<li>%s</li>\n", markdownInline(match[1]))
+			continue
+		}
+
+		if match := mdOrdered.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			openList("ol")
+			fmt.Fprintf(&out, "<li>%s</li>\n", markdownInline(match[1]))
+			continue
+		}
+
+		if match := mdQuote.FindStringSubmatch(line); match != nil {
+			flushParagraph()
+			closeList()
+			quote := []string{match[1]}
+			for i+1 < len(lines) {
+				next := mdQuote.FindStringSubmatch(lines[i+1])
+				if next == nil {
+					break
+				}
+				quote = append(quote, next[1])
+				i++
+			}
+			inner, _ := markdownToHTML(strings.Join(quote, "\n"))
+			fmt.Fprintf(&out, "<blockquote>\n%s</blockquote>\n", inner)
+			continue
+		}
+
+		closeList()
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+	flushParagraph()
+	closeList()
+
+	return out.String(), nil
+}
+
+// markdownInline 转换行内语法，行内代码先替换为占位符，避免其内容被继续转换
+func markdownInline(text string) string {
+	var codes []string
+	text = mdInlineCode.ReplaceAllStringFunc(text, func(match string) string {
+		codes = append(codes, mdInlineCode.FindStringSubmatch(match)[1])
+		return fmt.Sprintf("\x00%d\x00", len(codes)-1)
+	})
+
+	text = html.EscapeString(text)
+	text = mdImage.ReplaceAllString(text, `<img src="$2" alt="$1">`)
+	text = mdLink.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = mdBold.ReplaceAllString(text, `<strong>$2</strong>`)
+	text = mdItalic.ReplaceAllString(text, `$1<em>$2</em>`)
+
+	return mdPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		var index int
+		fmt.Sscanf(mdPlaceholder.FindStringSubmatch(match)[1], "%d", &index)
+		return "<code>" + html.EscapeString(codes[index]) + "</code>"
+	})
+}
+
+// htmlToMarkdown HTML转Markdown，转换常用标签，其余标签只保留文本
+// 脚本、样式和head中的内容被丢弃
+func htmlToMarkdown(text string) (string, error) {
+	text = htmlSkipped.ReplaceAllString(text, "")
+
+	var out strings.Builder
+	var lists []int // 列表栈，元素为有序列表的下一个序号，无序列表为-1
+	var href string
+	pre := false
+
+	last := 0
+	for _, match := range htmlTag.FindAllStringSubmatchIndex(text, -1) {
+		writeHTMLText(&out, text[last:match[0]], pre)
+		last = match[1]
+
+		if match[4] < 0 {
+			continue // 注释
+		}
+		closing := match[3] > match[2]
+		tag := strings.ToLower(text[match[4]:match[5]])
+		attrs := htmlAttributes(text[match[6]:match[7]])
+
+		switch tag {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			if closing {
+				out.WriteString("\n\n")
+			} else {
+				out.WriteString("\n\n" + strings.Repeat("#", int(tag[1]-'0')) + " ")
+			}
+		case "p", "div", "section", "article", "table":
+			out.WriteString("\n\n")
+		case "tr":
+			if closing {
+				out.WriteString("\n")
+			}
+		case "td", "th":
+			if !closing {
+				out.WriteString(" | ")
+			}
+		case "br":
+			out.WriteString("  \n")
+		case "hr":
+			out.WriteString("\n\n---\n\n")
+		case "strong", "b":
+			out.WriteString("**")
+		case "em", "i":
+			out.WriteString("*")
+		case "code":
+			if !pre {
+				out.WriteString("`")
+			}
+		case "pre":
+			pre = !closing
+			if closing {
+				out.WriteString("\n```\n\n")
+			} else {
+				out.WriteString("\n\n```\n")
+			}
+		case "blockquote":
+			if !closing {
+				out.WriteString("\n\n> ")
+			} else {
+				out.WriteString("\n\n")
+			}
+		case "a":
+			if closing {
+				if href != "" {
+					out.WriteString("](" + href + ")")
+				}
+				href = ""
+			} else {
+				href = attrs["href"]
+				if href != "" {
+					out.WriteString("[")
+				}
+			}
+		case "img":
+			fmt.Fprintf(&out, "![%s](%s)", attrs["alt"], attrs["src"])
+		case "ul", "ol":
+			if closing {
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				if len(lists) == 0 {
+					out.WriteString("\n\n")
+				}
+			} else {
+				next := -1
+				if tag == "ol" {
+					next = 1
+				}
+				if len(lists) == 0 {
+					out.WriteString("\n")
+				}
+				lists = append(lists, next)
+			}
+		case "li":
+			if closing || len(lists) == 0 {
+				continue
+			}
+			depth := len(lists) - 1
+			out.WriteString("\n" + strings.Repeat("  ", depth))
+			if next := lists[depth]; next > 0 {
+				fmt.Fprintf(&out, "%d. ", next)
+				lists[depth]++
+			} else {
+				out.WriteString("- ")
+			}
+		}
+	}
+	writeHTMLText(&out, text[last:], pre)
+
+	result := out.String()
+	lines := strings.Split(result, "\n")
+	for i, line := range lines {
+		if !strings.HasSuffix(line, "  ") {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+	}
+	result = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(result) + "\n", nil
+}
+
+// writeHTMLText 写入标签之间的文本，代码块外将连续空白合并为一个空格
+func writeHTMLText(out *strings.Builder, text string, pre bool) {
+	text = html.UnescapeString(text)
+	if pre {
+		out.WriteString(text)
+		return
+	}
+	text = whitespace.ReplaceAllString(text, " ")
+	if current := out.String(); current == "" || strings.HasSuffix(current, " ") || strings.HasSuffix(current, "\n") {
+		text = strings.TrimLeft(text, " ")
+	}
+	out.WriteString(text)
+}
+
+// htmlAttributes 解析标签属性
+func htmlAttributes(text string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range htmlAttr.FindAllStringSubmatch(text, -1) {
+		value := match[3] + match[4] + match[5]
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(value)
+	}
+	return attrs
+}
@@ -0,0 +1,198 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// ErrBlockedByPolicy 目标地址被网络访问策略拒绝
+var ErrBlockedByPolicy = errors.New("blocked by policy")
+
+// maxRedirects 允许跟随的最大重定向次数
+const maxRedirects = 10
+
+// networkSettings 获取网络访问策略配置，未配置时返回零值
+func networkSettings() config.NetworkSettings {
+	if settings := config.GetConfig().GetToolsSettings(); settings != nil && settings.Network != nil {
+		return *settings.Network
+	}
+	return config.NetworkSettings{}
+}
+
+// checkURL 检查URL是否符合网络访问策略
+func checkURL(rawURL string) error {
+	return checkURLWithSettings(rawURL, networkSettings())
+}
+
+// checkURLWithSettings 按给定策略检查URL
+func checkURLWithSettings(rawURL string, settings config.NetworkSettings) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("无效的URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: 不支持的协议 %q", ErrBlockedByPolicy, parsed.Scheme)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: URL缺少主机名", ErrBlockedByPolicy)
+	}
+
+	if matchDomain(host, settings.DeniedDomains) {
+		return fmt.Errorf("%w: 域名 %s 在禁止列表中", ErrBlockedByPolicy, host)
+	}
+	if len(settings.AllowedDomains) > 0 && !matchDomain(host, settings.AllowedDomains) {
+		return fmt.Errorf("%w: 域名 %s 不在允许列表中", ErrBlockedByPolicy, host)
+	}
+
+	// IP字面量直接检查，域名在连接时检查解析结果
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(ip, settings)
+	}
+	if !settings.AllowPrivate && isLocalHostname(host) {
+		return fmt.Errorf("%w: 禁止访问内部地址 %s", ErrBlockedByPolicy, host)
+	}
+	return nil
+}
+
+// matchDomain 检查主机名是否匹配域名列表，域名同时匹配其子域名
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "*."))
+		if domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalHostname 检查主机名是否指向本机
+func isLocalHostname(host string) bool {
+	return host == "localhost" || strings.HasSuffix(host, ".localhost")
+}
+
+// reservedNetworks 除标准库分类外需要拦截的保留网段
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 本网络
+	"100.64.0.0/10", // 运营商级NAT
+	"192.0.0.0/24",  // IETF协议分配
+	"198.18.0.0/15", // 基准测试
+	"fd00:ec2::/32", // 云厂商IPv6元数据服务
+)
+
+// mustParseCIDRs 解析网段列表，格式错误时panic
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isPrivateIP 检查IP是否为回环、私有、链路本地（含元数据服务）或其他保留地址
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipResolver 域名解析接口，便于替换
+type ipResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// resolver 网络工具使用的域名解析器
+var resolver ipResolver = net.DefaultResolver
+
+// checkIP 检查解析后的IP是否允许连接
+func checkIP(ip net.IP, settings config.NetworkSettings) error {
+	if settings.AllowPrivate || !isPrivateIP(ip) {
+		return nil
+	}
+	for _, cidr := range settings.AllowedNetworks {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err == nil && network.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: 禁止连接内部地址 %s", ErrBlockedByPolicy, ip)
+}
+
+// safeDialContext 解析目标地址并拒绝连接内部地址，直接连接已检查的IP以避免DNS重绑定
+func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("无法解析主机: %s", host)
+	}
+
+	settings := networkSettings()
+	for _, ipAddr := range addrs {
+		if err := checkIP(ipAddr.IP, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var lastErr error
+	for _, ipAddr := range addrs {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ipAddr.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// newSafeHTTPClient 创建执行网络访问策略的HTTP客户端，重定向和实际连接均受检查
+func newSafeHTTPClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 代理会绕过连接地址检查，因此不使用环境代理
+	transport.Proxy = nil
+	transport.DialContext = safeDialContext
+
+	return &http.Client{
+		Timeout:       timeout,
+		Transport:     transport,
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// checkRedirect 对每次重定向的目标重新执行访问策略检查
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("重定向次数过多: %d", len(via))
+	}
+	return checkURL(req.URL.String())
+}
@@ -0,0 +1,176 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+func TestCheckURLWithSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		settings config.NetworkSettings
+		blocked  bool
+	}{
+		{"public host", "https://example.com/page", config.NetworkSettings{}, false},
+		{"allowed domain", "https://docs.example.com", config.NetworkSettings{AllowedDomains: []string{"example.com"}}, false},
+		{"outside allow list", "https://example.org", config.NetworkSettings{AllowedDomains: []string{"example.com"}}, true},
+		{"denied domain", "https://example.com", config.NetworkSettings{DeniedDomains: []string{"example.com"}}, true},
+		{"denied subdomain", "https://api.Example.com.", config.NetworkSettings{DeniedDomains: []string{"*.example.com"}}, true},
+		{"deny wins over allow", "https://bad.example.com", config.NetworkSettings{AllowedDomains: []string{"example.com"}, DeniedDomains: []string{"bad.example.com"}}, true},
+		{"unsupported scheme", "file:///etc/passwd", config.NetworkSettings{}, true},
+		{"localhost", "http://localhost:8080", config.NetworkSettings{}, true},
+		{"loopback literal", "http://127.0.0.1/", config.NetworkSettings{}, true},
+		{"ipv6 loopback", "http://[::1]/", config.NetworkSettings{}, true},
+		{"metadata service", "http://169.254.169.254/latest/meta-data", config.NetworkSettings{}, true},
+		{"private allowed", "http://127.0.0.1/", config.NetworkSettings{AllowPrivate: true}, false},
+		{"allowed network", "http://10.1.2.3/", config.NetworkSettings{AllowedNetworks: []string{"10.0.0.0/8"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkURLWithSettings(tt.url, tt.settings)
+			if blocked := errors.Is(err, ErrBlockedByPolicy); blocked != tt.blocked {
+				t.Errorf("checkURLWithSettings(%s) = %v, want blocked %v", tt.url, err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestSimpleBrowserBlocksLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached the loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewSimpleBrowser().Execute(context.Background(), `{"url": "`+server.URL+`"}`)
+	if !errors.Is(err, ErrBlockedByPolicy) {
+		t.Errorf("err = %v, want ErrBlockedByPolicy", err)
+	}
+}
+
+func TestSimpleBrowserChecksRedirectTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		settings string
+	}{
+		{"denied domain", "http://denied.example/secret", "allow_private = true\ndenied_domains = [\"denied.example\"]"},
+		{"loopback name", "http://localhost/admin", "allowed_networks = [\"127.0.0.1/32\"]"},
+		{"metadata service", "http://169.254.169.254/latest/meta-data", "allowed_networks = [\"127.0.0.1/32\"]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testconfig.Use(t, baseTestConfig+"\n[tools.network]\n"+tt.settings+"\n")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, tt.location, http.StatusFound)
+			}))
+			defer server.Close()
+
+			_, err := NewSimpleBrowser().Execute(context.Background(), `{"url": "`+server.URL+`"}`)
+			if !errors.Is(err, ErrBlockedByPolicy) {
+				t.Errorf("redirect to %s: err = %v, want ErrBlockedByPolicy", tt.location, err)
+			}
+		})
+	}
+}
+
+func TestSimpleBrowserFollowsAllowedRedirect(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+"\n[tools.network]\nallow_private = true\n")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("moved here"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	output := runTool(t, context.Background(), NewSimpleBrowser(), `{"url": "`+server.URL+`/old"}`)
+	if output.Content != "moved here" {
+		t.Errorf("content = %q", output.Content)
+	}
+}
+
+// stubResolver 按主机名返回固定地址的解析器
+type stubResolver map[string][]string
+
+// LookupIPAddr 实现 ipResolver
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	var addrs []net.IPAddr
+	for _, addr := range r[host] {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(addr)})
+	}
+	return addrs, nil
+}
+
+// useResolver 替换网络工具的域名解析器，测试结束后恢复
+func useResolver(t *testing.T, stub stubResolver) {
+	t.Helper()
+	original := resolver
+	resolver = stub
+	t.Cleanup(func() { resolver = original })
+}
+
+func TestReservedRangesAreBlocked(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1", "::1", // 回环
+		"10.1.2.3", "172.16.0.1", "192.168.1.1", "fd12::1", // 私有
+		"169.254.169.254", "fe80::1", // 链路本地、元数据服务
+		"0.0.0.0", "::", "0.1.2.3", // 未指定、本网络
+		"100.64.0.1",           // 运营商级NAT
+		"192.0.0.8",            // IETF协议分配
+		"198.18.0.1",           // 基准测试
+		"224.0.0.1", "ff02::1", // 组播
+		"fd00:ec2::254", // 云厂商IPv6元数据服务
+	}
+	for _, addr := range blocked {
+		if err := checkIP(net.ParseIP(addr), config.NetworkSettings{}); !errors.Is(err, ErrBlockedByPolicy) {
+			t.Errorf("checkIP(%s) = %v, want ErrBlockedByPolicy", addr, err)
+		}
+	}
+
+	for _, addr := range []string{"93.184.216.34", "8.8.8.8", "2606:4700::1111"} {
+		if err := checkIP(net.ParseIP(addr), config.NetworkSettings{}); err != nil {
+			t.Errorf("checkIP(%s) = %v, want allowed", addr, err)
+		}
+	}
+}
+
+func TestDialChecksResolvedAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port := serverURL.Port()
+
+	// 公开域名解析到内部地址（DNS重绑定）时拒绝连接
+	useResolver(t, stubResolver{
+		"rebind.example": {"127.0.0.1"},
+		"mixed.example":  {"93.184.216.34", "10.0.0.1"},
+	})
+	for _, host := range []string{"rebind.example", "mixed.example"} {
+		_, err := NewSimpleBrowser().Execute(context.Background(), `{"url": "http://`+host+`:`+port+`"}`)
+		if !errors.Is(err, ErrBlockedByPolicy) {
+			t.Errorf("%s: err = %v, want ErrBlockedByPolicy", host, err)
+		}
+	}
+
+	// 显式允许的网段可以连接
+	testconfig.Use(t, baseTestConfig+"\n[tools.network]\nallowed_networks = [\"127.0.0.0/8\"]\n")
+	output := runTool(t, context.Background(), NewSimpleBrowser(), `{"url": "http://rebind.example:`+port+`"}`)
+	if output.Content != "internal" {
+		t.Errorf("content = %q", output.Content)
+	}
+}
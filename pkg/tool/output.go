@@ -0,0 +1,67 @@
+package tool
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// DefaultMaxOutputBytes 单个工具默认收集的最大原始输出字节数
+const DefaultMaxOutputBytes = 1 << 20
+
+// maxOutputBytes 获取配置的工具原始输出上限
+func maxOutputBytes() int {
+	if settings := config.GetConfig().GetToolsSettings(); settings != nil && settings.MaxOutputBytes > 0 {
+		return settings.MaxOutputBytes
+	}
+	return DefaultMaxOutputBytes
+}
+
+// limitedBuffer 有上限的并发安全输出缓冲区，超出部分丢弃并在结果中标记
+type limitedBuffer struct {
+	data    []byte
+	limit   int
+	dropped int
+	mu      sync.Mutex
+}
+
+// newLimitedBuffer 创建有上限的输出缓冲区
+func newLimitedBuffer(limit int) *limitedBuffer {
+	return &limitedBuffer{limit: limit}
+}
+
+// Write 实现io.Writer，超出上限的数据被丢弃但仍报告写入成功，避免阻塞子进程
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remaining := b.limit - len(b.data)
+	if remaining <= 0 {
+		b.dropped += len(p)
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		b.data = append(b.data, p[:remaining]...)
+		b.dropped += len(p) - remaining
+		return len(p), nil
+	}
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
+
+// String 返回收集的输出，发生截断时附加标记
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dropped == 0 {
+		return string(b.data)
+	}
+	return string(b.data) + truncationMarker(b.dropped)
+}
+
+// truncationMarker 截断标记
+func truncationMarker(dropped int) string {
+	return fmt.Sprintf("\n...[输出超过上限，已截断 %d 字节]", dropped)
+}
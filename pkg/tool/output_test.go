@@ -0,0 +1,111 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// smallOutputCap 测试使用的输出上限配置
+const smallOutputCap = baseTestConfig + `
+[tools]
+max_output_bytes = 1000
+
+[tools.network]
+allow_private = true
+`
+
+func TestLimitedBufferDropsOutputPastTheCap(t *testing.T) {
+	buffer := newLimitedBuffer(10)
+	for i := 0; i < 5; i++ {
+		if n, err := buffer.Write([]byte("abcdef")); n != 6 || err != nil {
+			t.Fatalf("Write = %d, %v; writes must always report success", n, err)
+		}
+	}
+
+	if got, want := buffer.String(), "abcdefabcd"+truncationMarker(20); got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	if len(buffer.data) != 10 {
+		t.Errorf("buffer holds %d bytes, want 10", len(buffer.data))
+	}
+}
+
+func TestPythonExecuteCapsOutput(t *testing.T) {
+	requirePython(t)
+	testconfig.Use(t, smallOutputCap)
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+
+	forwarded := 0
+	python := NewPythonExecute()
+	python.OnOutput = func(stream, line string) { forwarded++ }
+
+	// 生成器输出约 6MB
+	output := runTool(t, ctx, python, `{"code": "for i in range(200000):\n    print('line %06d ' % i + 'x' * 20)"}`)
+	if !strings.HasPrefix(output.Content, "line 000000 ") || !strings.Contains(output.Content, "...[输出超过上限，已截断") {
+		t.Errorf("output not truncated: %q", output.Content[:min(len(output.Content), 200)])
+	}
+	if len(output.Content) > 1000+100 {
+		t.Errorf("output is %d bytes, want about the 1000-byte cap", len(output.Content))
+	}
+	// 约 30 行达到上限，之后只通知一次
+	if forwarded > 40 {
+		t.Errorf("forwarded %d lines past the cap", forwarded)
+	}
+}
+
+func TestSimpleBrowserCapsResponse(t *testing.T) {
+	testconfig.Use(t, smallOutputCap)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("y", 100000)))
+	}))
+	defer server.Close()
+
+	output := runTool(t, context.Background(), NewSimpleBrowser(), `{"url": "`+server.URL+`"}`)
+	if !strings.HasPrefix(output.Content, strings.Repeat("y", 1000)+"\n...[响应超过上限") {
+		t.Errorf("response not capped at 1000 bytes: %d bytes", len(output.Content))
+	}
+}
+
+func TestTestOutputParserCountsEveryTestPastTheCap(t *testing.T) {
+	plain := newLimitedBuffer(100)
+	parser := newTestOutputParser(plain)
+
+	// 按任意边界分块写入，与管道读取一致
+	var stream strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&stream, `{"Action":"run","Package":"p","Test":"TestPass%d"}`+"\n", i)
+		fmt.Fprintf(&stream, `{"Action":"output","Package":"p","Test":"TestPass%d","Output":"%s\n"}`+"\n", i, strings.Repeat("o", 200))
+		fmt.Fprintf(&stream, `{"Action":"pass","Package":"p","Test":"TestPass%d"}`+"\n", i)
+	}
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&stream, `{"Action":"output","Package":"p","Test":"TestFail","Output":"%s\n"}`+"\n", strings.Repeat("f", 1000))
+	}
+	stream.WriteString(`{"Action":"fail","Package":"p","Test":"TestFail"}` + "\n")
+	stream.WriteString(`{"Action":"output","Package":"p","Output":"FAIL\n"}`)
+	data := stream.String()
+	for len(data) > 0 {
+		n := min(len(data), 777)
+		parser.Write([]byte(data[:n]))
+		data = data[n:]
+	}
+	parser.Flush()
+
+	output := parser.Result(plain.String(), false)
+	if !strings.HasPrefix(output.Content, "通过 500 个测试，失败 1 个") {
+		t.Errorf("content = %q", output.Content)
+	}
+	failed := output.Data.(map[string]interface{})["failed"].([]map[string]interface{})
+	snippet := failed[0]["output"].(string)
+	if !strings.HasPrefix(snippet, "ffff") || len(snippet) > maxTestOutputBytes+100 {
+		t.Errorf("failure snippet is %d bytes, want it capped at %d", len(snippet), maxTestOutputBytes)
+	}
+	if len(parser.testOutput) != 0 {
+		t.Errorf("output of finished tests is still held: %d entries", len(parser.testOutput))
+	}
+}
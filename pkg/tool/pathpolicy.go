@@ -0,0 +1,119 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// ErrProtectedPath 目标路径受保护，工具禁止访问
+var ErrProtectedPath = errors.New("protected path")
+
+// protectedPaths 获取受保护路径：配置目录、.env 文件、当前运行的程序和 [tools].protected_paths
+// 与工作目录限制无关，始终生效
+func protectedPaths() []string {
+	paths := []string{config.DefaultEnvFile}
+	if file := config.GetConfig().ConfigFile(); file != "" {
+		// 配置文件直接位于项目根目录时只保护文件本身，否则工作空间也会被包含在内
+		dir := filepath.Dir(file)
+		if isWithin(normalizePath(config.GetConfig().GetWorkspaceRoot()), normalizePath(dir)) {
+			paths = append(paths, file)
+		} else {
+			paths = append(paths, dir)
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		paths = append(paths, exe)
+	}
+	if settings := config.GetConfig().GetToolsSettings(); settings != nil {
+		paths = append(paths, settings.ProtectedPaths...)
+	}
+
+	normalized := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path = normalizePath(expandHome(path)); path != "" {
+			normalized = append(normalized, path)
+		}
+	}
+	return normalized
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// normalizePath 转换为绝对路径并解析已存在部分的符号链接，避免通过链接绕过保护
+func normalizePath(path string) string {
+	if strings.TrimSpace(path) == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+
+	// 从完整路径向上查找第一个存在的祖先并解析其符号链接
+	rest := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// checkProtectedPath 检查路径是否为受保护路径或位于其下
+func checkProtectedPath(path string) error {
+	target := normalizePath(path)
+	for _, protected := range protectedPaths() {
+		if isWithin(target, protected) {
+			return fmt.Errorf("%w: %s 受保护，禁止工具访问", ErrProtectedPath, path)
+		}
+	}
+	return nil
+}
+
+// checkProtectedReferences 检查代码中是否引用受保护路径
+// 无法覆盖动态拼接的路径，仅作为执行工具的基本防护
+func checkProtectedReferences(code string) error {
+	for _, protected := range protectedPaths() {
+		if strings.Contains(code, protected) {
+			return fmt.Errorf("%w: 代码引用了受保护路径 %s", ErrProtectedPath, protected)
+		}
+	}
+	return nil
+}
+
+// resolveUnprotectedPath 解析路径并拒绝受保护路径
+func resolveUnprotectedPath(ctx context.Context, path string) (string, error) {
+	resolved := resolvePath(ctx, path)
+	if err := checkProtectedPath(resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// isWithin 检查 target 是否等于 root 或位于 root 之下
+func isWithin(target, root string) bool {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// createArguments 创建文件的编辑器参数
+func createArguments(path, text string) string {
+	arguments, _ := json.Marshal(map[string]string{"command": "create", "path": path, "file_text": text})
+	return string(arguments)
+}
+
+func TestEditorCannotWriteConfig(t *testing.T) {
+	configFile, _ := filepath.Abs(config.GetConfig().ConfigFile())
+	before, _ := os.ReadFile(configFile)
+	workspace := t.TempDir()
+	ctx := WithWorkspace(context.Background(), workspace)
+
+	// 工作目录内指向配置目录的符号链接
+	if err := os.Symlink(filepath.Dir(configFile), filepath.Join(workspace, "cfg")); err != nil {
+		t.Fatal(err)
+	}
+	relative, _ := filepath.Rel(workspace, configFile)
+
+	for _, path := range []string{
+		configFile, // 绝对路径不受工作目录限制
+		relative,   // 相对路径跳出工作目录
+		filepath.Join(workspace, "cfg", "config.toml"),      // 符号链接
+		filepath.Join(filepath.Dir(configFile), "new.toml"), // 配置目录中的新文件
+	} {
+		_, err := NewStrReplaceEditor().Execute(ctx, createArguments(path, "[llm.default]\napi_key = \"stolen\"\n"))
+		if !errors.Is(err, ErrProtectedPath) {
+			t.Errorf("create %s: err = %v, want ErrProtectedPath", path, err)
+		}
+	}
+
+	if after, _ := os.ReadFile(configFile); string(after) != string(before) {
+		t.Errorf("config file modified:\n%s", after)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(configFile), "new.toml")); !os.IsNotExist(err) {
+		t.Errorf("file created in the config directory: %v", err)
+	}
+}
+
+func TestConfiguredProtectedPaths(t *testing.T) {
+	secrets := t.TempDir()
+	testconfig.Use(t, baseTestConfig+"\n[tools]\nprotected_paths = [\""+secrets+"\"]\n")
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+
+	if _, err := NewStrReplaceEditor().Execute(ctx, createArguments(filepath.Join(secrets, "key.pem"), "x")); !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("write to configured protected path: err = %v", err)
+	}
+	if _, err := NewStrReplaceEditor().Execute(ctx, createArguments(".env", "OPENAI_API_KEY=x")); err != nil {
+		t.Errorf("a workspace .env is not the protected one: %v", err)
+	}
+	output := runTool(t, ctx, NewStrReplaceEditor(), createArguments("notes.txt", "ok"))
+	if output.IsError {
+		t.Errorf("write inside the workspace failed: %s", output.Content)
+	}
+}
+
+func TestPythonCannotReferenceConfig(t *testing.T) {
+	configFile, _ := filepath.Abs(config.GetConfig().ConfigFile())
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	code, _ := json.Marshal(map[string]string{"code": "open(" + `"` + configFile + `"` + ", 'w').write('')"})
+
+	if _, err := NewPythonExecute().Execute(ctx, string(code)); !errors.Is(err, ErrProtectedPath) {
+		t.Errorf("err = %v, want ErrProtectedPath", err)
+	}
+}
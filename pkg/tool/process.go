@@ -0,0 +1,227 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// ErrProcessNotFound 进程不是由工具启动的，或已从登记表中移除
+var ErrProcessNotFound = errors.New("process not found")
+
+// ProcessInfo 登记的后台进程信息
+type ProcessInfo struct {
+	PID       int       `json:"pid"`
+	Tool      string    `json:"tool"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+	Running   bool      `json:"running"`
+	ExitCode  int       `json:"exit_code"`
+	LogFile   string    `json:"log_file,omitempty"`
+}
+
+// trackedProcess 登记表中的进程，done 在进程退出后关闭
+type trackedProcess struct {
+	info ProcessInfo
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// ProcessRegistry 工具启动的后台进程登记表
+// 智能体清理时结束所有仍在运行的进程，避免运行结束后留下孤儿进程；进程自己再启动的子进程不在登记范围内
+type ProcessRegistry struct {
+	processes map[int]*trackedProcess
+	mu        sync.Mutex
+}
+
+// NewProcessRegistry 创建后台进程登记表
+func NewProcessRegistry() *ProcessRegistry {
+	return &ProcessRegistry{processes: make(map[int]*trackedProcess)}
+}
+
+// Start 启动命令并登记，logFile 为进程输出写入的文件（可为空）
+func (r *ProcessRegistry) Start(toolName string, cmd *exec.Cmd, logFile string) (ProcessInfo, error) {
+	if err := cmd.Start(); err != nil {
+		return ProcessInfo{}, fmt.Errorf("启动进程失败: %w", err)
+	}
+
+	process := &trackedProcess{
+		info: ProcessInfo{
+			PID:       cmd.Process.Pid,
+			Tool:      toolName,
+			Command:   strings.Join(cmd.Args, " "),
+			StartedAt: time.Now(),
+			Running:   true,
+			LogFile:   logFile,
+		},
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	r.processes[process.info.PID] = process
+	r.mu.Unlock()
+
+	go func() {
+		cmd.Wait()
+		r.mu.Lock()
+		process.info.Running = false
+		process.info.ExitCode = cmd.ProcessState.ExitCode()
+		r.mu.Unlock()
+		close(process.done)
+	}()
+
+	logger.Info("后台进程已启动",
+		zap.String("tool", toolName),
+		zap.Int("pid", process.info.PID),
+		zap.String("command", process.info.Command))
+	return process.info, nil
+}
+
+// List 列出登记的进程，按启动时间排序
+func (r *ProcessRegistry) List() []ProcessInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	infos := make([]ProcessInfo, 0, len(r.processes))
+	for _, process := range r.processes {
+		infos = append(infos, process.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartedAt.Before(infos[j].StartedAt) })
+	return infos
+}
+
+// Kill 结束登记的进程并等待其退出，已退出的进程直接返回
+func (r *ProcessRegistry) Kill(pid int) (ProcessInfo, error) {
+	r.mu.Lock()
+	process, ok := r.processes[pid]
+	r.mu.Unlock()
+	if !ok {
+		return ProcessInfo{}, fmt.Errorf("%w: %d", ErrProcessNotFound, pid)
+	}
+
+	select {
+	case <-process.done:
+	default:
+		if err := process.cmd.Process.Kill(); err != nil {
+			// 进程可能恰好在此时退出
+			select {
+			case <-process.done:
+			default:
+				return ProcessInfo{}, fmt.Errorf("结束进程 %d 失败: %w", pid, err)
+			}
+		}
+		<-process.done
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return process.info, nil
+}
+
+// Cleanup 结束所有仍在运行的进程，nil登记表不做任何事
+func (r *ProcessRegistry) Cleanup() {
+	if r == nil {
+		return
+	}
+	for _, info := range r.List() {
+		if !info.Running {
+			continue
+		}
+		if _, err := r.Kill(info.PID); err != nil {
+			logger.Error("清理后台进程失败", zap.Int("pid", info.PID), zap.Error(err))
+			continue
+		}
+		logger.Info("已清理后台进程", zap.Int("pid", info.PID), zap.String("command", info.Command))
+	}
+}
+
+// ListProcesses 列出工具启动的后台进程
+type ListProcesses struct {
+	BaseTool
+	Processes *ProcessRegistry
+}
+
+// NewListProcesses 创建后台进程列表工具
+func NewListProcesses(processes *ProcessRegistry) *ListProcesses {
+	return &ListProcesses{
+		BaseTool: BaseTool{
+			Name:        "ListProcesses",
+			Description: "列出本次运行中由工具在后台启动的进程（如 PythonExecute 的后台脚本），包括进程ID、命令、是否仍在运行和输出日志文件",
+			Parameters:  map[string]interface{}{},
+		},
+		Processes: processes,
+	}
+}
+
+// Execute 列出后台进程
+func (l *ListProcesses) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	processes := l.Processes.List()
+	if len(processes) == 0 {
+		return schema.NewToolOutput("没有后台进程", nil), nil
+	}
+
+	running := 0
+	for _, process := range processes {
+		if process.Running {
+			running++
+		}
+	}
+	return schema.NewToolOutput(fmt.Sprintf("共 %d 个后台进程，%d 个正在运行", len(processes), running), processes), nil
+}
+
+// KillProcess 结束工具启动的后台进程
+type KillProcess struct {
+	BaseTool
+	Processes *ProcessRegistry
+}
+
+// NewKillProcess 创建结束后台进程工具
+func NewKillProcess(processes *ProcessRegistry) *KillProcess {
+	return &KillProcess{
+		BaseTool: BaseTool{
+			Name:        "KillProcess",
+			Description: "结束由工具在后台启动的进程，只能结束 ListProcesses 中列出的进程",
+			Parameters: map[string]interface{}{
+				"pid": map[string]interface{}{
+					"type":        "integer",
+					"description": "要结束的进程ID",
+				},
+			},
+			Required: []string{"pid"},
+		},
+		Processes: processes,
+	}
+}
+
+// Execute 结束后台进程
+func (k *KillProcess) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateArguments(args, k.Required); err != nil {
+		return nil, err
+	}
+
+	pid, ok := args["pid"].(float64)
+	if !ok || pid != float64(int(pid)) {
+		return nil, invalidArguments("参数pid必须是整数")
+	}
+
+	info, err := k.Processes.Kill(int(pid))
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewToolOutput(fmt.Sprintf("进程 %d 已结束", info.PID), info), nil
+}
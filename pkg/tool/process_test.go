@@ -0,0 +1,73 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// startSleep 在登记表中启动一个长时间运行的sleep进程
+func startSleep(t *testing.T, processes *ProcessRegistry) ProcessInfo {
+	t.Helper()
+	path, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+	info, err := processes.Start("Test", exec.Command(path, "30"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestListAndKillProcesses(t *testing.T) {
+	processes := NewProcessRegistry()
+	started := startSleep(t, processes)
+	t.Cleanup(processes.Cleanup)
+
+	result, err := NewListProcesses(processes).Execute(context.Background(), `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, _ := schema.ToToolOutput(result).Data.([]ProcessInfo)
+	if len(listed) != 1 || listed[0].PID != started.PID || !listed[0].Running {
+		t.Fatalf("listed = %+v, want the running sleep process", listed)
+	}
+
+	result, err = NewKillProcess(processes).Execute(context.Background(), fmt.Sprintf(`{"pid": %d}`, started.PID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if killed, _ := schema.ToToolOutput(result).Data.(ProcessInfo); killed.Running {
+		t.Errorf("killed process still running: %+v", killed)
+	}
+	if listed := processes.List(); len(listed) != 1 || listed[0].Running {
+		t.Errorf("after kill = %+v, want the process marked exited", listed)
+	}
+
+	// 只能结束登记表中的进程
+	if _, err := NewKillProcess(processes).Execute(context.Background(), `{"pid": 1}`); !errors.Is(err, ErrProcessNotFound) {
+		t.Errorf("kill unknown pid err = %v, want ErrProcessNotFound", err)
+	}
+}
+
+func TestProcessCleanupReapsSurvivors(t *testing.T) {
+	processes := NewProcessRegistry()
+	first := startSleep(t, processes)
+	second := startSleep(t, processes)
+
+	processes.Cleanup()
+
+	for _, info := range processes.List() {
+		if info.Running {
+			t.Errorf("process %d still running after cleanup", info.PID)
+		}
+	}
+	if len(processes.List()) != 2 || first.PID == second.PID {
+		t.Errorf("processes = %+v, want both sleep processes recorded", processes.List())
+	}
+}
@@ -0,0 +1,71 @@
+package tool
+
+import (
+	"context"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// ProgressHandler 进度回调，percent 取值 0-100
+type ProgressHandler func(status string, percent float64)
+
+// ReportProgress 运行进度报告工具
+type ReportProgress struct {
+	BaseTool
+	// OnProgress 收到进度时回调，为nil时只记录日志
+	OnProgress ProgressHandler
+}
+
+// NewReportProgress 创建进度报告工具
+func NewReportProgress() *ReportProgress {
+	return &ReportProgress{
+		BaseTool: BaseTool{
+			Name:        "ReportProgress",
+			Description: "报告长任务的当前进度，供用户查看。在完成重要阶段时调用",
+			Parameters: map[string]interface{}{
+				"status": map[string]interface{}{
+					"type":        "string",
+					"description": "简短的当前状态描述",
+				},
+				"percent": map[string]interface{}{
+					"type":        "number",
+					"description": "完成百分比（0-100）",
+				},
+			},
+			Required: []string{"status", "percent"},
+		},
+	}
+}
+
+// Execute 报告进度
+func (r *ReportProgress) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateArguments(args, r.Required); err != nil {
+		return nil, err
+	}
+
+	status, ok := args["status"].(string)
+	if !ok || status == "" {
+		return nil, invalidArguments("参数status必须是非空字符串")
+	}
+	percent, ok := args["percent"].(float64)
+	if !ok || percent < 0 || percent > 100 {
+		return nil, invalidArguments("参数percent必须是0到100之间的数字")
+	}
+
+	logger.Info("任务进度", zap.String("status", status), zap.Float64("percent", percent))
+	if r.OnProgress != nil {
+		r.OnProgress(status, percent)
+	}
+
+	return schema.NewToolOutput("进度已更新", map[string]interface{}{
+		"status":  status,
+		"percent": percent,
+	}), nil
+}
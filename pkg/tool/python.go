@@ -0,0 +1,168 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// pythonCandidates 按优先级查找的Python解释器名称
+var pythonCandidates = []string{"python3", "python", "py"}
+
+// lookPath 查找可执行文件，测试时可替换
+var lookPath = exec.LookPath
+
+// findPython 查找Python解释器，优先使用 [tools.python] 中配置的路径
+func findPython() (string, error) {
+	if settings := config.GetConfig().GetToolsSettings(); settings != nil &&
+		settings.Python != nil && settings.Python.Interpreter != "" {
+		path, err := lookPath(settings.Python.Interpreter)
+		if err != nil {
+			return "", fmt.Errorf("配置的Python解释器不可用: %s: %w", settings.Python.Interpreter, err)
+		}
+		return path, nil
+	}
+
+	for _, name := range pythonCandidates {
+		if path, err := lookPath(name); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("未找到Python解释器（已尝试 %v），请安装Python 3或在 [tools.python] interpreter 中配置解释器路径", pythonCandidates)
+}
+
+// PythonBridge 管理工作目录下的Python虚拟环境，虚拟环境只创建一次并记录已安装的包
+type PythonBridge struct {
+	workDir     string
+	interpreter string
+	venvDir     string
+	venvReady   bool
+	installed   map[string]bool
+	mu          sync.Mutex
+}
+
+var (
+	pythonBridges   = make(map[string]*PythonBridge)
+	pythonBridgesMu sync.Mutex
+)
+
+// NewPythonBridge 创建Python虚拟环境管理器
+func NewPythonBridge(workDir, interpreter string) *PythonBridge {
+	return &PythonBridge{
+		workDir:     workDir,
+		interpreter: interpreter,
+		venvDir:     filepath.Join(workDir, ".venv"),
+		installed:   make(map[string]bool),
+	}
+}
+
+// GetPythonBridge 获取工作目录共享的Python虚拟环境管理器
+func GetPythonBridge(workDir, interpreter string) *PythonBridge {
+	pythonBridgesMu.Lock()
+	defer pythonBridgesMu.Unlock()
+
+	if bridge, ok := pythonBridges[workDir]; ok {
+		return bridge
+	}
+
+	bridge := NewPythonBridge(workDir, interpreter)
+	pythonBridges[workDir] = bridge
+	return bridge
+}
+
+// Interpreter 获取虚拟环境中的Python解释器路径
+func (b *PythonBridge) Interpreter() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(b.venvDir, "Scripts", "python.exe")
+	}
+	return filepath.Join(b.venvDir, "bin", "python")
+}
+
+// HasVirtualEnv 检查虚拟环境是否已创建
+func (b *PythonBridge) HasVirtualEnv() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.venvReady
+}
+
+// setupVirtualEnv 在工作目录下创建虚拟环境，已存在时直接复用，调用方需持有锁
+func (b *PythonBridge) setupVirtualEnv(ctx context.Context) error {
+	if b.venvReady {
+		return nil
+	}
+
+	if _, err := os.Stat(b.Interpreter()); err == nil {
+		b.venvReady = true
+		return nil
+	}
+
+	logger.Info("创建Python虚拟环境", zap.String("venv", b.venvDir))
+
+	cmd := exec.CommandContext(ctx, b.interpreter, "-m", "venv", b.venvDir)
+	cmd.Dir = b.workDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("创建虚拟环境失败: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	b.venvReady = true
+	return nil
+}
+
+// InstallRequirements 在虚拟环境中安装pip包，跳过已安装的包
+func (b *PythonBridge) InstallRequirements(ctx context.Context, packages []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.setupVirtualEnv(ctx); err != nil {
+		return err
+	}
+
+	pending := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		if !b.installed[pkg] {
+			pending = append(pending, pkg)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	logger.Info("安装Python依赖", zap.Strings("packages", pending))
+
+	cmdArgs := append([]string{"-m", "pip", "install", "--disable-pip-version-check"}, pending...)
+	cmd := exec.CommandContext(ctx, b.Interpreter(), cmdArgs...)
+	cmd.Dir = b.workDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("安装Python依赖失败: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	for _, pkg := range pending {
+		b.installed[pkg] = true
+	}
+	return nil
+}
+
+// stringSlice 将JSON数组参数转换为字符串切片
+func stringSlice(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if str, ok := item.(string); ok && str != "" {
+			result = append(result, str)
+		}
+	}
+	return result
+}
@@ -0,0 +1,262 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// stubLookPath 将解释器查找替换为只认识 available 中名称的实现，测试结束后恢复
+func stubLookPath(t *testing.T, available map[string]string) {
+	t.Helper()
+	lookPath = func(name string) (string, error) {
+		if path, ok := available[name]; ok {
+			return path, nil
+		}
+		return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	t.Cleanup(func() { lookPath = exec.LookPath })
+}
+
+func TestFindPythonWithoutInterpreter(t *testing.T) {
+	stubLookPath(t, nil)
+
+	_, err := findPython()
+	if err == nil || !strings.Contains(err.Error(), "python3") || !strings.Contains(err.Error(), "[tools.python]") {
+		t.Errorf("error = %v, want a hint listing the candidates and the config section", err)
+	}
+
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+	if _, err := NewPythonExecute().Execute(ctx, `{"code": "print(1)"}`); err == nil {
+		t.Error("PythonExecute without an interpreter: expected error")
+	}
+}
+
+func TestFindPythonFallsBackToAlternateNames(t *testing.T) {
+	stubLookPath(t, map[string]string{"python": "/usr/local/bin/python", "py": `C:\Windows\py.exe`})
+
+	if path, err := findPython(); err != nil || path != "/usr/local/bin/python" {
+		t.Errorf("findPython = %q, %v; want the first available candidate", path, err)
+	}
+}
+
+func TestFindPythonUsesConfiguredInterpreter(t *testing.T) {
+	stubLookPath(t, map[string]string{"python3": "/usr/bin/python3", "pypy3": "/opt/pypy/bin/pypy3"})
+
+	testconfig.Use(t, baseTestConfig+"\n[tools.python]\ninterpreter = \"pypy3\"\n")
+	if path, err := findPython(); err != nil || path != "/opt/pypy/bin/pypy3" {
+		t.Errorf("findPython = %q, %v; want the configured interpreter", path, err)
+	}
+
+	testconfig.Use(t, baseTestConfig+"\n[tools.python]\ninterpreter = \"python2.7\"\n")
+	var execErr *exec.Error
+	if _, err := findPython(); !errors.As(err, &execErr) {
+		t.Errorf("missing configured interpreter: error = %v, want the lookup error", err)
+	}
+}
+
+// requirePython 找不到Python解释器时跳过测试
+func requirePython(t *testing.T) string {
+	t.Helper()
+	path, err := findPython()
+	if err != nil {
+		t.Skip("python not available:", err)
+	}
+	return path
+}
+
+// fakeVenvPython 模拟虚拟环境解释器的脚本：pip 安装只记录参数，其余参数交给真实解释器
+const fakeVenvPython = `#!/bin/sh
+if [ "$1" = "-m" ] && [ "$2" = "pip" ]; then
+	echo "$@" >> "$(dirname "$0")/pip.log"
+	exit 0
+fi
+exec "%s" "$@"
+`
+
+// installFakeVenv 在工作目录中创建模拟的虚拟环境，返回pip调用记录文件路径
+func installFakeVenv(t *testing.T, workDir, python string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake virtualenv uses a shell script")
+	}
+	bin := filepath.Join(workDir, ".venv", "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "python"), []byte(fmt.Sprintf(fakeVenvPython, python)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(bin, "pip.log")
+}
+
+// runPython 在 ctx 的工作目录中执行 PythonExecute，返回脚本输出
+func runPython(t *testing.T, ctx context.Context, arguments string) string {
+	t.Helper()
+	result, err := NewPythonExecute().Execute(ctx, arguments)
+	if err != nil {
+		t.Fatalf("PythonExecute(%s): %v", arguments, err)
+	}
+	output := result.(*schema.ToolOutput)
+	if output.IsError {
+		t.Fatalf("PythonExecute(%s) failed: %s", arguments, output.Content)
+	}
+	return output.Content
+}
+
+func TestPythonExecutePipesStdin(t *testing.T) {
+	requirePython(t)
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+
+	output := runPython(t, ctx, `{"code": "import sys\nprint(sys.stdin.read().upper())", "stdin": "hello"}`)
+	if strings.TrimSpace(output) != "HELLO" {
+		t.Errorf("output = %q", output)
+	}
+}
+
+func TestPythonExecuteSetsEnvironment(t *testing.T) {
+	requirePython(t)
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+
+	output := runPython(t, ctx, `{"code": "import os\nprint(os.environ['GREETING'], 'PATH' in os.environ)", "env": {"GREETING": "hi"}}`)
+	if strings.TrimSpace(output) != "hi True" {
+		t.Errorf("output = %q, want the extra variable on top of the inherited environment", output)
+	}
+}
+
+func TestPythonExecuteInstallsRequirements(t *testing.T) {
+	python := requirePython(t)
+	workDir := t.TempDir()
+	pipLog := installFakeVenv(t, workDir, python)
+	ctx := WithWorkspace(context.Background(), workDir)
+
+	output := runPython(t, ctx, `{"code": "import sys\nprint(sys.argv[0].endswith('.py'))", "requirements": ["requests", "rich"]}`)
+	if strings.TrimSpace(output) != "True" {
+		t.Errorf("output = %q", output)
+	}
+	if log := readFile(t, pipLog); !strings.Contains(log, "install --disable-pip-version-check requests rich") {
+		t.Errorf("pip log = %q", log)
+	}
+}
+
+// fakeBasePython 模拟基础解释器的脚本：-m venv 时记录调用并用 fakeVenvPython 创建虚拟环境
+const fakeBasePython = `#!/bin/sh
+if [ "$1" = "-m" ] && [ "$2" = "venv" ]; then
+	echo "$3" >> "%[1]s"
+	mkdir -p "$3/bin"
+	cp "%[2]s" "$3/bin/python"
+	exit 0
+fi
+exit 1
+`
+
+func TestPythonBridgeCreatesVenvOnceAndSkipsInstalledPackages(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake interpreter uses a shell script")
+	}
+	scripts := t.TempDir()
+	venvLog := filepath.Join(scripts, "venv.log")
+	venvPython := filepath.Join(scripts, "venv-python")
+	basePython := filepath.Join(scripts, "python3")
+	writeFiles(t, scripts, map[string]string{
+		"venv-python": fmt.Sprintf(fakeVenvPython, "/bin/false"),
+		"python3":     fmt.Sprintf(fakeBasePython, venvLog, venvPython),
+	})
+	os.Chmod(venvPython, 0755)
+	os.Chmod(basePython, 0755)
+
+	workDir := t.TempDir()
+	bridge := GetPythonBridge(workDir, basePython)
+	if GetPythonBridge(workDir, basePython) != bridge {
+		t.Error("bridges for the same workspace are not shared")
+	}
+
+	ctx := context.Background()
+	for _, packages := range [][]string{{"requests"}, {"requests"}, {"requests", "rich"}} {
+		if err := bridge.InstallRequirements(ctx, packages); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !bridge.HasVirtualEnv() {
+		t.Error("virtual environment not recorded as ready")
+	}
+	if created := strings.Count(readFile(t, venvLog), "\n"); created != 1 {
+		t.Errorf("virtual environment created %d times, want 1", created)
+	}
+	installs := strings.Split(strings.TrimSpace(readFile(t, filepath.Join(workDir, ".venv", "bin", "pip.log"))), "\n")
+	if len(installs) != 2 || !strings.HasSuffix(installs[0], " requests") || !strings.HasSuffix(installs[1], " rich") {
+		t.Errorf("pip installs = %q, want requests then rich only", installs)
+	}
+
+	// 新的管理器复用已存在的虚拟环境
+	if err := NewPythonBridge(workDir, basePython).InstallRequirements(ctx, []string{"rich"}); err != nil {
+		t.Fatal(err)
+	}
+	if created := strings.Count(readFile(t, venvLog), "\n"); created != 1 {
+		t.Errorf("existing virtual environment was recreated")
+	}
+}
+
+func TestPythonExecuteStreamsOutputBeforeExit(t *testing.T) {
+	requirePython(t)
+	ctx := WithWorkspace(context.Background(), t.TempDir())
+
+	type chunk struct {
+		stream, line string
+		at           time.Time
+	}
+	var mu sync.Mutex
+	var chunks []chunk
+	python := NewPythonExecute()
+	python.OnOutput = func(stream, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		chunks = append(chunks, chunk{stream, line, time.Now()})
+	}
+
+	code := `import sys, time
+print("started", flush=True)
+time.sleep(0.5)
+print("warning", file=sys.stderr, flush=True)
+print("finished")`
+	arguments, _ := json.Marshal(map[string]string{"code": code})
+	result, err := python.Execute(ctx, string(arguments))
+	done := time.Now()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := result.(*schema.ToolOutput).Content
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %v, want 3", chunks)
+	}
+	// stdout和stderr由不同的协程读取，只有先输出的一行顺序确定
+	if chunks[0].line != "started" || chunks[0].stream != "stdout" {
+		t.Errorf("chunks = %v", chunks)
+	}
+	later := map[string]string{chunks[1].line: chunks[1].stream, chunks[2].line: chunks[2].stream}
+	if later["warning"] != "stderr" || later["finished"] != "stdout" {
+		t.Errorf("chunks = %v", chunks)
+	}
+	if early := done.Sub(chunks[0].at); early < 300*time.Millisecond {
+		t.Errorf("first chunk observed only %v before the script exited", early)
+	}
+	if !strings.Contains(output, "started") || !strings.Contains(output, "finished") {
+		t.Errorf("collected output = %q", output)
+	}
+}
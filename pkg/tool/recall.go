@@ -0,0 +1,101 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// maxRecallRecords RecallToolCalls 单次最多返回的记录数
+const maxRecallRecords = 50
+
+// ToolCallRecord 本次运行中一次已执行的工具调用，参数和结果为截断后的摘要
+type ToolCallRecord struct {
+	Step      int    `json:"step"`
+	Tool      string `json:"tool"`
+	Arguments string `json:"arguments"`
+	Success   bool   `json:"success"`
+	Result    string `json:"result"`
+}
+
+// RecallFunc 返回本次运行中已执行的工具调用，按执行顺序排列
+type RecallFunc func() []ToolCallRecord
+
+// RecallToolCalls 回顾本次运行中已执行的工具调用
+type RecallToolCalls struct {
+	BaseTool
+	// Recall 获取工具调用记录，为nil时工具不可用
+	Recall RecallFunc
+}
+
+// NewRecallToolCalls 创建工具调用回顾工具
+func NewRecallToolCalls() *RecallToolCalls {
+	return &RecallToolCalls{
+		BaseTool: BaseTool{
+			Name:        "RecallToolCalls",
+			Description: "回顾本次运行中已经执行过的工具调用（工具名、参数摘要、成功或失败），避免重复尝试已经做过的操作",
+			Parameters: map[string]interface{}{
+				"tool": map[string]interface{}{
+					"type":        "string",
+					"description": "只返回指定工具的调用（可选）",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("返回最近的调用数（默认20，最多%d）", maxRecallRecords),
+					"default":     20,
+				},
+			},
+		},
+	}
+}
+
+// Execute 返回最近的工具调用摘要
+func (r *RecallToolCalls) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Recall == nil {
+		return nil, fmt.Errorf("回顾工具未配置工具调用记录")
+	}
+
+	limit := 20
+	if value, ok := args["limit"].(float64); ok {
+		limit = int(value)
+	}
+	if limit <= 0 || limit > maxRecallRecords {
+		limit = maxRecallRecords
+	}
+	name, _ := args["tool"].(string)
+
+	var records []ToolCallRecord
+	for _, record := range r.Recall() {
+		if name == "" || record.Tool == name {
+			records = append(records, record)
+		}
+	}
+	total := len(records)
+	if total == 0 {
+		return schema.NewToolOutput("本次运行中还没有执行过工具调用", nil), nil
+	}
+	if total > limit {
+		records = records[total-limit:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "共执行 %d 次工具调用，以下为最近 %d 次:\n", total, len(records))
+	for i, record := range records {
+		status := "成功"
+		if !record.Success {
+			status = "失败"
+		}
+		fmt.Fprintf(&b, "%d. [步骤%d] %s %s %s: %s\n", i+1, record.Step, record.Tool, record.Arguments, status, record.Result)
+	}
+	return schema.NewToolOutput(b.String(), nil), nil
+}
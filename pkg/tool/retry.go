@@ -0,0 +1,28 @@
+package tool
+
+import (
+	"context"
+	"errors"
+)
+
+// RetryableTool 可重试工具接口，只读或幂等的工具实现该接口，执行出错时可以安全地重新执行
+type RetryableTool interface {
+	Retryable(arguments string) bool
+}
+
+// IsRetryable 检查工具在给定参数下执行出错时是否可以重试
+func IsRetryable(t Tool, arguments string) bool {
+	retryable, ok := t.(RetryableTool)
+	return ok && retryable.Retryable(arguments)
+}
+
+// IsTransientError 检查工具错误是否可能是临时的
+// 参数无效、被策略拦截和上下文取消不会因重试而改变
+func IsTransientError(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrInvalidArguments) &&
+		!errors.Is(err, ErrBlockedByPolicy) &&
+		!errors.Is(err, ErrProtectedPath) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,40 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOnlyIdempotentRequestsAreRetryable(t *testing.T) {
+	browser := NewSimpleBrowser()
+	tests := []struct {
+		arguments string
+		want      bool
+	}{
+		{`{"url": "https://example.com"}`, true},
+		{`{"url": "https://example.com", "method": "head"}`, true},
+		{`{"url": "https://example.com", "method": "POST"}`, false},
+		{`{"url": "https://example.com", "form": {"a": "b"}}`, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(browser, tt.arguments); got != tt.want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", tt.arguments, got, tt.want)
+		}
+	}
+	if IsRetryable(NewStrReplaceEditor(), `{"command": "view", "path": "a.txt"}`) {
+		t.Error("editor should not be retryable")
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	if !IsTransientError(errors.New("connection refused")) {
+		t.Error("network error should be transient")
+	}
+	for _, err := range []error{nil, invalidArguments("bad"), fmt.Errorf("%w: rm", ErrBlockedByPolicy), context.Canceled} {
+		if IsTransientError(err) {
+			t.Errorf("%v should not be transient", err)
+		}
+	}
+}
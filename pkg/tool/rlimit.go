@@ -0,0 +1,40 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// resourceLimits 子进程的资源上限，0表示不限制
+type resourceLimits struct {
+	cpuSeconds int
+	memoryMB   int
+}
+
+// pythonLimits 获取 [tools.python] 中配置的资源上限
+func pythonLimits() resourceLimits {
+	settings := config.GetConfig().GetToolsSettings()
+	if settings == nil || settings.Python == nil {
+		return resourceLimits{}
+	}
+	return resourceLimits{cpuSeconds: settings.Python.CPUTime, memoryMB: settings.Python.MaxMemoryMB}
+}
+
+// empty 是否没有任何上限
+func (l resourceLimits) empty() bool {
+	return l.cpuSeconds <= 0 && l.memoryMB <= 0
+}
+
+// exceeded 判断进程是否因超出资源上限而失败，返回说明，未超出时返回空字符串
+// 内存不足时Python抛出 MemoryError，也可能在分配失败时直接被信号终止
+func (l resourceLimits) exceeded(err error, output string) string {
+	if err == nil || l.empty() {
+		return ""
+	}
+	if l.memoryMB > 0 && strings.Contains(output, "MemoryError") {
+		return fmt.Sprintf("内存超过 %d MB", l.memoryMB)
+	}
+	return l.exceededBySignal(err)
+}
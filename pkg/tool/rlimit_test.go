@@ -0,0 +1,69 @@
+//go:build linux
+
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// runLimitedPython 在配置的资源上限下执行Python代码
+func runLimitedPython(t *testing.T, ctx context.Context, limits, code string) *schema.ToolOutput {
+	t.Helper()
+	requirePython(t)
+	testconfig.Use(t, baseTestConfig+"\n[tools.python]\n"+limits)
+	ctx = WithWorkspace(ctx, t.TempDir())
+
+	arguments, _ := json.Marshal(map[string]string{"code": code})
+	result, err := NewPythonExecute().Execute(ctx, string(arguments))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema.ToToolOutput(result)
+}
+
+func TestPythonMemoryLimitKillsHungryScript(t *testing.T) {
+	output := runLimitedPython(t, context.Background(), "max_memory_mb = 200\n", "data = bytearray(1024 * 1024 * 1024)\nprint('allocated')")
+
+	if !output.IsError || !strings.Contains(output.Content, "资源超出限制") || !strings.Contains(output.Content, "内存") {
+		t.Errorf("output = %+v, want a memory limit error", output)
+	}
+	if strings.Contains(output.Content, "allocated") {
+		t.Error("script allocated past the memory limit")
+	}
+}
+
+func TestPythonCPULimitKillsBusyLoop(t *testing.T) {
+	output := runLimitedPython(t, context.Background(), "cpu_time = 1\n", "while True:\n    pass")
+
+	if !output.IsError || !strings.Contains(output.Content, "CPU") {
+		t.Errorf("output = %+v, want a CPU limit error", output)
+	}
+}
+
+func TestPythonWithinLimitsRunsNormally(t *testing.T) {
+	output := runLimitedPython(t, context.Background(), "cpu_time = 10\nmax_memory_mb = 1024\n", "print(sum(range(10)))")
+
+	if output.IsError || strings.TrimSpace(output.Content) != "45" {
+		t.Errorf("output = %+v, want 45", output)
+	}
+}
+
+func TestPythonCancellationIsNotReportedAsLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	output := runLimitedPython(t, ctx, "cpu_time = 30\nmax_memory_mb = 1024\n", "import time\ntime.sleep(30)")
+
+	if !output.IsError {
+		t.Fatalf("output = %+v, want an error for the cancelled script", output)
+	}
+	if strings.Contains(output.Content, "资源超出限制") {
+		t.Errorf("cancellation reported as a resource limit: %q", output.Content)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// apply 通过 sh 的 ulimit 设置上限后再exec原命令，上限在脚本启动前生效且对其子进程同样有效
+// 未指定 -H/-S 时 ulimit 同时设置软硬上限，脚本无法自行调高
+func (l resourceLimits) apply(cmd *exec.Cmd) {
+	if l.empty() {
+		return
+	}
+
+	var script []string
+	if l.cpuSeconds > 0 {
+		script = append(script, fmt.Sprintf("ulimit -t %d", l.cpuSeconds))
+	}
+	if l.memoryMB > 0 {
+		script = append(script, fmt.Sprintf("ulimit -v %d", l.memoryMB*1024))
+	}
+	script = append(script, `exec "$@"`)
+
+	cmd.Args = append([]string{"sh", "-c", strings.Join(script, " && "), "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+}
+
+// exceededBySignal 根据终止进程的信号判断是否超出上限
+// 超出CPU时间时内核发送SIGXCPU，硬上限处为SIGKILL；超出内存时分配失败可能导致SIGSEGV或SIGABRT
+// 上下文取消时 exec.CommandContext 也以SIGKILL终止进程，调用方需先排除这种情况
+func (l resourceLimits) exceededBySignal(err error) string {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return ""
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	switch signal := status.Signal(); {
+	case l.cpuSeconds > 0 && (signal == syscall.SIGXCPU || signal == syscall.SIGKILL):
+		return fmt.Sprintf("CPU时间超过 %d 秒", l.cpuSeconds)
+	case l.memoryMB > 0 && (signal == syscall.SIGSEGV || signal == syscall.SIGABRT || signal == syscall.SIGKILL):
+		return fmt.Sprintf("内存超过 %d MB", l.memoryMB)
+	default:
+		return ""
+	}
+}
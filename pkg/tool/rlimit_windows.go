@@ -0,0 +1,21 @@
+//go:build windows
+
+package tool
+
+import (
+	"os/exec"
+
+	"github.com/yahao333/GoManus/pkg/logger"
+)
+
+// apply Windows上不支持ulimit，配置的上限不生效
+func (l resourceLimits) apply(cmd *exec.Cmd) {
+	if !l.empty() {
+		logger.Warn("Windows上不支持Python资源上限，cpu_time 和 max_memory_mb 不生效")
+	}
+}
+
+// exceededBySignal Windows上进程不会因信号终止
+func (l resourceLimits) exceededBySignal(err error) string {
+	return ""
+}
@@ -4,11 +4,17 @@ import (
     "context"
     "encoding/json"
     "fmt"
+    "io"
     "net/http"
+    "net/http/cookiejar"
+    "net/url"
+    "strconv"
     "strings"
     "time"
 
+    "github.com/yahao333/GoManus/pkg/config"
     "github.com/yahao333/GoManus/pkg/logger"
+    "github.com/yahao333/GoManus/pkg/schema"
     "go.uber.org/zap"
 )
 
@@ -20,18 +26,24 @@ type SimpleBrowser struct {
 
 // NewSimpleBrowser 创建简化浏览器工具
 func NewSimpleBrowser() *SimpleBrowser {
-	return &SimpleBrowser{
+	browser := &SimpleBrowser{
 		BaseTool: BaseTool{
 			Name:        "SimpleBrowser",
-			Description: "简单的HTTP浏览器工具",
+			Description: "简单的HTTP浏览器工具，同一次运行中的请求共享Cookie",
 			Parameters: map[string]interface{}{
+				"action": map[string]interface{}{
+					"type":        "string",
+					"description": "操作类型: request 发送请求, clear_cookies 清除已保存的Cookie",
+					"enum":        []string{"request", "clear_cookies"},
+					"default":     "request",
+				},
 				"url": map[string]interface{}{
 					"type":        "string",
 					"description": "要访问的URL",
 				},
 				"method": map[string]interface{}{
 					"type":        "string",
-					"description": "HTTP方法: GET, POST",
+					"description": "HTTP方法: GET, POST（提交表单时默认POST）",
 					"enum":        []string{"GET", "POST"},
 					"default":     "GET",
 				},
@@ -44,13 +56,28 @@ func NewSimpleBrowser() *SimpleBrowser {
 					"type":        "string",
 					"description": "请求体（POST方法时使用）",
 				},
+				"form": map[string]interface{}{
+					"type":        "object",
+					"description": "表单字段（字段名到值），提供时以POST方式提交表单",
+				},
+				"files": map[string]interface{}{
+					"type":        "object",
+					"description": "表单文件字段（字段名到工作目录中的文件路径），提供时使用multipart编码",
+				},
 			},
-			Required: []string{"url"},
-		},
-		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Required: []string{},
 		},
+		client: newSafeHTTPClient(30 * time.Second),
 	}
+	browser.ClearCookies()
+	return browser
+}
+
+// ClearCookies 清除已保存的Cookie
+func (s *SimpleBrowser) ClearCookies() {
+	// 未提供PublicSuffixList时cookiejar.New不会返回错误
+	jar, _ := cookiejar.New(nil)
+	s.client.Jar = jar
 }
 
 // Execute 执行浏览器操作
@@ -60,12 +87,24 @@ func (s *SimpleBrowser) Execute(ctx context.Context, arguments string) (interfac
 		return nil, err
 	}
 
-	if err := validateArguments(args, s.Required); err != nil {
+	if action, ok := args["action"].(string); ok && action == "clear_cookies" {
+		s.ClearCookies()
+		return schema.NewToolOutput("已清除所有Cookie", nil), nil
+	}
+
+	if err := validateArguments(args, []string{"url"}); err != nil {
 		return nil, err
 	}
 
 	url, _ := args["url"].(string)
+	form, _ := args["form"].(map[string]interface{})
+	files, _ := args["files"].(map[string]interface{})
+	isForm := len(form) > 0 || len(files) > 0
+
 	method := "GET"
+	if isForm {
+		method = "POST"
+	}
 	if methodArg, ok := args["method"].(string); ok {
 		method = methodArg
 	}
@@ -74,11 +113,23 @@ func (s *SimpleBrowser) Execute(ctx context.Context, arguments string) (interfac
 		zap.String("url", url),
 		zap.String("method", method))
 
+	if err := checkURL(url); err != nil {
+		return nil, err
+	}
+
 	// 创建请求
 	var req *http.Request
 	var reqErr error
+	contentType := ""
 
-	if method == "POST" {
+	if isForm && method == "POST" {
+		body, formType, err := encodeForm(ctx, form, files)
+		if err != nil {
+			return nil, err
+		}
+		contentType = formType
+		req, reqErr = http.NewRequestWithContext(ctx, method, url, body)
+	} else if method == "POST" {
 		body := ""
 		if bodyArg, ok := args["body"].(string); ok {
 			body = bodyArg
@@ -92,7 +143,11 @@ func (s *SimpleBrowser) Execute(ctx context.Context, arguments string) (interfac
 		return nil, fmt.Errorf("创建请求失败: %w", reqErr)
 	}
 
-	// 设置请求头
+	// 设置请求头，单次调用指定的请求头优先于配置的默认值
+	applyDefaultHeaders(req)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	if headers, ok := args["headers"].(map[string]interface{}); ok {
 		for key, value := range headers {
 			if strValue, ok := value.(string); ok {
@@ -108,34 +163,103 @@ func (s *SimpleBrowser) Execute(ctx context.Context, arguments string) (interfac
 	}
 	defer resp.Body.Close()
 
-	// 读取响应
-	var result strings.Builder
-	buffer := make([]byte, 1024)
-	for {
-		n, err := resp.Body.Read(buffer)
-		if n > 0 {
-			result.Write(buffer[:n])
-		}
-		if err != nil {
-			break
-		}
+	// 读取响应，最多收集配置的字节数
+	limit := maxOutputBytes()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	truncated := len(body) > limit
+	if truncated {
+		body = body[:limit]
 	}
 
 	// 截断内容（避免太长）
-	content := result.String()
+	content := string(body)
 	if len(content) > 5000 {
 		content = content[:5000] + "..."
+	} else if truncated {
+		content += "\n...[响应超过上限，已截断]"
 	}
 
-	return map[string]interface{}{
-		"url":        url,
-		"method":     method,
+	data := map[string]interface{}{
+		"url":         url,
+		"method":      method,
 		"status_code": resp.StatusCode,
-		"status":     resp.Status,
-		"headers":    resp.Header,
-		"content":    content,
-		"length":     len(content),
-	}, nil
+		"status":      resp.Status,
+		"headers":     resp.Header,
+		"length":      len(content),
+	}
+	// 非2xx响应作为错误结果返回，不会被缓存
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return schema.NewToolError(fmt.Sprintf("HTTP %s\n%s", resp.Status, content), data), nil
+	}
+	return schema.NewToolOutput(content, data), nil
+}
+
+// DefaultUserAgent HTTP工具默认的User-Agent
+const DefaultUserAgent = "Mozilla/5.0 (compatible; GoManus/1.0)"
+
+// applyDefaultHeaders 设置配置的User-Agent和默认请求头
+func applyDefaultHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", DefaultUserAgent)
+
+	settings := config.GetConfig().GetToolsSettings()
+	if settings == nil || settings.Browser == nil {
+		return
+	}
+	if settings.Browser.UserAgent != "" {
+		req.Header.Set("User-Agent", settings.Browser.UserAgent)
+	}
+	for key, value := range settings.Browser.Headers {
+		req.Header.Set(key, value)
+	}
+}
+
+// Cacheable 不带Cookie的GET请求的结果可缓存
+// Cookie中保存着登录等会话状态，Cookie罐中已有目标主机的Cookie时，同一URL的响应可能随会话变化，不使用缓存
+func (s *SimpleBrowser) Cacheable(arguments string) bool {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return false
+	}
+	if action, ok := args["action"].(string); ok && action != "" && action != "request" {
+		return false
+	}
+	if _, ok := args["form"]; ok {
+		return false
+	}
+	if _, ok := args["files"]; ok {
+		return false
+	}
+	if method, ok := args["method"].(string); ok && !strings.EqualFold(method, "GET") {
+		return false
+	}
+	target, _ := args["url"].(string)
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	return s.client.Jar == nil || len(s.client.Jar.Cookies(parsed)) == 0
+}
+
+// Retryable 不带表单的GET和HEAD请求是幂等的，出错时可以重试
+func (s *SimpleBrowser) Retryable(arguments string) bool {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return false
+	}
+	if action, ok := args["action"].(string); ok && action != "" && action != "request" {
+		return false
+	}
+	if _, ok := args["form"]; ok {
+		return false
+	}
+	if _, ok := args["files"]; ok {
+		return false
+	}
+	method, ok := args["method"].(string)
+	return !ok || strings.EqualFold(method, "GET") || strings.EqualFold(method, "HEAD")
 }
 
 // SimpleSearch 简化搜索工具
@@ -198,18 +322,12 @@ func (s *SimpleSearch) Execute(ctx context.Context, arguments string) (interface
 		zap.String("engine", engine),
 		zap.Int("num_results", numResults))
 
-	// 构建搜索URL
-	var searchURL string
-	switch engine {
-	case "google":
-		searchURL = fmt.Sprintf("https://www.google.com/search?q=%s&num=%d", 
-			strings.ReplaceAll(query, " ", "+"), numResults)
-	case "bing":
-		searchURL = fmt.Sprintf("https://www.bing.com/search?q=%s&count=%d", 
-			strings.ReplaceAll(query, " ", "+"), numResults)
-	default: // duckduckgo
-		searchURL = fmt.Sprintf("https://duckduckgo.com/?q=%s&kl=us-en", 
-			strings.ReplaceAll(query, " ", "+"))
+	// 构建搜索URL，按区域设置请求本地化结果
+	lang, country := searchLocale()
+	searchURL := buildSearchURL(engine, query, numResults, lang, country)
+
+	if err := checkURL(searchURL); err != nil {
+		return nil, err
 	}
 
 	// 使用浏览器工具获取搜索结果
@@ -224,12 +342,72 @@ func (s *SimpleSearch) Execute(ctx context.Context, arguments string) (interface
 	}
 
 	// 简化搜索结果（实际实现中需要解析HTML）
-	return map[string]interface{}{
-		"query":        query,
-		"engine":       engine,
-		"search_url":   searchURL,
-		"results":      "模拟搜索结果",
-		"num_results":  numResults,
-		"note":         "这是简化的搜索结果，实际实现需要解析HTML",
-	}, nil
+	return schema.NewToolOutput("模拟搜索结果（这是简化的搜索结果，实际实现需要解析HTML）", map[string]interface{}{
+		"query":       query,
+		"engine":      engine,
+		"search_url":  searchURL,
+		"num_results": numResults,
+		"lang":        lang,
+		"country":     country,
+	}), nil
+}
+
+// searchLocale 获取搜索语言和国家/地区，[search] 中未配置的部分使用区域设置
+func searchLocale() (lang, country string) {
+	lang, country = config.SplitLocale(config.GetConfig().GetLocale())
+	if settings := config.GetConfig().GetSearchSettings(); settings != nil {
+		if settings.Lang != "" {
+			lang = strings.ToLower(settings.Lang)
+		}
+		if settings.Country != "" {
+			country = strings.ToLower(settings.Country)
+		}
+	}
+	return lang, country
+}
+
+// buildSearchURL 构建搜索引擎的查询URL，lang 和 country 为空时不附加对应参数
+func buildSearchURL(engine, query string, numResults int, lang, country string) string {
+	params := url.Values{}
+	params.Set("q", query)
+
+	var base string
+	switch engine {
+	case "google":
+		base = "https://www.google.com/search"
+		params.Set("num", strconv.Itoa(numResults))
+		setIfNotEmpty(params, "hl", lang)
+		setIfNotEmpty(params, "gl", country)
+	case "bing":
+		base = "https://www.bing.com/search"
+		params.Set("count", strconv.Itoa(numResults))
+		setIfNotEmpty(params, "setlang", lang)
+		setIfNotEmpty(params, "cc", country)
+	default: // duckduckgo
+		base = "https://duckduckgo.com/"
+		// DuckDuckGo 的地区参数形如 us-en，缺少任一部分时使用全球结果
+		region := "wt-wt"
+		if lang != "" && country != "" {
+			region = country + "-" + lang
+		}
+		params.Set("kl", region)
+	}
+	return base + "?" + params.Encode()
+}
+
+// setIfNotEmpty 值非空时设置查询参数
+func setIfNotEmpty(params url.Values, key, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
+
+// Cacheable 搜索结果可缓存
+func (s *SimpleSearch) Cacheable(arguments string) bool {
+	return true
+}
+
+// Retryable 搜索只读取结果，出错时可以重试
+func (s *SimpleSearch) Retryable(arguments string) bool {
+	return true
 }
@@ -0,0 +1,235 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+)
+
+// privateNetworkConfig 允许访问本机测试服务器的配置
+const privateNetworkConfig = baseTestConfig + `
+[tools.network]
+allow_private = true
+`
+
+// recordingServer 记录收到的请求的测试服务器
+type recordingServer struct {
+	*httptest.Server
+	requests []*http.Request
+	mu       sync.Mutex
+}
+
+// newRecordingServer 创建测试服务器，handler 为nil时返回 "ok"
+func newRecordingServer(t *testing.T, handler http.HandlerFunc) *recordingServer {
+	t.Helper()
+	server := &recordingServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		server.requests = append(server.requests, r)
+		server.mu.Unlock()
+		if handler != nil {
+			handler(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Requests 返回收到的请求
+func (s *recordingServer) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func TestSimpleBrowserDefaultHeaders(t *testing.T) {
+	server := newRecordingServer(t, nil)
+	browser := NewSimpleBrowser()
+
+	testconfig.Use(t, privateNetworkConfig)
+	runTool(t, context.Background(), browser, `{"url": "`+server.URL+`"}`)
+
+	testconfig.Use(t, privateNetworkConfig+`
+[tools.browser]
+user_agent = "TestAgent/2.0"
+
+[tools.browser.headers]
+Accept-Language = "zh-CN"
+X-Team = "docs"
+`)
+	runTool(t, context.Background(), browser, `{"url": "`+server.URL+`"}`)
+	runTool(t, context.Background(), browser, `{"url": "`+server.URL+`", "headers": {"X-Team": "search", "User-Agent": "Override/1.0"}}`)
+
+	requests := server.Requests()
+	if got := requests[0].Header.Get("User-Agent"); got != DefaultUserAgent {
+		t.Errorf("default User-Agent = %q, want %q", got, DefaultUserAgent)
+	}
+
+	configured := requests[1].Header
+	if configured.Get("User-Agent") != "TestAgent/2.0" || configured.Get("Accept-Language") != "zh-CN" || configured.Get("X-Team") != "docs" {
+		t.Errorf("configured headers = %v", configured)
+	}
+
+	// 单次调用指定的请求头优先，其余默认值保留
+	overridden := requests[2].Header
+	if overridden.Get("User-Agent") != "Override/1.0" || overridden.Get("X-Team") != "search" || overridden.Get("Accept-Language") != "zh-CN" {
+		t.Errorf("per-call headers = %v", overridden)
+	}
+}
+
+func TestSimpleBrowserKeepsCookiesAcrossCalls(t *testing.T) {
+	testconfig.Use(t, privateNetworkConfig)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/"})
+		w.Write([]byte("logged in"))
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("no session"))
+			return
+		}
+		w.Write([]byte("profile"))
+	})
+	server := newRecordingServer(t, mux.ServeHTTP)
+	browser := NewSimpleBrowser()
+	ctx := context.Background()
+
+	runTool(t, ctx, browser, `{"url": "`+server.URL+`/login"}`)
+	if output := runTool(t, ctx, browser, `{"url": "`+server.URL+`/profile"}`); output.Content != "profile" {
+		t.Errorf("second call without the session cookie: %q", output.Content)
+	}
+
+	// 清除后不再携带Cookie，其他浏览器实例也不共享
+	runTool(t, ctx, browser, `{"action": "clear_cookies"}`)
+	if output := runTool(t, ctx, browser, `{"url": "`+server.URL+`/profile"}`); !output.IsError {
+		t.Errorf("cookie sent after clear_cookies: %q", output.Content)
+	}
+	if output := runTool(t, ctx, NewSimpleBrowser(), `{"url": "`+server.URL+`/profile"}`); !output.IsError {
+		t.Errorf("cookie shared with a new browser: %q", output.Content)
+	}
+}
+
+func TestSimpleBrowserSubmitsURLEncodedForm(t *testing.T) {
+	testconfig.Use(t, privateNetworkConfig)
+	var method, contentType string
+	var fields url.Values
+	server := newRecordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method, contentType, fields = r.Method, r.Header.Get("Content-Type"), r.PostForm
+	})
+
+	runTool(t, context.Background(), NewSimpleBrowser(), `{"url": "`+server.URL+`", "form": {"q": "go & rust", "page": 2}}`)
+
+	if method != http.MethodPost || contentType != "application/x-www-form-urlencoded" {
+		t.Errorf("method = %s, content type = %s", method, contentType)
+	}
+	if fields.Get("q") != "go & rust" || fields.Get("page") != "2" {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestSimpleBrowserSubmitsMultipartForm(t *testing.T) {
+	testconfig.Use(t, privateNetworkConfig)
+	workspace := t.TempDir()
+	writeFiles(t, workspace, map[string]string{"docs/report.txt": "季度报告"})
+
+	var title, filename, content string
+	server := newRecordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm: %v", err)
+			return
+		}
+		title = r.FormValue("title")
+		file, header, err := r.FormFile("attachment")
+		if err != nil {
+			t.Errorf("FormFile: %v", err)
+			return
+		}
+		defer file.Close()
+		data, _ := io.ReadAll(file)
+		filename, content = header.Filename, string(data)
+	})
+
+	ctx := WithWorkspace(context.Background(), workspace)
+	runTool(t, ctx, NewSimpleBrowser(), `{"url": "`+server.URL+`", "form": {"title": "Q3"}, "files": {"attachment": "docs/report.txt"}}`)
+
+	if title != "Q3" || filename != "report.txt" || content != "季度报告" {
+		t.Errorf("title = %q, file = %q, content = %q", title, filename, content)
+	}
+}
+
+func TestSimpleBrowserRejectsFilesOutsideWorkspace(t *testing.T) {
+	testconfig.Use(t, privateNetworkConfig)
+	root := t.TempDir()
+	workspace := filepath.Join(root, "workspace")
+	writeFiles(t, root, map[string]string{"secret.txt": "密钥", "workspace/ok.txt": "ok"})
+
+	requests := 0
+	server := newRecordingServer(t, func(w http.ResponseWriter, r *http.Request) { requests++ })
+
+	ctx := WithWorkspace(context.Background(), workspace)
+	for _, path := range []string{filepath.Join(root, "secret.txt"), "../secret.txt", "docs/../../secret.txt"} {
+		arguments, _ := json.Marshal(map[string]interface{}{
+			"url":   server.URL,
+			"files": map[string]string{"attachment": path},
+		})
+		if _, err := NewSimpleBrowser().Execute(ctx, string(arguments)); !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("%s: err = %v, want ErrInvalidArguments", path, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("%d requests sent with files outside the workspace", requests)
+	}
+}
+
+func TestSearchURLUsesConfiguredLocale(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[agent]
+locale = "de_DE.UTF-8"
+`)
+	lang, country := searchLocale()
+
+	tests := map[string]map[string]string{
+		"google":     {"q": "go generics", "hl": "de", "gl": "de", "num": "3"},
+		"bing":       {"q": "go generics", "setlang": "de", "cc": "de", "count": "3"},
+		"duckduckgo": {"q": "go generics", "kl": "de-de"},
+	}
+	for engine, want := range tests {
+		parsed, err := url.Parse(buildSearchURL(engine, "go generics", 3, lang, country))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range want {
+			if got := parsed.Query().Get(key); got != value {
+				t.Errorf("%s: %s = %q, want %q (url %s)", engine, key, got, value, parsed)
+			}
+		}
+	}
+}
+
+func TestSearchSettingsOverrideLocale(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[agent]
+locale = "de-DE"
+
+[search]
+lang = "EN"
+`)
+	if lang, country := searchLocale(); lang != "en" || country != "de" {
+		t.Errorf("searchLocale = %q, %q; want en from [search] and de from the locale", lang, country)
+	}
+}
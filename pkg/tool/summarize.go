@@ -0,0 +1,187 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/llm"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultSummaryChunkSize 每个分块的默认字符数
+	defaultSummaryChunkSize = 8000
+	// defaultSummaryTargetLength 摘要的默认目标字符数
+	defaultSummaryTargetLength = 1000
+	// maxSummaryRounds 归并摘要的最大轮数
+	maxSummaryRounds = 5
+)
+
+// Summarize 大文本摘要工具，分块后通过LLM逐级归并摘要
+type Summarize struct {
+	BaseTool
+	llm llm.Provider
+}
+
+// NewSummarize 创建摘要工具
+func NewSummarize(provider llm.Provider) *Summarize {
+	return &Summarize{
+		BaseTool: BaseTool{
+			Name:        "Summarize",
+			Description: "对超出上下文的长文本或工作目录中的文件进行分块摘要，返回精简的概述",
+			Parameters: map[string]interface{}{
+				"text": map[string]interface{}{
+					"type":        "string",
+					"description": "要摘要的文本（与path二选一）",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "要摘要的文件路径（与text二选一）",
+				},
+				"target_length": map[string]interface{}{
+					"type":        "integer",
+					"description": "摘要的目标字符数",
+					"default":     defaultSummaryTargetLength,
+				},
+				"focus": map[string]interface{}{
+					"type":        "string",
+					"description": "摘要时重点关注的内容（可选）",
+				},
+			},
+			Required: []string{},
+		},
+		llm: provider,
+	}
+}
+
+// Execute 执行摘要
+func (s *Summarize) Execute(ctx context.Context, arguments string) (interface{}, error) {
+	args, err := parseArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	text, _ := args["text"].(string)
+	if path, ok := args["path"].(string); ok && path != "" {
+		resolved, err := resolveUnprotectedPath(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, invalidArguments("需要提供text或path参数")
+	}
+
+	targetLength := defaultSummaryTargetLength
+	if length, ok := args["target_length"].(float64); ok && length > 0 {
+		targetLength = int(length)
+	}
+	focus, _ := args["focus"].(string)
+
+	chunks := chunkText(text, defaultSummaryChunkSize)
+	logger.Info("开始摘要",
+		zap.Int("chars", len([]rune(text))),
+		zap.Int("chunks", len(chunks)))
+
+	summary, err := s.summarize(ctx, chunks, targetLength, focus)
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.NewToolOutput(summary, map[string]interface{}{
+		"chunks": len(chunks),
+	}), nil
+}
+
+// summarize 映射阶段逐块摘要，归并阶段合并各块摘要直至只剩一个分块
+func (s *Summarize) summarize(ctx context.Context, chunks []string, targetLength int, focus string) (string, error) {
+	for round := 0; len(chunks) > 1; round++ {
+		if round >= maxSummaryRounds {
+			return "", fmt.Errorf("摘要归并轮数超过上限: %d", maxSummaryRounds)
+		}
+
+		// 每个分块摘要长度按分块数均分，保证合并后仍可归并
+		partLength := defaultSummaryChunkSize / len(chunks)
+		if partLength < targetLength {
+			partLength = targetLength
+		}
+
+		partials := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			partial, err := s.complete(ctx, summaryPrompt(chunk, partLength, focus))
+			if err != nil {
+				return "", fmt.Errorf("第%d块摘要失败: %w", i+1, err)
+			}
+			partials = append(partials, partial)
+		}
+
+		chunks = chunkText(strings.Join(partials, "\n\n"), defaultSummaryChunkSize)
+	}
+
+	return s.complete(ctx, summaryPrompt(chunks[0], targetLength, focus))
+}
+
+// complete 调用LLM生成摘要
+func (s *Summarize) complete(ctx context.Context, prompt string) (string, error) {
+	if s.llm == nil {
+		return "", fmt.Errorf("摘要工具未配置LLM")
+	}
+
+	response, err := s.llm.GenerateResponse(ctx, []schema.Message{
+		schema.NewUserMessage(prompt),
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	if response.Content == nil {
+		return "", fmt.Errorf("LLM未返回摘要内容")
+	}
+	return strings.TrimSpace(*response.Content), nil
+}
+
+// summaryPrompt 构建摘要提示
+func summaryPrompt(text string, targetLength int, focus string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "请将以下内容摘要为不超过%d个字符的概述，保留关键事实、数据和结论。", targetLength)
+	if focus != "" {
+		fmt.Fprintf(&b, "重点关注: %s。", focus)
+	}
+	b.WriteString("只输出摘要本身。\n\n")
+	b.WriteString(text)
+	return b.String()
+}
+
+// chunkText 按字符数将文本分块，优先在段落和换行处切分
+func chunkText(text string, size int) []string {
+	runes := []rune(text)
+	chunks := make([]string, 0, len(runes)/size+1)
+
+	for len(runes) > size {
+		cut := size
+		window := string(runes[:size])
+		if idx := strings.LastIndex(window, "\n\n"); idx > 0 {
+			cut = len([]rune(window[:idx]))
+		} else if idx := strings.LastIndex(window, "\n"); idx > 0 {
+			cut = len([]rune(window[:idx]))
+		}
+
+		if chunk := strings.TrimSpace(string(runes[:cut])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		runes = runes[cut:]
+	}
+
+	if chunk := strings.TrimSpace(string(runes)); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,77 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yahao333/GoManus/pkg/llm"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// fakeSummarizer 记录提示的测试提供者，按调用顺序返回 "摘要N"
+type fakeSummarizer struct {
+	prompts []string
+	mu      sync.Mutex
+}
+
+// GenerateResponse 记录提示并返回编号的摘要
+func (f *fakeSummarizer) GenerateResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (*schema.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.prompts = append(f.prompts, *messages[len(messages)-1].Content)
+	response := schema.NewAssistantMessage(fmt.Sprintf("摘要%d", len(f.prompts)))
+	return &response, nil
+}
+
+// GenerateStreamResponse 摘要工具不使用流式响应
+func (f *fakeSummarizer) GenerateStreamResponse(ctx context.Context, messages []schema.Message, tools []schema.ToolDefinition) (<-chan llm.StreamChunk, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func TestSummarizeCombinesChunkSummaries(t *testing.T) {
+	var paragraphs []string
+	for i := 0; i < 3; i++ {
+		paragraphs = append(paragraphs, fmt.Sprintf("第%d部分 ", i+1)+strings.Repeat("内容", defaultSummaryChunkSize/2-10))
+	}
+	text := strings.Join(paragraphs, "\n\n")
+
+	provider := &fakeSummarizer{}
+	output := runTool(t, context.Background(), NewSummarize(provider), fmt.Sprintf(`{"text": %q}`, text))
+
+	// 三个分块各摘要一次，再将三段摘要归并为一份
+	if len(provider.prompts) != 4 {
+		t.Fatalf("LLM called %d times, want 4", len(provider.prompts))
+	}
+	for i, prompt := range provider.prompts[:3] {
+		if !strings.Contains(prompt, fmt.Sprintf("第%d部分", i+1)) {
+			t.Errorf("map prompt %d does not contain chunk %d", i+1, i+1)
+		}
+	}
+	reduce := provider.prompts[3]
+	for _, partial := range []string{"摘要1", "摘要2", "摘要3"} {
+		if !strings.Contains(reduce, partial) {
+			t.Errorf("reduce prompt does not contain %s", partial)
+		}
+	}
+	if output.Content != "摘要4" {
+		t.Errorf("summary = %q, want the single combined summary", output.Content)
+	}
+	if chunks := output.Data.(map[string]interface{})["chunks"]; chunks != 3 {
+		t.Errorf("chunks = %v, want 3", chunks)
+	}
+}
+
+func TestSummarizeShortTextInOneCall(t *testing.T) {
+	provider := &fakeSummarizer{}
+	output := runTool(t, context.Background(), NewSummarize(provider), `{"text": "一段短文本", "focus": "结论"}`)
+
+	if len(provider.prompts) != 1 || !strings.Contains(provider.prompts[0], "重点关注: 结论") {
+		t.Errorf("prompts = %q", provider.prompts)
+	}
+	if output.Content != "摘要1" {
+		t.Errorf("summary = %q", output.Content)
+	}
+}
@@ -0,0 +1,86 @@
+package tool
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/yahao333/GoManus/pkg/config"
+	"github.com/yahao333/GoManus/pkg/logger"
+	"github.com/yahao333/GoManus/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// templateFuncs 结果模板可用的函数
+var templateFuncs = template.FuncMap{
+	// inc 序号从1开始，用于编号列表
+	"inc": func(i int) int { return i + 1 },
+	// json 将值编码为JSON
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// templateInput 结果模板的输入，Data 经JSON往返转换，字段名与默认输出中的JSON一致
+type templateInput struct {
+	Content string
+	Data    interface{}
+}
+
+// resultTemplate 获取工具配置的结果模板，配置键不区分大小写
+func resultTemplate(toolName string) string {
+	settings := config.GetConfig().GetToolsSettings()
+	if settings == nil {
+		return ""
+	}
+	for name, text := range settings.Templates {
+		if strings.EqualFold(name, toolName) {
+			return text
+		}
+	}
+	return ""
+}
+
+// FormatOutput 将工具输出格式化为发送给模型的文本
+// 配置了 [tools.templates] 中该工具的模板时按模板渲染，未配置、输出为错误或模板执行失败时使用默认格式
+func FormatOutput(toolName string, output *schema.ToolOutput) string {
+	text := resultTemplate(toolName)
+	if text == "" || output.IsError {
+		return output.Format()
+	}
+
+	rendered, err := renderOutput(toolName, text, output)
+	if err != nil {
+		logger.Warn("工具结果模板渲染失败，使用默认格式",
+			zap.String("tool", toolName),
+			zap.Error(err))
+		return output.Format()
+	}
+	return rendered
+}
+
+// renderOutput 使用模板渲染工具输出
+func renderOutput(toolName, text string, output *schema.ToolOutput) (string, error) {
+	tmpl, err := template.New(toolName).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	input := templateInput{Content: output.Content}
+	if output.Data != nil {
+		data, err := json.Marshal(output.Data)
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(data, &input.Data); err != nil {
+			return "", err
+		}
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, input); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
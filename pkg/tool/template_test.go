@@ -0,0 +1,58 @@
+package tool
+
+import (
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// searchTemplateConfig 为搜索工具配置编号列表模板
+const searchTemplateConfig = baseTestConfig + `
+[tools.templates]
+SimpleSearch = """{{.Content}}
+{{range $i, $r := .Data.results}}{{inc $i}}. {{$r.title}} ({{$r.url}})
+{{end}}"""
+Broken = "{{.Data.missing}}"
+`
+
+// searchOutput 模拟搜索工具的结构化结果
+func searchOutput() *schema.ToolOutput {
+	type result struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	}
+	return schema.NewToolOutput("go 的搜索结果:", map[string]interface{}{
+		"results": []result{
+			{Title: "The Go Programming Language", URL: "https://go.dev"},
+			{Title: "Go by Example", URL: "https://gobyexample.com"},
+		},
+	})
+}
+
+func TestFormatOutputRendersToolTemplate(t *testing.T) {
+	testconfig.Use(t, searchTemplateConfig)
+
+	want := "go 的搜索结果:\n" +
+		"1. The Go Programming Language (https://go.dev)\n" +
+		"2. Go by Example (https://gobyexample.com)\n"
+	if got := FormatOutput("SimpleSearch", searchOutput()); got != want {
+		t.Errorf("FormatOutput = %q, want %q", got, want)
+	}
+}
+
+func TestFormatOutputFallsBackToJSON(t *testing.T) {
+	testconfig.Use(t, searchTemplateConfig)
+	output := searchOutput()
+
+	// 未配置模板、模板执行失败和错误输出都使用默认格式
+	for _, name := range []string{"SimpleBrowser", "Broken"} {
+		if got, want := FormatOutput(name, output), output.Format(); got != want {
+			t.Errorf("FormatOutput(%s) = %q, want default %q", name, got, want)
+		}
+	}
+	failed := schema.NewToolError("搜索失败", nil)
+	if got, want := FormatOutput("SimpleSearch", failed), failed.Format(); got != want {
+		t.Errorf("FormatOutput(error) = %q, want %q", got, want)
+	}
+}
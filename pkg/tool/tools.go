@@ -1,22 +1,33 @@
 package tool
 
 import (
+    "bufio"
     "context"
     "fmt"
+    "io"
     "os"
     "os/exec"
     "path/filepath"
+    "strconv"
     "strings"
+    "sync"
     "time"
 
-    "github.com/yahao333/GoManus/pkg/config"
     "github.com/yahao333/GoManus/pkg/logger"
+    "github.com/yahao333/GoManus/pkg/schema"
     "go.uber.org/zap"
 )
 
+// OutputHandler 逐行输出回调，stream为stdout或stderr
+type OutputHandler func(stream, line string)
+
 // PythonExecute Python执行工具
 type PythonExecute struct {
 	BaseTool
+	// OnOutput 脚本运行期间逐行回调输出，为nil时只收集完整输出；转发的字节数超过输出上限后不再回调
+	OnOutput OutputHandler
+	// Processes 后台脚本登记到的进程表，为nil时不支持后台运行
+	Processes *ProcessRegistry
 }
 
 // NewPythonExecute 创建Python执行工具
@@ -30,6 +41,25 @@ func NewPythonExecute() *PythonExecute {
 					"type":        "string",
 					"description": "要执行的Python代码",
 				},
+				"stdin": map[string]interface{}{
+					"type":        "string",
+					"description": "传给脚本标准输入的数据",
+				},
+				"env": map[string]interface{}{
+					"type":        "object",
+					"description": "额外的环境变量",
+					"default":     map[string]string{},
+				},
+				"requirements": map[string]interface{}{
+					"type":        "array",
+					"description": "执行前安装到虚拟环境中的pip包",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"background": map[string]interface{}{
+					"type":        "boolean",
+					"description": "在后台运行脚本（如启动服务）并立即返回进程ID，输出写入日志文件；可用 ListProcesses 查看、KillProcess 结束，运行结束时自动清理",
+					"default":     false,
+				},
 			},
 			Required: []string{"code"},
 		},
@@ -49,40 +79,169 @@ func (p *PythonExecute) Execute(ctx context.Context, arguments string) (interfac
 
 	code, ok := args["code"].(string)
 	if !ok {
-		return nil, fmt.Errorf("参数code必须是字符串")
+		return nil, invalidArguments("参数code必须是字符串")
 	}
 
 	logger.Info("执行Python代码", zap.String("code", code))
 
+	// 查找Python解释器
+	interpreter, err := findPython()
+	if err != nil {
+		return nil, err
+	}
+
+	// 拒绝引用受保护路径的代码，工作目录本身也不能受保护
+	if err := checkProtectedReferences(code); err != nil {
+		return nil, err
+	}
+	workDir := WorkspaceFromContext(ctx)
+	if err := checkProtectedPath(workDir); err != nil {
+		return nil, err
+	}
+
 	// 创建工作目录
-	workDir := config.GetConfig().GetWorkspaceRoot()
 	if err := os.MkdirAll(workDir, 0755); err != nil {
 		return nil, fmt.Errorf("创建工作目录失败: %w", err)
 	}
 
-	// 创建临时文件
-	tempFile := filepath.Join(workDir, fmt.Sprintf("python_script_%d.py", time.Now().Unix()))
-	if err := os.WriteFile(tempFile, []byte(code), 0644); err != nil {
+	// 需要依赖时在工作目录共享的虚拟环境中安装，已有虚拟环境时始终使用其解释器
+	bridge := GetPythonBridge(workDir, interpreter)
+	if requirements := stringSlice(args["requirements"]); len(requirements) > 0 {
+		if err := bridge.InstallRequirements(ctx, requirements); err != nil {
+			return nil, err
+		}
+	}
+	if bridge.HasVirtualEnv() {
+		interpreter = bridge.Interpreter()
+	}
+
+	background, _ := args["background"].(bool)
+	if background && p.Processes == nil {
+		return nil, fmt.Errorf("当前智能体不支持后台运行Python脚本")
+	}
+
+	// 创建临时文件，后台脚本在进程运行期间保留
+	tempFile := filepath.Join(workDir, fmt.Sprintf("python_script_%d.py", time.Now().UnixNano()))
+	if err := writeFileAtomic(tempFile, []byte(code), 0644); err != nil {
 		return nil, fmt.Errorf("写入临时文件失败: %w", err)
 	}
-	defer os.Remove(tempFile)
+	if !background {
+		defer os.Remove(tempFile)
+	}
 
 	// 执行Python代码
-	cmd := exec.CommandContext(ctx, "python3", tempFile)
+	cmd := exec.CommandContext(ctx, interpreter, tempFile)
 	cmd.Dir = workDir
+	if stdin, ok := args["stdin"].(string); ok {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	if env, ok := args["env"].(map[string]interface{}); ok && len(env) > 0 {
+		cmd.Env = os.Environ()
+		for key, value := range env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+
+	// 按 [tools.python] 限制脚本的CPU时间和内存
+	limits := pythonLimits()
+	limits.apply(cmd)
+
+	if background {
+		return p.startBackground(cmd, strings.TrimSuffix(tempFile, ".py")+".log")
+	}
 	
-	output, err := cmd.CombinedOutput()
+	output, err := p.runStreaming(cmd)
+	// 取消或超时时进程同样被SIGKILL终止，不能当作超出上限
+	if ctx.Err() == nil {
+		if reason := limits.exceeded(err, output); reason != "" {
+			logger.Warn("Python脚本超出资源上限", zap.String("reason", reason))
+			return schema.NewToolError(strings.TrimSpace("资源超出限制: "+reason+"，脚本已被终止\n"+output), nil), nil
+		}
+	}
+	if err != nil {
+		return schema.NewToolError(strings.TrimSpace(output+"\n"+err.Error()), nil), nil
+	}
+
+	return schema.NewToolOutput(output, nil), nil
+}
+
+// startBackground 在后台启动脚本并登记到进程表，stdout和stderr写入日志文件
+func (p *PythonExecute) startBackground(cmd *exec.Cmd, logFile string) (interface{}, error) {
+	log, err := os.Create(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("创建日志文件失败: %w", err)
+	}
+	// 子进程持有自己的文件描述符，启动后即可关闭
+	defer log.Close()
+	cmd.Stdout = log
+	cmd.Stderr = log
+
+	info, err := p.Processes.Start(p.Name, cmd, logFile)
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewToolOutput(fmt.Sprintf("脚本已在后台启动，进程ID %d，输出写入 %s", info.PID, logFile), info), nil
+}
+
+// runStreaming 运行命令并逐行转发stdout/stderr，同时收集完整输出
+func (p *PythonExecute) runStreaming(cmd *exec.Cmd) (string, error) {
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("获取标准输出失败: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return map[string]interface{}{
-			"output": string(output),
-			"error":  err.Error(),
-		}, nil
+		return "", fmt.Errorf("获取标准错误失败: %w", err)
 	}
 
-	return map[string]interface{}{
-		"output": string(output),
-		"success": true,
-	}, nil
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("启动Python进程失败: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	limit := maxOutputBytes()
+	output := newLimitedBuffer(limit)
+	// 逐行回调同样受输出上限约束，超出后只通知一次
+	var forwardMu sync.Mutex
+	forwarded := 0
+	notify := func(stream string, chunk []byte) {
+		forwardMu.Lock()
+		defer forwardMu.Unlock()
+		if forwarded > limit {
+			return
+		}
+		forwarded += len(chunk)
+		if forwarded > limit {
+			p.OnOutput(stream, "...[输出超过上限，不再转发]")
+			return
+		}
+		p.OnOutput(stream, strings.TrimRight(string(chunk), "\r\n"))
+	}
+	forward := func(stream string, r io.Reader) {
+		defer wg.Done()
+		reader := bufio.NewReaderSize(r, 64*1024)
+		for {
+			// 使用ReadSlice避免超长行无限累积内存
+			chunk, readErr := reader.ReadSlice('\n')
+			if len(chunk) > 0 {
+				output.Write(chunk)
+				if p.OnOutput != nil {
+					notify(stream, chunk)
+				}
+			}
+			if readErr != nil && readErr != bufio.ErrBufferFull {
+				return
+			}
+		}
+	}
+
+	wg.Add(2)
+	go forward("stdout", stdout)
+	go forward("stderr", stderr)
+	wg.Wait()
+
+	err = cmd.Wait()
+	return output.String(), err
 }
 
 // StrReplaceEditor 文件编辑工具
@@ -137,6 +296,10 @@ func (s *StrReplaceEditor) Execute(ctx context.Context, arguments string) (inter
 
 	command, _ := args["command"].(string)
 	path, _ := args["path"].(string)
+	path, err = resolveUnprotectedPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
 
 	logger.Info("执行文件编辑", 
 		zap.String("command", command),
@@ -167,81 +330,156 @@ func (s *StrReplaceEditor) createFile(path string, args map[string]interface{})
 		return nil, fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	if err := os.WriteFile(path, []byte(fileText), 0644); err != nil {
+	// 记录原内容以生成diff（文件不存在时视为空），覆盖时沿用原文件的BOM和换行符
+	data := []byte(fileText)
+	before := ""
+	if content, err := os.ReadFile(path); err == nil {
+		if format, text, err := decodeText(content); err == nil {
+			before = text
+			// 混用换行符的文件没有统一格式可沿用，按给出的内容写入
+			if !format.mixed {
+				data = format.encode(fileText)
+			}
+		}
+	}
+
+	if err := writeFileAtomic(path, data, 0644); err != nil {
 		return nil, fmt.Errorf("写入文件失败: %w", err)
 	}
 
-	return map[string]interface{}{
-		"message": "文件创建成功",
-		"path":    path,
-	}, nil
+	return schema.NewToolOutput(
+		strings.TrimSpace("文件创建成功\n\n"+unifiedDiff(path, before, normalizeNewlines(fileText))),
+		map[string]interface{}{"path": path},
+	), nil
 }
 
 // viewFile 查看文件
 func (s *StrReplaceEditor) viewFile(path string) (interface{}, error) {
-	content, err := os.ReadFile(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("读取文件失败: %w", err)
 	}
 
-	return map[string]interface{}{
-		"content": string(content),
-		"path":    path,
-	}, nil
+	// 无法按文本解析的文件原样返回
+	format, content, err := decodeText(data)
+	if err != nil {
+		return schema.NewToolOutput(string(data), map[string]interface{}{"path": path}), nil
+	}
+
+	lineEnding := "LF"
+	switch {
+	case format.mixed:
+		lineEnding = "mixed"
+	case format.crlf:
+		lineEnding = "CRLF"
+	}
+
+	return schema.NewToolOutput(content, map[string]interface{}{
+		"path":        path,
+		"line_ending": lineEnding,
+		"bom":         format.bom,
+	}), nil
 }
 
 // strReplace 字符串替换
 func (s *StrReplaceEditor) strReplace(path string, args map[string]interface{}) (interface{}, error) {
 	oldStr, ok := args["old_str"].(string)
 	if !ok {
-		return nil, fmt.Errorf("str_replace命令需要提供old_str参数")
+		return nil, invalidArguments("str_replace命令需要提供old_str参数")
 	}
 
 	newStr, ok := args["new_str"].(string)
 	if !ok {
-		return nil, fmt.Errorf("str_replace命令需要提供new_str参数")
+		return nil, invalidArguments("str_replace命令需要提供new_str参数")
 	}
 
-	content, err := os.ReadFile(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("读取文件失败: %w", err)
 	}
 
-	newContent := strings.ReplaceAll(string(content), oldStr, newStr)
-	if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
+	// 统一为LF匹配，写回时还原原文件的BOM和换行符
+	format, content, err := decodeText(data)
+	if err != nil {
+		return nil, fmt.Errorf("拒绝编辑 %s: %w", path, err)
+	}
+	// 写回时只能统一为一种换行符，会改动未编辑的行
+	if format.mixed {
+		return nil, fmt.Errorf("拒绝编辑 %s: 文件混用CRLF和LF换行符，请先统一换行符或用create命令重写整个文件", path)
+	}
+	oldStr = normalizeNewlines(oldStr)
+	newStr = normalizeNewlines(newStr)
+
+	replacements := 0
+	if oldStr != "" {
+		replacements = strings.Count(content, oldStr)
+	}
+	if replacements == 0 {
+		return nil, fmt.Errorf("文件中未找到要替换的字符串: %s", path)
+	}
+
+	newContent := strings.ReplaceAll(content, oldStr, newStr)
+	if err := writeFileAtomic(path, format.encode(newContent), 0644); err != nil {
 		return nil, fmt.Errorf("写入文件失败: %w", err)
 	}
 
-	return map[string]interface{}{
-		"message": "字符串替换成功",
-		"path":    path,
-		"old_str": oldStr,
-		"new_str": newStr,
-	}, nil
+	return schema.NewToolOutput(
+		strings.TrimSpace("字符串替换成功\n\n"+unifiedDiff(path, content, newContent)),
+		map[string]interface{}{
+			"path":         path,
+			"replacements": replacements,
+		},
+	), nil
 }
 
 // AskHuman 人类提问工具
 type AskHuman struct {
 	BaseTool
+	// In 读取用户回答的输入，Out 输出问题和选项
+	In  io.Reader
+	Out io.Writer
+	// Interactive 为false时不等待用户输入
+	Interactive bool
+
+	reader *bufio.Reader
+	mu     sync.Mutex
 }
 
-// NewAskHuman 创建人类提问工具
+// NewAskHuman 创建人类提问工具，标准输入为终端时以交互方式提问
 func NewAskHuman() *AskHuman {
 	return &AskHuman{
 		BaseTool: BaseTool{
 			Name:        "AskHuman",
-			Description: "向用户提问",
+			Description: "向用户提问。提供choices时用户只能从选项中选择，返回所选的选项",
 			Parameters: map[string]interface{}{
 				"question": map[string]interface{}{
 					"type":        "string",
 					"description": "要提问的问题",
 				},
+				"choices": map[string]interface{}{
+					"type":        "array",
+					"description": "可选项，提供时用户需输入序号或选项内容",
+					"items":       map[string]interface{}{"type": "string"},
+				},
+				"default": map[string]interface{}{
+					"type":        "string",
+					"description": "非交互模式下使用的默认选项（选项内容或从1开始的序号）",
+				},
 			},
 			Required: []string{"question"},
 		},
+		In:          os.Stdin,
+		Out:         os.Stdout,
+		Interactive: isTerminal(os.Stdin),
 	}
 }
 
+// isTerminal 检查文件是否为终端
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 // Execute 执行提问
 func (a *AskHuman) Execute(ctx context.Context, arguments string) (interface{}, error) {
 	args, err := parseArguments(arguments)
@@ -254,16 +492,102 @@ func (a *AskHuman) Execute(ctx context.Context, arguments string) (interface{},
 	}
 
 	question, _ := args["question"].(string)
+	choices := stringSlice(args["choices"])
+
+	logger.Info("向用户提问",
+		zap.String("question", question),
+		zap.Strings("choices", choices))
 
-	logger.Info("向用户提问", zap.String("question", question))
+	if len(choices) > 0 {
+		return a.askChoice(question, choices, args)
+	}
+
+	if !a.Interactive {
+		// 非交互模式下无法获得回答，提示模型自行决定
+		return schema.NewToolOutput("用户回答: 继续执行任务（当前为非交互模式，无法获得用户输入）", nil), nil
+	}
+
+	answer, err := a.prompt(question + "\n> ")
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewToolOutput(answer, nil), nil
+}
+
+// askChoice 展示编号选项并读取用户选择，输入无效时重新提问
+func (a *AskHuman) askChoice(question string, choices []string, args map[string]interface{}) (interface{}, error) {
+	if !a.Interactive {
+		defaultChoice, _ := args["default"].(string)
+		if defaultChoice == "" {
+			return nil, fmt.Errorf("非交互模式下无法让用户选择，且未指定默认选项")
+		}
+		index, ok := matchChoice(defaultChoice, choices)
+		if !ok {
+			return nil, fmt.Errorf("默认选项不在可选项中: %s", defaultChoice)
+		}
+		return choiceResult(choices, index, true), nil
+	}
+
+	var menu strings.Builder
+	menu.WriteString(question + "\n")
+	for i, choice := range choices {
+		fmt.Fprintf(&menu, "  %d. %s\n", i+1, choice)
+	}
+	menu.WriteString("请输入序号或选项: ")
+
+	text := menu.String()
+	for {
+		answer, err := a.prompt(text)
+		if err != nil {
+			return nil, err
+		}
+		if index, ok := matchChoice(answer, choices); ok {
+			return choiceResult(choices, index, false), nil
+		}
+		text = fmt.Sprintf("无效的选择 %q，请输入 1-%d 之间的序号或选项内容: ", answer, len(choices))
+	}
+}
+
+// prompt 输出提示并读取一行输入
+func (a *AskHuman) prompt(text string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.reader == nil {
+		a.reader = bufio.NewReader(a.In)
+	}
+	fmt.Fprint(a.Out, text)
+
+	line, err := a.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("读取用户输入失败: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// matchChoice 按从1开始的序号或选项内容（忽略大小写）匹配选项
+func matchChoice(answer string, choices []string) (int, bool) {
+	answer = strings.TrimSpace(answer)
+	if index, err := strconv.Atoi(answer); err == nil {
+		if index >= 1 && index <= len(choices) {
+			return index - 1, true
+		}
+		return 0, false
+	}
+	for i, choice := range choices {
+		if strings.EqualFold(answer, choice) {
+			return i, true
+		}
+	}
+	return 0, false
+}
 
-	// 在实际实现中，这里应该等待用户输入
-	// 为了简化，返回一个模拟的响应
-	return map[string]interface{}{
-		"question": question,
-		"answer":   "用户回答: 继续执行任务",
-		"note":     "这是一个模拟响应，实际使用时需要实现用户输入机制",
-	}, nil
+// choiceResult 构造选择结果，index从0开始，返回时转为从1开始的序号
+func choiceResult(choices []string, index int, isDefault bool) *schema.ToolOutput {
+	return schema.NewToolOutput(choices[index], map[string]interface{}{
+		"index":   index + 1,
+		"default": isDefault,
+	})
 }
 
 // Terminate 终止工具
@@ -303,10 +627,9 @@ func (t *Terminate) Execute(ctx context.Context, arguments string) (interface{},
 
 	logger.Info("任务完成", zap.String("message", message))
 
-	return map[string]interface{}{
-		"message": message,
-		"status":  "completed",
-	}, nil
+	return schema.NewToolOutput(message, map[string]interface{}{
+		"status": "completed",
+	}), nil
 }
 
 // BrowserUseTool 浏览器工具
@@ -366,37 +689,30 @@ func (b *BrowserUseTool) Execute(ctx context.Context, arguments string) (interfa
 	// 为了简化，返回模拟结果
 	switch action {
 	case "visit":
-		return map[string]interface{}{
-			"url":     url,
-			"action":  action,
-			"status":  "visited",
-			"content": "模拟网页内容",
-		}, nil
+		return schema.NewToolOutput("模拟网页内容", map[string]interface{}{
+			"url":    url,
+			"status": "visited",
+		}), nil
 	case "click":
 		selector, _ := args["selector"].(string)
-		return map[string]interface{}{
-			"url":      url,
-			"action":   action,
-			"selector": selector,
-			"status":   "clicked",
-		}, nil
+		return schema.NewToolOutput("已点击元素: "+selector, map[string]interface{}{
+			"url":    url,
+			"status": "clicked",
+		}), nil
 	case "fill":
 		selector, _ := args["selector"].(string)
 		text, _ := args["text"].(string)
-		return map[string]interface{}{
-			"url":      url,
-			"action":   action,
-			"selector": selector,
-			"text":     text,
-			"status":   "filled",
-		}, nil
+		return schema.NewToolOutput("已填写元素: "+selector, map[string]interface{}{
+			"url":    url,
+			"text":   text,
+			"status": "filled",
+		}), nil
 	case "screenshot":
-		return map[string]interface{}{
-			"url":      url,
-			"action":   action,
-			"status":   "screenshot_taken",
-			"image":    "模拟截图数据",
-		}, nil
+		// 模拟实现没有真实截图，真实实现应将截图放入 Base64Image 随工具消息发送给模型
+		return schema.NewToolOutput("模拟截图数据", map[string]interface{}{
+			"url":    url,
+			"status": "screenshot_taken",
+		}), nil
 	default:
 		return nil, fmt.Errorf("不支持的操作: %s", action)
 	}
@@ -0,0 +1,185 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// newScriptedAskHuman 创建以给定输入作为用户回答的交互式提问工具
+func newScriptedAskHuman(input string) (*AskHuman, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	ask := NewAskHuman()
+	ask.In, ask.Out, ask.Interactive = strings.NewReader(input), out, true
+	return ask, out
+}
+
+const colorQuestion = `{"question": "选哪个颜色？", "choices": ["Red", "Green", "Blue"]}`
+
+func TestAskHumanChoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		choice  string
+		index   int
+		retries int
+	}{
+		{"index", "2\n", "Green", 2, 0},
+		{"label ignores case", "  blue \n", "Blue", 3, 0},
+		{"invalid answers are asked again", "0\n4\npurple\n1\n", "Red", 1, 3},
+		{"last line without newline", "green", "Green", 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ask, out := newScriptedAskHuman(tt.input)
+			result, err := ask.Execute(context.Background(), colorQuestion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output := result.(*schema.ToolOutput)
+			data := output.Data.(map[string]interface{})
+			if output.Content != tt.choice || data["index"] != tt.index || data["default"] != false {
+				t.Errorf("result = %q %v, want %q index %d", output.Content, data, tt.choice, tt.index)
+			}
+
+			if !strings.Contains(out.String(), "选哪个颜色？\n  1. Red\n  2. Green\n  3. Blue\n") {
+				t.Errorf("menu not shown:\n%s", out.String())
+			}
+			if got := strings.Count(out.String(), "无效的选择"); got != tt.retries {
+				t.Errorf("asked again %d times, want %d", got, tt.retries)
+			}
+		})
+	}
+}
+
+func TestAskHumanChoiceInputEnds(t *testing.T) {
+	ask, _ := newScriptedAskHuman("purple\n")
+	if _, err := ask.Execute(context.Background(), colorQuestion); err == nil {
+		t.Error("expected an error when input ends without a valid choice")
+	}
+}
+
+func TestAskHumanNonInteractive(t *testing.T) {
+	ask := NewAskHuman()
+	ask.In, ask.Out, ask.Interactive = strings.NewReader("1\n"), &bytes.Buffer{}, false
+
+	result, err := ask.Execute(context.Background(), `{"question": "选哪个颜色？", "choices": ["Red", "Green"], "default": "green"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := result.(*schema.ToolOutput)
+	data := output.Data.(map[string]interface{})
+	if output.Content != "Green" || data["index"] != 2 || data["default"] != true {
+		t.Errorf("default choice = %q %v", output.Content, data)
+	}
+
+	for name, arguments := range map[string]string{
+		"no default":      `{"question": "选哪个？", "choices": ["Red"]}`,
+		"unknown default": `{"question": "选哪个？", "choices": ["Red"], "default": "3"}`,
+	} {
+		if _, err := ask.Execute(context.Background(), arguments); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// 自由提问不等待输入
+	result, err = ask.Execute(context.Background(), `{"question": "还有什么要求？"}`)
+	if err != nil || !strings.Contains(result.(*schema.ToolOutput).Content, "非交互模式") {
+		t.Errorf("free-text answer = %v, %v", result, err)
+	}
+}
+
+func TestAskHumanFreeText(t *testing.T) {
+	ask, out := newScriptedAskHuman("用中文写\n")
+	result, err := ask.Execute(context.Background(), `{"question": "还有什么要求？"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := result.(*schema.ToolOutput).Content; got != "用中文写" {
+		t.Errorf("answer = %q", got)
+	}
+	if out.String() != "还有什么要求？\n> " {
+		t.Errorf("prompt = %q", out.String())
+	}
+}
+
+func TestBuiltinToolsReturnToolOutput(t *testing.T) {
+	testconfig.Use(t, privateNetworkConfig)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><p>页面内容</p></body></html>")
+	}))
+	defer server.Close()
+
+	workspace := t.TempDir()
+	ctx := WithWorkspace(context.Background(), workspace)
+
+	tests := []struct {
+		tool      Tool
+		arguments string
+	}{
+		{NewStrReplaceEditor(), createArguments("notes.txt", "第一行\n")},
+		{NewStrReplaceEditor(), `{"command": "view", "path": "notes.txt"}`},
+		{NewPythonExecute(), `{"code": "print(1 + 1)"}`},
+		{NewSimpleBrowser(), fmt.Sprintf(`{"url": %q}`, server.URL)},
+		{NewAskHuman(), `{"question": "继续吗？", "choices": ["是", "否"], "default": "1"}`},
+		{NewSummarize(&fakeSummarizer{}), `{"text": "一段很短的文本"}`},
+		{NewReportProgress(), `{"status": "进行中", "percent": 50}`},
+		{NewBrowserUseTool(), `{"url": "https://example.com", "action": "visit"}`},
+		{NewTerminate(), `{"message": "完成"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool.GetName(), func(t *testing.T) {
+			if tt.tool.GetName() == "PythonExecute" {
+				requirePython(t)
+			}
+			if ask, ok := tt.tool.(*AskHuman); ok {
+				ask.Interactive = false
+			}
+
+			result, err := tt.tool.Execute(ctx, tt.arguments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output, ok := result.(*schema.ToolOutput)
+			if !ok || output == nil {
+				t.Fatalf("result is %T, want *schema.ToolOutput", result)
+			}
+			if output.IsError || strings.TrimSpace(output.Content) == "" {
+				t.Errorf("output = %+v, want non-empty content without error", output)
+			}
+			if output.Data != nil {
+				if _, err := json.Marshal(output.Data); err != nil {
+					t.Errorf("data is not JSON serializable: %v", err)
+				}
+			}
+			if formatted := output.Format(); !strings.HasPrefix(formatted, output.Content) {
+				t.Errorf("formatted output does not start with the content: %q", formatted)
+			}
+		})
+	}
+}
+
+func TestFailedScriptReturnsToolError(t *testing.T) {
+	requirePython(t)
+	result, err := NewPythonExecute().Execute(WithWorkspace(context.Background(), t.TempDir()), `{"code": "print('partial')\nraise SystemExit(3)"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, ok := result.(*schema.ToolOutput)
+	if !ok || !output.IsError || !strings.Contains(output.Content, "partial") {
+		t.Errorf("result = %+v, want an error output keeping the script output", result)
+	}
+	if !strings.HasPrefix(output.Format(), "错误: ") {
+		t.Errorf("formatted = %q", output.Format())
+	}
+}
@@ -0,0 +1,45 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// workspaceKey 上下文中工作目录的键
+type workspaceKey struct{}
+
+// WithWorkspace 返回携带本次运行工作目录的上下文
+func WithWorkspace(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, dir)
+}
+
+// WorkspaceFromContext 获取上下文中的工作目录，未设置时返回全局工作空间根目录
+func WorkspaceFromContext(ctx context.Context) string {
+	if dir, ok := ctx.Value(workspaceKey{}).(string); ok && dir != "" {
+		return dir
+	}
+	return config.GetConfig().GetWorkspaceRoot()
+}
+
+// resolvePath 将相对路径解析到当前工作目录下，绝对路径保持不变
+func resolvePath(ctx context.Context, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(WorkspaceFromContext(ctx), path)
+}
+
+// resolveWorkspacePath 将相对路径解析到工作目录下，拒绝越界路径
+func resolveWorkspacePath(ctx context.Context, relPath string) (string, error) {
+	root := WorkspaceFromContext(ctx)
+	target := filepath.Clean(filepath.Join(root, relPath))
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("路径不在工作目录内: %s", relPath)
+	}
+	return target, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/yahao333/GoManus/pkg/agent"
+	"github.com/yahao333/GoManus/pkg/config"
+)
+
+// runToolsCommand 执行tools子命令，返回进程退出码
+func runToolsCommand(args []string) int {
+	if len(args) == 0 {
+		printToolsUsage()
+		return ExitConfig
+	}
+
+	switch args[0] {
+	case "list":
+		return runToolsList(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "未知的tools子命令: %s\n", args[0])
+		printToolsUsage()
+		return ExitConfig
+	}
+}
+
+// printToolsUsage 输出tools子命令用法
+func printToolsUsage() {
+	fmt.Fprintln(os.Stderr, "用法: gomanus tools <子命令>")
+	fmt.Fprintln(os.Stderr, "  list [--json] [--agent 档案]  列出智能体可用的工具，--json 输出包含参数Schema的工具定义数组")
+}
+
+// runToolsList 列出档案启用的工具定义
+func runToolsList(args []string) int {
+	fs := flag.NewFlagSet("tools list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "以JSON数组输出工具定义（name、description、parameters、required）")
+	agentName := fs.String("agent", agent.DefaultProfileName, "使用的智能体档案名称")
+	envFile := fs.String("env-file", config.DefaultEnvFile, "启动时加载的环境变量文件（不覆盖已设置的变量）")
+	if err := fs.Parse(args); err != nil {
+		return ExitConfig
+	}
+
+	envFileSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "env-file" {
+			envFileSet = true
+		}
+	})
+	if err := config.LoadEnvFile(*envFile, envFileSet); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return ExitConfig
+	}
+
+	manus, err := agent.NewManusFromProfile(*agentName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "创建智能体失败: %v\n", err)
+		return ExitConfig
+	}
+	definitions := manus.ToolDefinitions()
+
+	if *asJSON {
+		data, err := json.MarshalIndent(definitions, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "序列化工具定义失败: %v\n", err)
+			return ExitFailure
+		}
+		fmt.Println(string(data))
+		return ExitOK
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, definition := range definitions {
+		fmt.Fprintf(w, "%s\t%s\n", definition.Name, definition.Description)
+	}
+	w.Flush()
+	return ExitOK
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yahao333/GoManus/internal/testconfig"
+	"github.com/yahao333/GoManus/pkg/schema"
+)
+
+// captureStdout 执行 fn 并返回其写入标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestToolsListJSON(t *testing.T) {
+	var code int
+	output := captureStdout(t, func() { code = runToolsCommand([]string{"list", "--json"}) })
+	if code != 0 {
+		t.Fatalf("exit code = %d", code)
+	}
+
+	var definitions []schema.ToolDefinition
+	if err := json.Unmarshal([]byte(output), &definitions); err != nil {
+		t.Fatalf("output is not a JSON array of tool definitions: %v\n%s", err, output)
+	}
+	byName := make(map[string]schema.ToolDefinition)
+	for _, definition := range definitions {
+		byName[definition.Name] = definition
+	}
+	for _, name := range []string{"PythonExecute", "StrReplaceEditor", "SimpleBrowser", "AskHuman", "Terminate"} {
+		definition, ok := byName[name]
+		if !ok {
+			t.Errorf("built-in tool %s missing from the list", name)
+			continue
+		}
+		if definition.Description == "" || len(definition.Parameters) == 0 {
+			t.Errorf("%s has no description or parameters: %+v", name, definition)
+		}
+	}
+	if required := byName["Terminate"].Required; len(required) != 1 || required[0] != "message" {
+		t.Errorf("Terminate required = %v", required)
+	}
+}
+
+func TestToolsListFollowsProfile(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[agents.reader]
+tools = ["SimpleBrowser", "Terminate"]
+`)
+	var code int
+	output := captureStdout(t, func() { code = runToolsCommand([]string{"list", "--agent", "reader"}) })
+	if code != 0 {
+		t.Fatalf("exit code = %d", code)
+	}
+
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		names = append(names, strings.Fields(line)[0])
+	}
+	if strings.Join(names, ",") != "SimpleBrowser,Terminate" {
+		t.Errorf("listed tools = %v\n%s", names, output)
+	}
+}
+
+func TestToolsListSortedByName(t *testing.T) {
+	testconfig.Use(t, baseTestConfig+`
+[agents.reader]
+tools = ["Terminate", "SimpleSearch", "SimpleBrowser"]
+`)
+	var code int
+	output := captureStdout(t, func() { code = runToolsCommand([]string{"list", "--json", "--agent", "reader"}) })
+	if code != 0 {
+		t.Fatalf("exit code = %d", code)
+	}
+
+	var definitions []schema.ToolDefinition
+	if err := json.Unmarshal([]byte(output), &definitions); err != nil {
+		t.Fatalf("output is not a JSON array of tool definitions: %v\n%s", err, output)
+	}
+	var names []string
+	for _, definition := range definitions {
+		names = append(names, definition.Name)
+	}
+	if strings.Join(names, ",") != "SimpleBrowser,SimpleSearch,Terminate" {
+		t.Errorf("listed tools = %v, want sorted by name", names)
+	}
+}
+
+func TestToolsCommandErrors(t *testing.T) {
+	for _, args := range [][]string{nil, {"remove"}, {"list", "--agent", "nobody"}} {
+		if code := runToolsCommand(args); code == 0 {
+			t.Errorf("%v: exit code = 0, want failure", args)
+		}
+	}
+}